@@ -34,6 +34,7 @@ func init() {
 	rootCmd.AddCommand(addCmd)
 
 	persistentFlags := addCmd.PersistentFlags()
+	persistentFlags.BoolVar(&config.add.options.Create, "create", false, "add files that should exist, irrespective of their contents")
 	persistentFlags.BoolVarP(&config.add.options.Empty, "empty", "e", false, "add empty files")
 	persistentFlags.BoolVar(&config.add.options.Encrypt, "encrypt", false, "encrypt files")
 	persistentFlags.BoolVarP(&config.add.force, "force", "f", false, "overwrite source state, even if template would be lost")
@@ -80,7 +81,7 @@ func (c *Config) runAddCmd(cmd *cobra.Command, args []string) (err error) {
 				if err != nil {
 					return err
 				}
-				if ts.TargetIgnore.Match(strings.TrimPrefix(path, destDirPrefix)) {
+				if ts.TargetIgnore.Match(strings.TrimPrefix(path, destDirPrefix), info.IsDir()) {
 					cmd.Printf("warning: %s: skipping file ignored by .chezmoiignore\n", path)
 					return nil
 				}
@@ -114,7 +115,16 @@ func (c *Config) runAddCmd(cmd *cobra.Command, args []string) (err error) {
 				return err
 			}
 		} else {
-			if ts.TargetIgnore.Match(strings.TrimPrefix(path, destDirPrefix)) {
+			var info os.FileInfo
+			if c.Follow {
+				info, err = c.fs.Stat(path)
+			} else {
+				info, err = c.fs.Lstat(path)
+			}
+			if err != nil {
+				return err
+			}
+			if ts.TargetIgnore.Match(strings.TrimPrefix(path, destDirPrefix), info.IsDir()) {
 				cmd.Printf("warning: %s: skipping file ignored by .chezmoiignore\n", path)
 				continue
 			}
@@ -265,6 +265,43 @@ func TestAddCommand(t *testing.T) {
 				),
 			},
 		},
+		{
+			name: "add_create_file",
+			args: []string{"/home/user/.gitconfig.local"},
+			add: addCmdConfig{
+				options: chezmoi.AddOptions{
+					Create: true,
+				},
+			},
+			root: map[string]interface{}{
+				"/home/user":                      &vfst.Dir{Perm: 0o755},
+				"/home/user/.local/share/chezmoi": &vfst.Dir{Perm: 0o700},
+				"/home/user/.gitconfig.local":     "# contents of .gitconfig.local\n",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/create_dot_gitconfig.local",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("# contents of .gitconfig.local\n"),
+				),
+			},
+		},
+		{
+			name: "readd_create_file",
+			args: []string{"/home/user/.gitconfig.local"},
+			root: map[string]interface{}{
+				"/home/user/.gitconfig.local":                                "# new contents of .gitconfig.local\n",
+				"/home/user/.local/share/chezmoi/create_dot_gitconfig.local": "# contents of .gitconfig.local\n",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/create_dot_gitconfig.local",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("# new contents of .gitconfig.local\n"),
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_gitconfig.local",
+					vfst.TestDoesNotExist,
+				),
+			},
+		},
 		{
 			name: "add_empty_file",
 			args: []string{"/home/user/empty"},
@@ -1,7 +1,16 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/spf13/cobra"
+	vfs "github.com/twpayne/go-vfs"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 var applyCmd = &cobra.Command{
@@ -13,9 +22,20 @@ var applyCmd = &cobra.Command{
 	RunE:    config.runApplyCmd,
 }
 
+type applyCmdConfig struct {
+	format          string
+	plan            string
+	rollbackOnError bool
+}
+
 func init() {
 	rootCmd.AddCommand(applyCmd)
 
+	persistentFlags := applyCmd.PersistentFlags()
+	persistentFlags.StringVarP(&config.apply.format, "format", "f", "", "write plan in format (JSON, TOML, or YAML), requires --dry-run")
+	persistentFlags.StringVar(&config.apply.plan, "plan", "", "apply plan file created by chezmoi plan")
+	persistentFlags.BoolVar(&config.apply.rollbackOnError, "rollback-on-error", false, "undo changes if any change fails (best effort)")
+
 	markRemainingZshCompPositionalArgumentsAsFiles(applyCmd, 1)
 }
 
@@ -26,5 +46,80 @@ func (c *Config) runApplyCmd(cmd *cobra.Command, args []string) error {
 	}
 	defer persistentState.Close()
 
-	return c.applyArgs(args, persistentState)
+	if c.apply.plan != "" {
+		if len(args) != 0 {
+			return errors.New("cannot specify targets with --plan")
+		}
+		return c.applyPlan(c.apply.plan, persistentState)
+	}
+
+	if c.apply.format == "" {
+		return c.applyArgs(args, persistentState)
+	}
+
+	format, ok := formatMap[strings.ToLower(c.apply.format)]
+	if !ok {
+		return fmt.Errorf("%s: unknown format", c.apply.format)
+	}
+	if !c.DryRun {
+		return errors.New("--format requires --dry-run")
+	}
+	planMutator := chezmoi.NewPlanMutator(c.mutator)
+	c.mutator = planMutator
+	if err := c.applyArgs(args, persistentState); err != nil {
+		return err
+	}
+	return format(c.Stdout, planMutator.Plan())
+}
+
+// applyPlan performs exactly the operations recorded in the plan in filename,
+// if and only if none of the destination paths that they modify have changed
+// since the plan was created.
+func (c *Config) applyPlan(filename string, persistentState chezmoi.PersistentState) error {
+	data, err := c.fs.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var plan chezmoi.Plan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+
+	destPaths := make([]string, 0, len(plan.DestHashes))
+	for destPath := range plan.DestHashes {
+		destPaths = append(destPaths, destPath)
+	}
+	sort.Strings(destPaths)
+	for _, destPath := range destPaths {
+		destHash, err := chezmoi.DestHash(c.fs, destPath)
+		if err != nil {
+			return err
+		}
+		if destHash != plan.DestHashes[destPath] {
+			return fmt.Errorf("%s: changed since plan was created", destPath)
+		}
+	}
+
+	fs := vfs.NewReadOnlyFS(c.fs)
+	applyOptions := &chezmoi.ApplyOptions{
+		DestDir:           c.DestDir,
+		DryRun:            c.DryRun,
+		PersistentState:   persistentState,
+		ScriptStateBucket: c.scriptStateBucket,
+		Stdout:            c.Stdout,
+		Verbose:           c.Verbose,
+	}
+	if c.DryRun {
+		return plan.Apply(fs, c.mutator, applyOptions)
+	}
+
+	// Back up all destination paths before they are changed.
+	backupDir, err := chezmoi.NextBackupGenerationDir(c.fs, c.getBackupsDir())
+	if err != nil {
+		return err
+	}
+	if err := plan.Apply(fs, chezmoi.NewBackupMutator(c.mutator, c.fs, backupDir), applyOptions); err != nil {
+		return err
+	}
+	return c.pruneBackups()
 }
@@ -3,9 +3,14 @@
 package cmd
 
 import (
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strings"
+	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/twpayne/go-vfs/vfst"
 )
 
@@ -74,6 +79,30 @@ func getApplyScriptTestCases(tempDir string) []scriptTestCase {
 				),
 			},
 		},
+		{
+			name: "before_and_after",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"run_after_a":  "#!/bin/sh\necho after >>" + filepath.Join(tempDir, "evidence") + "\n",
+					"run_b":        "#!/bin/sh\necho during >>" + filepath.Join(tempDir, "evidence") + "\n",
+					"run_before_c": "#!/bin/sh\necho before >>" + filepath.Join(tempDir, "evidence") + "\n",
+					"dir": map[string]interface{}{
+						"run_before_d": "#!/bin/sh\necho nested before >>" + filepath.Join(tempDir, "evidence") + "\n",
+					},
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath(filepath.Join(tempDir, "evidence"),
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString(strings.Repeat(strings.Join([]string{
+						"before\n",
+						"nested before\n",
+						"during\n",
+						"after\n",
+					}, ""), 3)),
+				),
+			},
+		},
 	}
 }
 
@@ -82,3 +111,72 @@ func getRunOnceFiles() map[string]interface{} {
 		"/home/user/.local/share/chezmoi/run_once_foo.tmpl": "#!/bin/sh\necho bar >> {{ .TempFile }}\n",
 	}
 }
+
+func TestApplyRunOnChange(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "chezmoi")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tempDir))
+	}()
+	tempFile := filepath.Join(tempDir, "foo")
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi/run_onchange_foo.tmpl": "#!/bin/sh\necho {{ .Value }} >> {{ .TempFile }}\n",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	for _, tc := range []struct {
+		value string
+		want  string
+	}{
+		{value: "a", want: "a\n"},
+		{value: "a", want: "a\n"},
+		{value: "b", want: "a\nb\n"},
+		{value: "a", want: "a\nb\na\n"},
+	} {
+		c := newTestConfig(
+			fs,
+			withDestDir("/"),
+			withData(map[string]interface{}{
+				"TempFile": tempFile,
+				"Value":    tc.value,
+			}),
+		)
+		require.NoError(t, c.runApplyCmd(nil, nil))
+		actualData, err := ioutil.ReadFile(tempFile)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, string(actualData))
+	}
+}
+
+func TestApplyRollbackOnErrorScript(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".bashrc": "# old contents of .bashrc\n",
+			".local/share/chezmoi": map[string]interface{}{
+				"dot_bashrc":        "# contents of .bashrc\n",
+				"dot_hgrc":          "# contents of .hgrc\n",
+				"run_after_fail.sh": "#!/bin/sh\nexit 1\n",
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	c := newTestConfig(
+		fs,
+		withApplyCmdConfig(applyCmdConfig{
+			rollbackOnError: true,
+		}),
+	)
+	assert.Error(t, c.runApplyCmd(nil, nil))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestContentsString("# old contents of .bashrc\n"),
+		),
+		vfst.TestPath("/home/user/.hgrc",
+			vfst.TestDoesNotExist,
+		),
+	)
+}
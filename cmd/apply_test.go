@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -10,6 +12,8 @@ import (
 	"github.com/stretchr/testify/require"
 	vfs "github.com/twpayne/go-vfs"
 	"github.com/twpayne/go-vfs/vfst"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 type scriptTestCase struct {
@@ -183,6 +187,112 @@ func TestApplyCommand(t *testing.T) {
 	}
 }
 
+func TestApplyDryRunFormat(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".bashrc": "# old contents of .bashrc\n",
+			".local/share/chezmoi": map[string]interface{}{
+				"dot_bashrc": "# contents of .bashrc\n",
+				"dot_hgrc":   "# contents of .hgrc\n",
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	stdout := &bytes.Buffer{}
+	c := newTestConfig(
+		fs,
+		withApplyCmdConfig(applyCmdConfig{
+			format: "json",
+		}),
+		withDryRun(true),
+		withMutator(chezmoi.NullMutator{}),
+		withStdout(stdout),
+	)
+	require.NoError(t, c.runApplyCmd(nil, nil))
+
+	var plan chezmoi.Plan
+	require.NoError(t, json.NewDecoder(stdout).Decode(&plan))
+	assert.Equal(t, chezmoi.Plan{
+		Operations: []chezmoi.PlanOperation{
+			{
+				Type:     chezmoi.PlanOperationWriteFile,
+				Name:     filepath.Join("/", "home", "user", ".bashrc"),
+				Mode:     0o644,
+				Contents: []byte("# contents of .bashrc\n"),
+			},
+			{
+				Type:     chezmoi.PlanOperationWriteFile,
+				Name:     filepath.Join("/", "home", "user", ".hgrc"),
+				Mode:     0o644,
+				Contents: []byte("# contents of .hgrc\n"),
+			},
+		},
+	}, plan)
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestContentsString("# old contents of .bashrc\n"),
+		),
+		vfst.TestPath("/home/user/.hgrc",
+			vfst.TestDoesNotExist,
+		),
+	)
+
+	c.DryRun = false
+	assert.Error(t, c.runApplyCmd(nil, nil))
+}
+
+func TestApplyRollbackOnError(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		rollbackOnError bool
+		tests           []vfst.Test
+	}{
+		{
+			name:            "no_rollback",
+			rollbackOnError: false,
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.a",
+					vfst.TestContentsString("# contents of .a\n"),
+				),
+			},
+		},
+		{
+			name:            "rollback",
+			rollbackOnError: true,
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.a",
+					vfst.TestContentsString("# old contents of .a\n"),
+				),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".a": "# old contents of .a\n",
+					".local/share/chezmoi": map[string]interface{}{
+						"dot_a":      "# contents of .a\n",
+						"dot_b.tmpl": "{{ fail \"error\" }}",
+					},
+				},
+			})
+			require.NoError(t, err)
+			defer cleanup()
+
+			c := newTestConfig(
+				fs,
+				withApplyCmdConfig(applyCmdConfig{
+					rollbackOnError: tc.rollbackOnError,
+				}),
+			)
+			assert.Error(t, c.runApplyCmd(nil, nil))
+			vfst.RunTests(t, fs, "", tc.tests)
+		})
+	}
+}
+
 func TestApplyFollow(t *testing.T) {
 	for _, tc := range []struct {
 		name   string
@@ -338,6 +448,39 @@ func TestApplyRemove(t *testing.T) {
 				),
 			},
 		},
+		{
+			name: "dont_remove_nested",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/.chezmoiremove": "foo",
+				"/home/user/foo":     "# contents of foo\n",
+				"/home/user/dir/foo": "# contents of dir/foo\n",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/foo",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/dir/foo",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("# contents of dir/foo\n"),
+				),
+			},
+		},
+		{
+			name: "remove_nested_with_double_star",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/.chezmoiremove": "**/foo",
+				"/home/user/foo":     "# contents of foo\n",
+				"/home/user/dir/foo": "# contents of dir/foo\n",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/foo",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/dir/foo",
+					vfst.TestDoesNotExist,
+				),
+			},
+		},
 		{
 			name: "remove_subdirectory_first",
 			root: map[string]interface{}{
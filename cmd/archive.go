@@ -1,21 +1,23 @@
 package cmd
 
 import (
-	"archive/tar"
 	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 type archiveCmdConfig struct {
+	format string
 	output string
 }
 
 var archiveCmd = &cobra.Command{
 	Use:     "archive",
 	Args:    cobra.NoArgs,
-	Short:   "Write a tar archive of the target state to stdout",
+	Short:   "Write an archive of the target state to stdout",
 	Long:    mustGetLongHelp("archive"),
 	Example: getExample("archive"),
 	PreRunE: config.ensureNoError,
@@ -26,28 +28,35 @@ func init() {
 	rootCmd.AddCommand(archiveCmd)
 
 	persistentFlags := archiveCmd.PersistentFlags()
+	persistentFlags.StringVarP(&config.archive.format, "format", "f", "", "format ("+strings.Join(chezmoi.ArchiveFormats, ", ")+")")
 	persistentFlags.StringVarP(&config.archive.output, "output", "o", "", "output filename")
 	panicOnError(archiveCmd.MarkPersistentFlagFilename("output"))
 }
 
 func (c *Config) runArchiveCmd(cmd *cobra.Command, args []string) error {
+	format := c.archive.format
+	if format == "" && c.archive.output != "" {
+		format = chezmoi.GuessArchiveFormat(c.archive.output)
+	}
+	if format == "" {
+		format = chezmoi.ArchiveFormatTar
+	}
+
 	ts, err := c.getTargetState(nil)
 	if err != nil {
 		return err
 	}
 
-	output := &strings.Builder{}
-	w := tar.NewWriter(output)
-	if err := ts.Archive(w, os.FileMode(c.Umask)); err != nil {
-		return err
+	if c.archive.output == "" {
+		return ts.Archive(c.Stdout, format, os.FileMode(c.Umask))
 	}
-	if err := w.Close(); err != nil {
+	f, err := c.fs.Create(c.archive.output)
+	if err != nil {
 		return err
 	}
-
-	if c.archive.output == "" {
-		_, err := c.Stdout.Write([]byte(output.String()))
+	if err := ts.Archive(f, format, os.FileMode(c.Umask)); err != nil {
+		_ = f.Close()
 		return err
 	}
-	return c.fs.WriteFile(c.archive.output, []byte(output.String()), 0o666)
+	return f.Close()
 }
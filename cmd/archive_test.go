@@ -11,6 +11,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/twpayne/go-vfs/vfst"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 func TestArchiveCmd(t *testing.T) {
@@ -47,3 +49,44 @@ func TestArchiveCmd(t *testing.T) {
 	_, err = r.Next()
 	assert.Equal(t, err, io.EOF)
 }
+
+func TestArchiveImportCmdFormats(t *testing.T) {
+	for _, format := range []string{
+		chezmoi.ArchiveFormatTar,
+		chezmoi.ArchiveFormatTarGz,
+		chezmoi.ArchiveFormatTarXz,
+		chezmoi.ArchiveFormatTarZst,
+		chezmoi.ArchiveFormatZip,
+	} {
+		t.Run(format, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user/.local/share/chezmoi/dir/executable_file": "#!/bin/sh\n",
+				"/home/user/.local/share/chezmoi/symlink_symlink":     "target",
+			})
+			require.NoError(t, err)
+			defer cleanup()
+
+			c := newTestConfig(fs)
+			c.archive.output = "/home/user/archive." + format
+			require.NoError(t, c.runArchiveCmd(nil, nil))
+			require.NoError(t, c.fs.RemoveAll("/home/user/.local/share/chezmoi"))
+			require.NoError(t, c.fs.Mkdir("/home/user/.local/share/chezmoi", 0o700))
+
+			c = newTestConfig(fs)
+			require.NoError(t, c.runImportCmd(nil, []string{"/home/user/archive." + format}))
+			vfst.RunTests(t, fs, "",
+				vfst.TestPath("/home/user/.local/share/chezmoi/dir",
+					vfst.TestIsDir,
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/dir/executable_file",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("#!/bin/sh\n"),
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/symlink_symlink",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("target"),
+				),
+			)
+		})
+	}
+}
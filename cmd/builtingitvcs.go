@@ -0,0 +1,418 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	vfs "github.com/twpayne/go-vfs"
+
+	"github.com/twpayne/chezmoi/internal/git"
+)
+
+// builtinVCSCommand is the value of sourceVCS.command that selects the
+// in-process git implementation.
+const builtinVCSCommand = "builtin"
+
+// errBuiltinGitNonFastForward is returned when the builtin VCS would have to
+// merge or rebase, which it cannot do.
+var errBuiltinGitNonFastForward = errors.New("non-fast-forward, use the git command to merge or rebase")
+
+// A builtinGitVCS is a vcsDriver for git that is implemented in-process with
+// go-git, so no git binary is required. If dryRun is true then it does not
+// change any repository.
+type builtinGitVCS struct {
+	fs     vfs.FS
+	dryRun bool
+}
+
+// Add adds all changes in dir, including deletions, to the index.
+func (v builtinGitVCS) Add(dir string) error {
+	if v.dryRun {
+		return nil
+	}
+	_, worktree, err := v.open(dir)
+	if err != nil {
+		return err
+	}
+	if err := worktree.AddWithOptions(&gogit.AddOptions{All: true}); err != nil {
+		return err
+	}
+	// go-git's AddWithOptions only walks files that exist, so explicitly
+	// remove deleted files from the index.
+	status, err := worktree.Status()
+	if err != nil {
+		return err
+	}
+	for path, fileStatus := range status {
+		if fileStatus.Worktree == gogit.Deleted {
+			if _, err := worktree.Remove(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// CheckoutUpstream writes the files of the fetched upstream commit of the repo
+// in dir to dst in v.fs. Submodules are not checked out.
+func (v builtinGitVCS) CheckoutUpstream(dir, dst string) error {
+	repo, _, err := v.open(dir)
+	if err != nil {
+		return err
+	}
+	_, upstream, err := builtinGitHeadAndUpstream(repo)
+	if err != nil {
+		return err
+	}
+	commit, err := repo.CommitObject(upstream)
+	if err != nil {
+		return err
+	}
+	files, err := commit.Files()
+	if err != nil {
+		return err
+	}
+	return files.ForEach(func(file *object.File) error {
+		path := filepath.Join(dst, filepath.FromSlash(file.Name))
+		if err := vfs.MkdirAll(v.fs, filepath.Dir(path), 0o777); err != nil {
+			return err
+		}
+		contents, err := file.Contents()
+		if err != nil {
+			return err
+		}
+		switch file.Mode {
+		case filemode.Symlink:
+			return v.fs.Symlink(contents, path)
+		case filemode.Executable:
+			return v.fs.WriteFile(path, []byte(contents), 0o777)
+		default:
+			return v.fs.WriteFile(path, []byte(contents), 0o666)
+		}
+	})
+}
+
+// Clone clones repo into dir, including submodules.
+func (v builtinGitVCS) Clone(repo, dir string, options vcsCloneOptions) error {
+	if v.dryRun {
+		return nil
+	}
+	rawDir, err := v.fs.RawPath(dir)
+	if err != nil {
+		return err
+	}
+	cloneOptions := &gogit.CloneOptions{
+		URL:               repo,
+		Depth:             options.Depth,
+		RecurseSubmodules: gogit.DefaultSubmoduleRecursionDepth,
+	}
+	if options.Branch != "" {
+		cloneOptions.ReferenceName = plumbing.NewBranchReferenceName(options.Branch)
+		cloneOptions.SingleBranch = true
+	}
+	_, err = gogit.PlainClone(rawDir, false, cloneOptions)
+	return err
+}
+
+// Commit commits the index in dir with message. The author is read from the
+// git configuration.
+func (v builtinGitVCS) Commit(dir, message string) error {
+	if v.dryRun {
+		return nil
+	}
+	_, worktree, err := v.open(dir)
+	if err != nil {
+		return err
+	}
+	_, err = worktree.Commit(message, &gogit.CommitOptions{})
+	return err
+}
+
+// Fetch fetches changes from the default remote without changing dir.
+func (v builtinGitVCS) Fetch(dir string) error {
+	repo, _, err := v.open(dir)
+	if err != nil {
+		return err
+	}
+	if err := repo.Fetch(&gogit.FetchOptions{}); err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return err
+	}
+	return nil
+}
+
+// Git returns true.
+func (builtinGitVCS) Git() bool {
+	return true
+}
+
+// IncomingLog returns the fetched upstream commits that are not in dir, one
+// per line in the same format as git log --oneline.
+func (v builtinGitVCS) IncomingLog(dir string) ([]byte, error) {
+	repo, _, err := v.open(dir)
+	if err != nil {
+		return nil, err
+	}
+	head, upstream, err := builtinGitHeadAndUpstream(repo)
+	if err != nil {
+		return nil, err
+	}
+	// If upstream is already in HEAD then there are no incoming commits.
+	upstreamInHead, err := builtinGitIsAncestor(repo, upstream, head)
+	if err != nil || upstreamInHead {
+		return nil, err
+	}
+	commits, err := repo.Log(&gogit.LogOptions{
+		From: upstream,
+	})
+	if err != nil {
+		return nil, err
+	}
+	b := &bytes.Buffer{}
+	foundHead := false
+	if err := commits.ForEach(func(commit *object.Commit) error {
+		if commit.Hash == head {
+			foundHead = true
+			return storer.ErrStop
+		}
+		fmt.Fprintf(b, "%s %s\n", commit.Hash.String()[:7], strings.SplitN(commit.Message, "\n", 2)[0])
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if !foundHead {
+		return nil, fmt.Errorf("%s: %w", dir, errBuiltinGitNonFastForward)
+	}
+	return b.Bytes(), nil
+}
+
+// Init creates a new repository in dir.
+func (v builtinGitVCS) Init(dir string) error {
+	if v.dryRun {
+		return nil
+	}
+	rawDir, err := v.fs.RawPath(dir)
+	if err != nil {
+		return err
+	}
+	_, err = gogit.PlainInit(rawDir, false)
+	return err
+}
+
+// Initialized returns true if dir contains a git repository.
+func (v builtinGitVCS) Initialized(dir string) (bool, error) {
+	switch info, err := v.fs.Stat(filepath.Join(dir, ".git")); {
+	case err == nil:
+		return info.IsDir(), nil
+	case os.IsNotExist(err):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// Merge fast-forwards dir to the fetched upstream.
+func (v builtinGitVCS) Merge(dir string) error {
+	return v.Pull(dir)
+}
+
+// Pull fetches changes from the default remote and fast-forwards dir to them,
+// then updates any submodules. Only fast-forwards are supported: go-git cannot
+// merge or rebase, so Pull returns errBuiltinGitNonFastForward if the local
+// branch has diverged from its upstream.
+func (v builtinGitVCS) Pull(dir string) error {
+	if v.dryRun {
+		return nil
+	}
+	_, worktree, err := v.open(dir)
+	if err != nil {
+		return err
+	}
+	switch err := worktree.Pull(&gogit.PullOptions{
+		RecurseSubmodules: gogit.DefaultSubmoduleRecursionDepth,
+	}); {
+	case err == nil:
+	case errors.Is(err, gogit.NoErrAlreadyUpToDate):
+	case errors.Is(err, transport.ErrEmptyRemoteRepository):
+		return nil
+	case errors.Is(err, gogit.ErrNonFastForwardUpdate):
+		return fmt.Errorf("%s: %w", dir, errBuiltinGitNonFastForward)
+	default:
+		return err
+	}
+	submodules, err := worktree.Submodules()
+	if err != nil {
+		return err
+	}
+	return submodules.Update(&gogit.SubmoduleUpdateOptions{
+		Init:              true,
+		RecurseSubmodules: gogit.DefaultSubmoduleRecursionDepth,
+	})
+}
+
+// Push pushes dir to the default remote.
+func (v builtinGitVCS) Push(dir string) error {
+	if v.dryRun {
+		return nil
+	}
+	repo, _, err := v.open(dir)
+	if err != nil {
+		return err
+	}
+	if err := repo.Push(&gogit.PushOptions{}); err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return err
+	}
+	return nil
+}
+
+// RemoveUpstreamCheckout does nothing because CheckoutUpstream does not record
+// anything in the repo.
+func (builtinGitVCS) RemoveUpstreamCheckout(dir, dst string) error {
+	return nil
+}
+
+// Run returns an error because the builtin VCS cannot run arbitrary commands.
+func (builtinGitVCS) Run(dir string, args []string) error {
+	return fmt.Errorf("%s: running arbitrary commands not supported", builtinVCSCommand)
+}
+
+// Status returns the status of dir as a *git.Status, in the same form as
+// git.ParseStatusPorcelainV2.
+func (v builtinGitVCS) Status(dir string) (interface{}, error) {
+	_, worktree, err := v.open(dir)
+	if err != nil {
+		return nil, err
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return nil, err
+	}
+	return builtinGitStatus(status), nil
+}
+
+// builtinGitHeadAndUpstream returns the hashes of HEAD and of the fetched
+// upstream branch of HEAD in repo.
+func builtinGitHeadAndUpstream(repo *gogit.Repository) (plumbing.Hash, plumbing.Hash, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return plumbing.ZeroHash, plumbing.ZeroHash, err
+	}
+	if !head.Name().IsBranch() {
+		return plumbing.ZeroHash, plumbing.ZeroHash, errors.New("HEAD is not a branch")
+	}
+	upstreamName := plumbing.NewRemoteReferenceName(gogit.DefaultRemoteName, head.Name().Short())
+	upstream, err := repo.Reference(upstreamName, true)
+	if err != nil {
+		return plumbing.ZeroHash, plumbing.ZeroHash, fmt.Errorf("%s: %w", upstreamName, err)
+	}
+	return head.Hash(), upstream.Hash(), nil
+}
+
+// builtinGitIsAncestor returns true if ancestor is an ancestor of, or the same
+// as, hash in repo.
+func builtinGitIsAncestor(repo *gogit.Repository, ancestor, hash plumbing.Hash) (bool, error) {
+	commits, err := repo.Log(&gogit.LogOptions{
+		From: hash,
+	})
+	if err != nil {
+		return false, err
+	}
+	found := false
+	if err := commits.ForEach(func(commit *object.Commit) error {
+		if commit.Hash == ancestor {
+			found = true
+			return storer.ErrStop
+		}
+		return nil
+	}); err != nil {
+		return false, err
+	}
+	return found, nil
+}
+
+// doctorCheck returns a check that is always skipped because the builtin VCS
+// does not need a binary.
+func (builtinGitVCS) doctorCheck() *doctorBinaryCheck {
+	return &doctorBinaryCheck{
+		name: "source VCS command",
+	}
+}
+
+// open opens the repository in dir and its worktree.
+func (v builtinGitVCS) open(dir string) (*gogit.Repository, *gogit.Worktree, error) {
+	rawDir, err := v.fs.RawPath(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	repo, err := gogit.PlainOpen(rawDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, nil, err
+	}
+	return repo, worktree, nil
+}
+
+// builtinGitStatus converts a go-git status into a git.Status.
+func builtinGitStatus(status gogit.Status) *git.Status {
+	paths := make([]string, 0, len(status))
+	for path := range status {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	result := &git.Status{}
+	for _, path := range paths {
+		fileStatus := status[path]
+		x, y := builtinGitStatusCode(fileStatus.Staging), builtinGitStatusCode(fileStatus.Worktree)
+		switch {
+		case fileStatus.Staging == gogit.Untracked || fileStatus.Worktree == gogit.Untracked:
+			result.Untracked = append(result.Untracked, git.UntrackedStatus{
+				Path: path,
+			})
+		case fileStatus.Staging == gogit.UpdatedButUnmerged || fileStatus.Worktree == gogit.UpdatedButUnmerged:
+			result.Unmerged = append(result.Unmerged, git.UnmergedStatus{
+				X:    x,
+				Y:    y,
+				Path: path,
+			})
+		case fileStatus.Staging == gogit.Renamed || fileStatus.Staging == gogit.Copied:
+			result.RenamedOrCopied = append(result.RenamedOrCopied, git.RenamedOrCopiedStatus{
+				X:        x,
+				Y:        y,
+				RC:       x,
+				Path:     path,
+				OrigPath: fileStatus.Extra,
+			})
+		case x == '.' && y == '.':
+		default:
+			result.Ordinary = append(result.Ordinary, git.OrdinaryStatus{
+				X:    x,
+				Y:    y,
+				Path: path,
+			})
+		}
+	}
+	return result
+}
+
+// builtinGitStatusCode converts a go-git status code into a porcelain v2
+// status code.
+func builtinGitStatusCode(statusCode gogit.StatusCode) byte {
+	if statusCode == gogit.Unmodified {
+		return '.'
+	}
+	return byte(statusCode)
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	gogit "github.com/go-git/go-git/v5"
+	gogitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+
+	"github.com/twpayne/chezmoi/internal/git"
+)
+
+func TestBuiltinGitStatus(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		status   gogit.Status
+		expected *git.Status
+	}{
+		{
+			name:     "empty",
+			status:   gogit.Status{},
+			expected: &git.Status{},
+		},
+		{
+			name: "ordinary",
+			status: gogit.Status{
+				"dot_bashrc": &gogit.FileStatus{Staging: gogit.Modified, Worktree: gogit.Unmodified},
+				"dot_vimrc":  &gogit.FileStatus{Staging: gogit.Added, Worktree: gogit.Unmodified},
+				"dot_zshrc":  &gogit.FileStatus{Staging: gogit.Deleted, Worktree: gogit.Unmodified},
+			},
+			expected: &git.Status{
+				Ordinary: []git.OrdinaryStatus{
+					{X: 'M', Y: '.', Path: "dot_bashrc"},
+					{X: 'A', Y: '.', Path: "dot_vimrc"},
+					{X: 'D', Y: '.', Path: "dot_zshrc"},
+				},
+			},
+		},
+		{
+			name: "renamed",
+			status: gogit.Status{
+				"dot_new": &gogit.FileStatus{Staging: gogit.Renamed, Worktree: gogit.Unmodified, Extra: "dot_old"},
+			},
+			expected: &git.Status{
+				RenamedOrCopied: []git.RenamedOrCopiedStatus{
+					{X: 'R', Y: '.', RC: 'R', Path: "dot_new", OrigPath: "dot_old"},
+				},
+			},
+		},
+		{
+			name: "unmerged_and_untracked",
+			status: gogit.Status{
+				"dot_bashrc": &gogit.FileStatus{Staging: gogit.UpdatedButUnmerged, Worktree: gogit.UpdatedButUnmerged},
+				"dot_vimrc":  &gogit.FileStatus{Staging: gogit.Untracked, Worktree: gogit.Untracked},
+			},
+			expected: &git.Status{
+				Unmerged: []git.UnmergedStatus{
+					{X: 'U', Y: 'U', Path: "dot_bashrc"},
+				},
+				Untracked: []git.UntrackedStatus{
+					{Path: "dot_vimrc"},
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, builtinGitStatus(tc.status))
+		})
+	}
+}
+
+func TestBuiltinGitVCS(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			"upstream": map[string]interface{}{
+				"dot_bashrc": "# contents of .bashrc\n",
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	rawBareDir, err := fs.RawPath("/home/user/repo.git")
+	require.NoError(t, err)
+	rawUpstreamDir, err := fs.RawPath("/home/user/upstream")
+	require.NoError(t, err)
+	rawSourceDir, err := fs.RawPath("/home/user/.local/share/chezmoi")
+	require.NoError(t, err)
+
+	vcs := builtinGitVCS{
+		fs: fs,
+	}
+
+	// Create an upstream repo with a single commit and push it to a local bare
+	// repo.
+	_, err = gogit.PlainInit(rawBareDir, true)
+	require.NoError(t, err)
+	require.NoError(t, vcs.Init("/home/user/upstream"))
+	upstreamRepo, err := gogit.PlainOpen(rawUpstreamDir)
+	require.NoError(t, err)
+	setBuiltinGitTestUser(t, upstreamRepo)
+	_, err = upstreamRepo.CreateRemote(&gogitconfig.RemoteConfig{
+		Name: gogit.DefaultRemoteName,
+		URLs: []string{rawBareDir},
+	})
+	require.NoError(t, err)
+	require.NoError(t, vcs.Add("/home/user/upstream"))
+	require.NoError(t, vcs.Commit("/home/user/upstream", "Initial commit\n"))
+	require.NoError(t, vcs.Push("/home/user/upstream"))
+
+	sourceVCS := sourceVCSConfig{
+		Command:  builtinVCSCommand,
+		AutoPush: true,
+	}
+
+	// Clone the bare repo into the source directory.
+	require.NoError(t, newTestConfig(fs, withSourceVCS(sourceVCS)).runInitCmd(nil, []string{rawBareDir}))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/share/chezmoi/.git",
+			vfst.TestIsDir,
+		),
+		vfst.TestPath("/home/user/.local/share/chezmoi/dot_bashrc",
+			vfst.TestContentsString("# contents of .bashrc\n"),
+		),
+	)
+
+	// Push a change upstream and pull it into the source directory.
+	require.NoError(t, fs.WriteFile("/home/user/upstream/dot_bashrc", []byte("# new contents of .bashrc\n"), 0o666))
+	require.NoError(t, vcs.Add("/home/user/upstream"))
+	require.NoError(t, vcs.Commit("/home/user/upstream", "Update dot_bashrc\n"))
+	require.NoError(t, vcs.Push("/home/user/upstream"))
+	require.NoError(t, newTestConfig(fs, withSourceVCS(sourceVCS)).runUpdateCmd(nil, nil))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/share/chezmoi/dot_bashrc",
+			vfst.TestContentsString("# new contents of .bashrc\n"),
+		),
+	)
+
+	// Auto commit and push a change from the source directory and pull it
+	// upstream.
+	sourceRepo, err := gogit.PlainOpen(rawSourceDir)
+	require.NoError(t, err)
+	setBuiltinGitTestUser(t, sourceRepo)
+	require.NoError(t, fs.WriteFile("/home/user/.local/share/chezmoi/dot_vimrc", []byte("# contents of .vimrc\n"), 0o666))
+	require.NoError(t, fs.Remove("/home/user/.local/share/chezmoi/dot_bashrc"))
+	require.NoError(t, newTestConfig(fs, withSourceVCS(sourceVCS)).autoCommitAndAutoPush(nil, nil))
+	status, err := vcs.Status("/home/user/.local/share/chezmoi")
+	require.NoError(t, err)
+	assert.True(t, status.(*git.Status).Empty())
+	require.NoError(t, vcs.Pull("/home/user/upstream"))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/upstream/dot_bashrc",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/upstream/dot_vimrc",
+			vfst.TestContentsString("# contents of .vimrc\n"),
+		),
+	)
+	head, err := upstreamRepo.Head()
+	require.NoError(t, err)
+	commit, err := upstreamRepo.CommitObject(head.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, "Remove .bashrc\nAdd .vimrc\n", commit.Message)
+
+	// Check out the fetched upstream through fs.
+	require.NoError(t, fs.WriteFile("/home/user/upstream/dot_zshrc", []byte("# contents of .zshrc\n"), 0o666))
+	require.NoError(t, vcs.Add("/home/user/upstream"))
+	require.NoError(t, vcs.Commit("/home/user/upstream", "Add dot_zshrc\n"))
+	require.NoError(t, vcs.Push("/home/user/upstream"))
+	require.NoError(t, vcs.Fetch("/home/user/.local/share/chezmoi"))
+	require.NoError(t, vcs.CheckoutUpstream("/home/user/.local/share/chezmoi", "/home/user/checkout"))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/checkout/dot_vimrc",
+			vfst.TestContentsString("# contents of .vimrc\n"),
+		),
+		vfst.TestPath("/home/user/checkout/dot_zshrc",
+			vfst.TestContentsString("# contents of .zshrc\n"),
+		),
+	)
+
+	// Pulling diverged changes is an error.
+	require.NoError(t, fs.WriteFile("/home/user/.local/share/chezmoi/dot_vimrc", []byte("# new contents of .vimrc\n"), 0o666))
+	require.NoError(t, vcs.Add("/home/user/.local/share/chezmoi"))
+	require.NoError(t, vcs.Commit("/home/user/.local/share/chezmoi", "Update dot_vimrc\n"))
+	assert.True(t, errors.Is(vcs.Pull("/home/user/.local/share/chezmoi"), errBuiltinGitNonFastForward))
+}
+
+func setBuiltinGitTestUser(t *testing.T, repo *gogit.Repository) {
+	cfg, err := repo.Config()
+	require.NoError(t, err)
+	cfg.User.Name = "Test User"
+	cfg.User.Email = "user@example.com"
+	require.NoError(t, repo.SetConfig(cfg))
+}
@@ -26,6 +26,9 @@ var chattrCmd = &cobra.Command{
 type boolModifier int
 
 type attributeModifiers struct {
+	after      boolModifier
+	before     boolModifier
+	create     boolModifier
 	empty      boolModifier
 	encrypted  boolModifier
 	exact      boolModifier
@@ -38,6 +41,9 @@ func init() {
 	rootCmd.AddCommand(chattrCmd)
 
 	attributes := []string{
+		"after", "a",
+		"before", "b",
+		"create",
 		"empty", "e",
 		"encrypted",
 		"exact",
@@ -64,7 +70,7 @@ func (c *Config) runChattrCmd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	entries, err := c.getEntries(ts, args[1:])
+	entries, err := c.getSourceEntries(ts, args[1:])
 	if err != nil {
 		return err
 	}
@@ -90,7 +96,7 @@ func (c *Config) runChattrCmd(cmd *cobra.Command, args []string) error {
 				}
 			}
 		case *chezmoi.File:
-			fa := chezmoi.ParseFileAttributes(oldBase)
+			fa := chezmoi.ParseFileAttributes(oldBase, ts.Encryption.EncryptedSuffix())
 			mode := os.FileMode(0o666)
 			if executable := ams.executable.modify(entry.Executable()); executable {
 				mode |= 0o111
@@ -99,41 +105,55 @@ func (c *Config) runChattrCmd(cmd *cobra.Command, args []string) error {
 				mode &= 0o700
 			}
 			fa.Mode = mode
+			fa.Create = ams.create.modify(entry.Create)
+			if fa.Create && fa.Modify {
+				return fmt.Errorf("%s: modify scripts cannot have the create attribute", entry.TargetName())
+			}
 			fa.Encrypted = ams.encrypted.modify(entry.Encrypted)
 			fa.Empty = ams.empty.modify(entry.Empty)
 			fa.Template = ams.template.modify(entry.Template)
-			newpath := filepath.Join(ts.SourceDir, dir, fa.SourceName())
+			newpath := filepath.Join(ts.SourceDir, dir, fa.SourceName(ts.Encryption.EncryptedSuffix()))
 			if fa.Encrypted != entry.Encrypted {
-				oldContents, err := c.fs.ReadFile(filepath.Join(c.SourceDir, entry.SourceName()))
+				update, err := c.newChattrEncryptedUpdate(ts.Encryption, entry.TargetName(), oldpath, newpath, fa.Encrypted)
 				if err != nil {
 					return err
 				}
-				var newContents []byte
-				if fa.Encrypted {
-					newContents, err = ts.GPG.Encrypt(entry.TargetName(), oldContents)
-				} else {
-					newContents, err = ts.GPG.Decrypt(entry.TargetName(), oldContents)
+				updates[oldpath] = update
+			} else if newpath != oldpath {
+				updates[oldpath] = func() error {
+					return c.mutator.Rename(oldpath, newpath)
 				}
+			}
+		case *chezmoi.Script:
+			sa := chezmoi.ParseScriptAttributes(oldBase, ts.Encryption.EncryptedSuffix())
+			sa.After = ams.after.modify(entry.After)
+			sa.Before = ams.before.modify(entry.Before)
+			// Scripts cannot run both before and after all other entries, so
+			// adding one attribute removes the other.
+			switch {
+			case ams.after > 0:
+				sa.Before = false
+			case ams.before > 0:
+				sa.After = false
+			}
+			sa.Encrypted = ams.encrypted.modify(entry.Encrypted)
+			sa.Template = ams.template.modify(entry.Template)
+			newpath := filepath.Join(ts.SourceDir, dir, sa.SourceName(ts.Encryption.EncryptedSuffix()))
+			if sa.Encrypted != entry.Encrypted {
+				update, err := c.newChattrEncryptedUpdate(ts.Encryption, entry.TargetName(), oldpath, newpath, sa.Encrypted)
 				if err != nil {
 					return err
 				}
-				updates[oldpath] = func() error {
-					// FIXME replace file and contents atomically, see
-					// https://github.com/google/renameio/issues/16.
-					if err := c.mutator.WriteFile(newpath, newContents, 0o644, oldContents); err != nil {
-						return err
-					}
-					return c.mutator.RemoveAll(oldpath)
-				}
+				updates[oldpath] = update
 			} else if newpath != oldpath {
 				updates[oldpath] = func() error {
 					return c.mutator.Rename(oldpath, newpath)
 				}
 			}
 		case *chezmoi.Symlink:
-			fa := chezmoi.ParseFileAttributes(oldBase)
+			fa := chezmoi.ParseFileAttributes(oldBase, ts.Encryption.EncryptedSuffix())
 			fa.Template = ams.template.modify(entry.Template)
-			newBase := fa.SourceName()
+			newBase := fa.SourceName(ts.Encryption.EncryptedSuffix())
 			if newBase != oldBase {
 				newpath := filepath.Join(ts.SourceDir, dir, newBase)
 				updates[oldpath] = func() error {
@@ -160,6 +180,32 @@ func (c *Config) runChattrCmd(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// newChattrEncryptedUpdate returns a function that replaces oldpath with
+// newpath, encrypting or decrypting its contents.
+func (c *Config) newChattrEncryptedUpdate(encryption chezmoi.Encryption, targetName, oldpath, newpath string, encrypt bool) (func() error, error) {
+	oldContents, err := c.fs.ReadFile(oldpath)
+	if err != nil {
+		return nil, err
+	}
+	var newContents []byte
+	if encrypt {
+		newContents, err = encryption.Encrypt(targetName, oldContents)
+	} else {
+		newContents, err = encryption.Decrypt(targetName, oldContents)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return func() error {
+		// FIXME replace file and contents atomically, see
+		// https://github.com/google/renameio/issues/16.
+		if err := c.mutator.WriteFile(newpath, newContents, 0o644, oldContents); err != nil {
+			return err
+		}
+		return c.mutator.RemoveAll(oldpath)
+	}, nil
+}
+
 func parseAttributeModifiers(s string) (*attributeModifiers, error) {
 	ams := &attributeModifiers{}
 	for _, attributeModifier := range strings.Split(s, ",") {
@@ -184,6 +230,12 @@ func parseAttributeModifiers(s string) (*attributeModifiers, error) {
 			attribute = attributeModifier
 		}
 		switch attribute {
+		case "after", "a":
+			ams.after = modifier
+		case "before", "b":
+			ams.before = modifier
+		case "create":
+			ams.create = modifier
 		case "empty", "e":
 			ams.empty = modifier
 		case "encrypted":
@@ -200,6 +252,9 @@ func parseAttributeModifiers(s string) (*attributeModifiers, error) {
 			return nil, fmt.Errorf("%s: unknown attribute", attribute)
 		}
 	}
+	if ams.after > 0 && ams.before > 0 {
+		return nil, fmt.Errorf("%s: after and before are mutually exclusive", s)
+	}
 	return ams, nil
 }
 
@@ -83,6 +83,42 @@ func TestChattrCommand(t *testing.T) {
 				),
 			},
 		},
+		{
+			name: "file_add_create",
+			args: []string{"+create", "/home/user/.gitconfig.local"},
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"private_dot_gitconfig.local": "# contents of ~/.gitconfig.local\n",
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/private_dot_gitconfig.local",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/create_private_dot_gitconfig.local",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("# contents of ~/.gitconfig.local\n"),
+				),
+			},
+		},
+		{
+			name: "file_remove_create",
+			args: []string{"-create", "/home/user/.gitconfig.local"},
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"create_dot_gitconfig.local": "# contents of ~/.gitconfig.local\n",
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/create_dot_gitconfig.local",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_gitconfig.local",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("# contents of ~/.gitconfig.local\n"),
+				),
+			},
+		},
 		{
 			name: "file_add_empty",
 			args: []string{"+empty", "/home/user/foo"},
@@ -247,6 +283,60 @@ func TestChattrCommand(t *testing.T) {
 				),
 			},
 		},
+		{
+			name: "script_add_template",
+			args: []string{"+template", "/home/user/install.sh"},
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"run_once_install.sh": "#!/bin/sh\n",
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/run_once_install.sh",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/run_once_install.sh.tmpl",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("#!/bin/sh\n"),
+				),
+			},
+		},
+		{
+			name: "script_add_before",
+			args: []string{"+before", "/home/user/install.sh"},
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"run_once_after_install.sh": "#!/bin/sh\n",
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/run_once_after_install.sh",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/run_once_before_install.sh",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("#!/bin/sh\n"),
+				),
+			},
+		},
+		{
+			name: "script_remove_after",
+			args: []string{"-after", "/home/user/install.sh"},
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"run_after_install.sh": "#!/bin/sh\n",
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/run_after_install.sh",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/run_install.sh",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("#!/bin/sh\n"),
+				),
+			},
+		},
 		{
 			name: "symlink_add_template",
 			args: []string{"+template", "/home/user/foo"},
@@ -301,6 +391,14 @@ func TestParseAttributeModifiers(t *testing.T) {
 		want    *attributeModifiers
 		wantErr bool
 	}{
+		{s: "after", want: &attributeModifiers{after: 1}},
+		{s: "-a", want: &attributeModifiers{after: -1}},
+		{s: "before", want: &attributeModifiers{before: 1}},
+		{s: "nob", want: &attributeModifiers{before: -1}},
+		{s: "after,before", wantErr: true},
+		{s: "-after,+before", want: &attributeModifiers{after: -1, before: 1}},
+		{s: "create", want: &attributeModifiers{create: 1}},
+		{s: "nocreate", want: &attributeModifiers{create: -1}},
 		{s: "empty", want: &attributeModifiers{empty: 1}},
 		{s: "+empty", want: &attributeModifiers{empty: 1}},
 		{s: "-empty", want: &attributeModifiers{empty: -1}},
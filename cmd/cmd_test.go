@@ -1,10 +1,12 @@
 package cmd
 
 import (
+	"bytes"
 	"os"
 	"runtime"
 	"testing"
 
+	"filippo.io/age"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/twpayne/go-vfs/vfst"
@@ -110,3 +112,70 @@ func TestExercise(t *testing.T) {
 		)
 	})
 }
+
+// TestExerciseAGE exercises commands that use age encryption.
+func TestExerciseAGE(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.config/chezmoi/key.txt": identity.String() + "\n",
+		"/home/user/.netrc": &vfst.File{
+			Perm:     0o600,
+			Contents: []byte("# contents of .netrc\n"),
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	identityFile, err := fs.RawPath("/home/user/.config/chezmoi/key.txt")
+	require.NoError(t, err)
+	stdout := &bytes.Buffer{}
+	c := newTestConfig(
+		fs,
+		withStdout(stdout),
+		func(c *Config) {
+			c.Encryption = "age"
+			c.AGE.Identity = identityFile
+		},
+	)
+
+	// chezmoi add --encrypt ~/.netrc
+	t.Run("chezmoi_add_encrypt_netrc", func(t *testing.T) {
+		c.add.options.Encrypt = true
+		defer func() {
+			c.add.options.Encrypt = false
+		}()
+		assert.NoError(t, c.runAddCmd(nil, []string{"/home/user/.netrc"}))
+		path := "/home/user/.local/share/chezmoi/encrypted_private_dot_netrc.age"
+		// Private files are not supported on Windows.
+		if runtime.GOOS == "windows" {
+			path = "/home/user/.local/share/chezmoi/encrypted_dot_netrc.age"
+		}
+		vfst.RunTests(t, fs, "",
+			vfst.TestPath(path,
+				vfst.TestModeIsRegular,
+			),
+		)
+		contents, err := fs.ReadFile(path)
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(contents, []byte("-----BEGIN AGE ENCRYPTED FILE-----\n")))
+	})
+
+	// chezmoi cat ~/.netrc
+	t.Run("chezmoi_cat_netrc", func(t *testing.T) {
+		stdout.Reset()
+		assert.NoError(t, c.runCatCmd(nil, []string{"/home/user/.netrc"}))
+		assert.Equal(t, "# contents of .netrc\n", stdout.String())
+	})
+
+	// chezmoi chattr -encrypted ~/.netrc
+	t.Run("chezmoi_chattr_netrc", func(t *testing.T) {
+		assert.NoError(t, c.runChattrCmd(nil, []string{"-encrypted,-private", "/home/user/.netrc"}))
+		vfst.RunTests(t, fs, "",
+			vfst.TestPath("/home/user/.local/share/chezmoi/dot_netrc",
+				vfst.TestModeIsRegular,
+				vfst.TestContentsString("# contents of .netrc\n"),
+			),
+		)
+	})
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+	"github.com/twpayne/chezmoi/internal/git"
+	"github.com/twpayne/chezmoi/internal/hg"
+)
+
+// commitMessageTemplateName is the name of the optional commit message
+// template in the source directory.
+const commitMessageTemplateName = ".chezmoicommitmessage" + chezmoi.TemplateSuffix
+
+// A commitMessageChange is a change to a single file in the source directory.
+type commitMessageChange struct {
+	Kind           string // One of "added", "deleted", "modified", or "renamed".
+	Path           string
+	OrigPath       string
+	TargetName     string
+	OrigTargetName string
+}
+
+// commitMessageData is the data passed to the commit message template. It
+// embeds the git status so that templates can still use the raw status.
+type commitMessageData struct {
+	*git.Status
+	Changes []commitMessageChange
+}
+
+// Name returns the target name of the change if it has one, otherwise its
+// path in the source directory.
+func (c commitMessageChange) Name() string {
+	if c.TargetName != "" {
+		return c.TargetName
+	}
+	return c.Path
+}
+
+// OrigName returns the original name of a renamed change.
+func (c commitMessageChange) OrigName() string {
+	if c.OrigTargetName != "" {
+		return c.OrigTargetName
+	}
+	return c.OrigPath
+}
+
+// getCommitMessageTemplate returns the commit message template. The
+// sourceVCS.commitMessageTemplate config variable takes precedence over the
+// template in the source directory, which in turn takes precedence over the
+// default template.
+func (c *Config) getCommitMessageTemplate() (string, string, error) {
+	if c.SourceVCS.CommitMessageTemplate != "" {
+		return "sourceVCS.commitMessageTemplate", c.SourceVCS.CommitMessageTemplate, nil
+	}
+	filename := filepath.Join(c.SourceDir, commitMessageTemplateName)
+	switch data, err := c.fs.ReadFile(filename); {
+	case err == nil:
+		return filename, string(data), nil
+	case !os.IsNotExist(err):
+		return "", "", err
+	}
+	data, err := getAsset(commitMessageTemplateAsset)
+	if err != nil {
+		return "", "", err
+	}
+	return commitMessageTemplateAsset, string(data), nil
+}
+
+// newCommitMessageData returns the commit message data for status.
+func newCommitMessageData(status *git.Status, encryptedSuffix string) (*commitMessageData, error) {
+	data := &commitMessageData{
+		Status: status,
+	}
+	for _, s := range status.Ordinary {
+		var kind string
+		switch xy := string([]byte{s.X, s.Y}); xy {
+		case "A.":
+			kind = "added"
+		case "D.":
+			kind = "deleted"
+		case "M.", "T.":
+			kind = "modified"
+		default:
+			return nil, fmt.Errorf("%s: unsupported XY: %q", s.Path, xy)
+		}
+		data.Changes = append(data.Changes, commitMessageChange{
+			Kind:       kind,
+			Path:       s.Path,
+			TargetName: commitMessageTargetName(s.Path, encryptedSuffix),
+		})
+	}
+	for _, s := range status.RenamedOrCopied {
+		if xy := string([]byte{s.X, s.Y}); xy != "R." {
+			return nil, fmt.Errorf("%s: unsupported XY: %q", s.Path, xy)
+		}
+		data.Changes = append(data.Changes, commitMessageChange{
+			Kind:           "renamed",
+			Path:           s.Path,
+			OrigPath:       s.OrigPath,
+			TargetName:     commitMessageTargetName(s.Path, encryptedSuffix),
+			OrigTargetName: commitMessageTargetName(s.OrigPath, encryptedSuffix),
+		})
+	}
+	return data, nil
+}
+
+// newHgCommitMessageData returns the commit message data for the Mercurial
+// status status. An added file whose copy source was removed is reported as a
+// rename. Untracked files are reported in the embedded git status so that the
+// same templates work for both git and Mercurial.
+func newHgCommitMessageData(status *hg.Status, encryptedSuffix string) (*commitMessageData, error) {
+	data := &commitMessageData{
+		Status: &git.Status{},
+	}
+	if len(status.Missing) != 0 {
+		return nil, fmt.Errorf("%s: missing file", status.Missing[0].Path)
+	}
+	for _, fileStatus := range status.NotTracked {
+		data.Untracked = append(data.Untracked, git.UntrackedStatus{
+			Path: fileStatus.Path,
+		})
+	}
+	renamedFrom := make(map[string]bool)
+	for _, fileStatus := range status.Added {
+		if fileStatus.Source == "" {
+			continue
+		}
+		for _, removed := range status.Removed {
+			if removed.Path == fileStatus.Source {
+				renamedFrom[fileStatus.Source] = true
+			}
+		}
+	}
+	for _, fileStatus := range status.Added {
+		change := commitMessageChange{
+			Kind:       "added",
+			Path:       fileStatus.Path,
+			TargetName: commitMessageTargetName(fileStatus.Path, encryptedSuffix),
+		}
+		if renamedFrom[fileStatus.Source] {
+			change.Kind = "renamed"
+			change.OrigPath = fileStatus.Source
+			change.OrigTargetName = commitMessageTargetName(fileStatus.Source, encryptedSuffix)
+		}
+		data.Changes = append(data.Changes, change)
+	}
+	for _, fileStatus := range status.Removed {
+		if renamedFrom[fileStatus.Path] {
+			continue
+		}
+		data.Changes = append(data.Changes, commitMessageChange{
+			Kind:       "deleted",
+			Path:       fileStatus.Path,
+			TargetName: commitMessageTargetName(fileStatus.Path, encryptedSuffix),
+		})
+	}
+	for _, fileStatus := range status.Modified {
+		data.Changes = append(data.Changes, commitMessageChange{
+			Kind:       "modified",
+			Path:       fileStatus.Path,
+			TargetName: commitMessageTargetName(fileStatus.Path, encryptedSuffix),
+		})
+	}
+	return data, nil
+}
+
+// commitMessageTargetName returns the target name of path, which is a path
+// reported by git, or the empty string if path does not correspond to a
+// target.
+func commitMessageTargetName(path, encryptedSuffix string) string {
+	targetName, ok := chezmoi.SourcePathTargetName(filepath.FromSlash(path), encryptedSuffix)
+	if !ok {
+		return ""
+	}
+	return filepath.ToSlash(targetName)
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+
+	"github.com/twpayne/chezmoi/internal/hg"
+)
+
+func TestGetCommitMessageTemplate(t *testing.T) {
+	defaultText, err := getAsset(commitMessageTemplateAsset)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name         string
+		root         interface{}
+		sourceVCS    sourceVCSConfig
+		expectedText string
+	}{
+		{
+			name: "default",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": &vfst.Dir{Perm: 0o700},
+			},
+			expectedText: string(defaultText),
+		},
+		{
+			name: "source_dir",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/.chezmoicommitmessage.tmpl": "source dir template",
+			},
+			expectedText: "source dir template",
+		},
+		{
+			name: "config",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/.chezmoicommitmessage.tmpl": "source dir template",
+			},
+			sourceVCS: sourceVCSConfig{
+				CommitMessageTemplate: "config template",
+			},
+			expectedText: "config template",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+
+			c := newTestConfig(fs, withSourceVCS(tc.sourceVCS))
+			_, actualText, err := c.getCommitMessageTemplate()
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedText, actualText)
+		})
+	}
+}
+
+func TestHgCommitMessage(t *testing.T) {
+	commitMessageText, err := getAsset(commitMessageTemplateAsset)
+	require.NoError(t, err)
+	commitMessageTmpl, err := template.New("commit_message").Funcs(sprig.TxtFuncMap()).Parse(string(commitMessageText))
+	require.NoError(t, err)
+	for _, tc := range []struct {
+		name            string
+		outputStr       string
+		wantErr         bool
+		expectedMessage string
+	}{
+		{
+			name:            "add",
+			outputStr:       "A dot_zshrc\n",
+			expectedMessage: "Add .zshrc\n",
+		},
+		{
+			name:            "remove",
+			outputStr:       "R dot_zshrc\n",
+			expectedMessage: "Remove .zshrc\n",
+		},
+		{
+			name:            "update",
+			outputStr:       "M private_dot_config/starship.toml\n",
+			expectedMessage: "Update .config/starship.toml\n",
+		},
+		{
+			name:            "rename",
+			outputStr:       "A dot_bashrc\n  dot_bash_profile\nR dot_bash_profile\n",
+			expectedMessage: "Rename .bash_profile to .bashrc\n",
+		},
+		{
+			name:            "copy",
+			outputStr:       "A dot_bashrc\n  dot_bash_profile\n",
+			expectedMessage: "Add .bashrc\n",
+		},
+		{
+			name:      "missing",
+			outputStr: "! dot_zshrc\n",
+			wantErr:   true,
+		},
+		{
+			name:      "untracked",
+			outputStr: "? dot_zshrc\n",
+			wantErr:   true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			status, err := hg.ParseStatus([]byte(tc.outputStr))
+			require.NoError(t, err)
+			b := &bytes.Buffer{}
+			data, err := newHgCommitMessageData(status, ".asc")
+			if err == nil {
+				err = commitMessageTmpl.Execute(b, data)
+			}
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedMessage, b.String())
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"os"
 	"os/exec"
 	"os/user"
@@ -13,6 +14,7 @@ import (
 	"regexp"
 	"runtime"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 	"unicode"
@@ -24,10 +26,12 @@ import (
 	vfs "github.com/twpayne/go-vfs"
 	xdg "github.com/twpayne/go-xdg/v3"
 	bolt "go.etcd.io/bbolt"
+	"golang.org/x/term"
 	yaml "gopkg.in/yaml.v2"
 
 	"github.com/twpayne/chezmoi/internal/chezmoi"
 	"github.com/twpayne/chezmoi/internal/git"
+	"github.com/twpayne/chezmoi/internal/hg"
 )
 
 const commitMessageTemplateAsset = "assets/templates/COMMIT_MESSAGE.tmpl"
@@ -35,18 +39,23 @@ const commitMessageTemplateAsset = "assets/templates/COMMIT_MESSAGE.tmpl"
 var whitespaceRegexp = regexp.MustCompile(`\s+`)
 
 type sourceVCSConfig struct {
-	Command    string
-	AutoCommit bool
-	AutoPush   bool
-	Init       interface{}
-	NotGit     bool
-	Pull       interface{}
+	Command               string
+	AutoCommit            bool
+	AutoPush              bool
+	CommitMessageTemplate string
+	Init                  interface{}
+	NotGit                bool
+	Pull                  interface{}
 }
 
 type templateConfig struct {
 	Options []string
 }
 
+type backupsConfig struct {
+	Keep int
+}
+
 // A Config represents a configuration.
 type Config struct {
 	configFile        string
@@ -56,16 +65,22 @@ type Config struct {
 	SourceDir         string
 	DestDir           string
 	Umask             permValue
+	Concurrency       int
 	DryRun            bool
 	Follow            bool
 	Remove            bool
+	RunModifyScripts  bool
 	Verbose           bool
 	Color             string
 	Debug             bool
+	Encryption        string
+	AGE               chezmoi.AGE
+	EncryptionCommand chezmoi.CommandEncryption
 	GPG               chezmoi.GPG
 	GPGRecipient      string
 	SourceVCS         sourceVCSConfig
 	Template          templateConfig
+	Backups           backupsConfig
 	Merge             mergeConfig
 	Bitwarden         bitwardenCmdConfig
 	CD                cdCmdConfig
@@ -82,6 +97,7 @@ type Config struct {
 	maxDiffDataSize   int
 	templateFuncs     template.FuncMap
 	add               addCmdConfig
+	apply             applyCmdConfig
 	archive           archiveCmdConfig
 	completion        completionCmdConfig
 	data              dataCmdConfig
@@ -92,16 +108,25 @@ type Config struct {
 	init              initCmdConfig
 	keyring           keyringCmdConfig
 	managed           managedCmdConfig
+	plan              planCmdConfig
 	purge             purgeCmdConfig
+	rollback          rollbackCmdConfig
 	remove            removeCmdConfig
+	state             stateCmdConfig
 	update            updateCmdConfig
 	upgrade           upgradeCmdConfig
 	Stdin             io.Reader
 	Stdout            io.Writer
 	Stderr            io.Writer
 	bds               *xdg.BaseDirectorySpecification
+	stateHome         string
 	scriptStateBucket []byte
 
+	// agePassphraseMutex serializes prompting for the age passphrase, as
+	// entries are evaluated concurrently.
+	agePassphraseMutex sync.Mutex
+	agePassphrase      *string
+
 	//nolint:structcheck,unused
 	ioregData ioregData
 }
@@ -139,20 +164,25 @@ var (
 // newConfig creates a new Config with the given options.
 func newConfig(options ...configOption) *Config {
 	c := &Config{
-		Umask: permValue(chezmoi.GetUmask()),
-		Color: "auto",
+		Umask:       permValue(chezmoi.GetUmask()),
+		Concurrency: runtime.NumCPU(),
+		Color:       "auto",
 		SourceVCS: sourceVCSConfig{
 			Command: "git",
 		},
 		Template: templateConfig{
 			Options: chezmoi.DefaultTemplateOptions,
 		},
+		Backups: backupsConfig{
+			Keep: 10,
+		},
 		Diff: diffCmdConfig{
 			Format: "chezmoi",
 		},
 		Merge: mergeConfig{
 			Command: "vimdiff",
 		},
+		Encryption: "gpg",
 		GPG: chezmoi.GPG{
 			Command: "gpg",
 		},
@@ -180,70 +210,161 @@ func (c *Config) addTemplateFunc(key string, value interface{}) {
 }
 
 func (c *Config) applyArgs(args []string, persistentState chezmoi.PersistentState) error {
+	// Only download stale externals when actually applying changes, other
+	// commands use the cached externals.
+	var populateOptions *chezmoi.PopulateOptions
+	switch {
+	case !c.DryRun:
+		populateOptions = &chezmoi.PopulateOptions{
+			ExecuteTemplates: true,
+			RefreshExternals: true,
+		}
+	case !c.RunModifyScripts:
+		// Modify scripts are user code, so do not run them when only
+		// previewing changes unless asked to.
+		populateOptions = &chezmoi.PopulateOptions{
+			ExecuteTemplates:  true,
+			SkipModifyScripts: true,
+		}
+	}
+	return c.applyArgsWithPopulateOptions(args, persistentState, populateOptions)
+}
+
+// applyArgsWithPopulateOptions is like applyArgs but populates the target
+// state with populateOptions.
+func (c *Config) applyArgsWithPopulateOptions(args []string, persistentState chezmoi.PersistentState, populateOptions *chezmoi.PopulateOptions) error {
 	fs := vfs.NewReadOnlyFS(c.fs)
-	ts, err := c.getTargetState(nil)
+	ts, err := c.getTargetState(populateOptions)
 	if err != nil {
 		return err
 	}
-	applyOptions := &chezmoi.ApplyOptions{
-		DestDir:           ts.DestDir,
-		DryRun:            c.DryRun,
-		Ignore:            ts.TargetIgnore.Match,
-		PersistentState:   persistentState,
-		Remove:            c.Remove,
-		ScriptStateBucket: c.scriptStateBucket,
-		Stdout:            c.Stdout,
-		Umask:             ts.Umask,
-		Verbose:           c.Verbose,
+
+	var entries []chezmoi.Entry
+	if len(args) != 0 {
+		entries, err = c.getEntries(ts, args)
+		if err != nil {
+			return err
+		}
 	}
-	if len(args) == 0 {
-		return ts.Apply(fs, c.mutator, c.Follow, applyOptions)
+
+	apply := func(mutator chezmoi.Mutator, dryRun, verbose bool) error {
+		applyOptions := &chezmoi.ApplyOptions{
+			DestDir:           ts.DestDir,
+			DryRun:            dryRun,
+			Ignore:            ts.TargetIgnore.Match,
+			PersistentState:   persistentState,
+			Remove:            c.Remove,
+			ScriptStateBucket: c.scriptStateBucket,
+			Stdout:            c.Stdout,
+			Umask:             ts.Umask,
+			Verbose:           verbose,
+		}
+		if len(args) == 0 {
+			return ts.Apply(fs, mutator, c.Follow, applyOptions)
+		}
+		return ts.ApplyEntries(fs, mutator, c.Follow, applyOptions, entries)
 	}
-	entries, err := c.getEntries(ts, args)
+
+	if c.DryRun {
+		return apply(c.mutator, true, c.Verbose)
+	}
+
+	// Back up all destination paths before they are changed.
+	backupDir, err := chezmoi.NextBackupGenerationDir(c.fs, c.getBackupsDir())
 	if err != nil {
 		return err
 	}
-	for _, entry := range entries {
-		if err := entry.Apply(fs, c.mutator, c.Follow, applyOptions); err != nil {
+	backupMutator := chezmoi.NewBackupMutator(c.mutator, c.fs, backupDir)
+
+	if !c.apply.rollbackOnError {
+		if err := apply(backupMutator, false, c.Verbose); err != nil {
 			return err
 		}
+		return c.pruneBackups()
 	}
-	return nil
-}
 
-func (c *Config) autoCommit(vcs VCS) error {
-	addArgs := vcs.AddArgs(".")
-	if addArgs == nil {
-		return fmt.Errorf("%s: autocommit not supported", c.SourceVCS.Command)
+	// With --rollback-on-error, first evaluate all entries and check all
+	// changes with a dry run, so that no changes are made and no scripts are
+	// run if any entry fails. Changes are not staged: they are written directly
+	// to the destination directory and undone from the backup generation on
+	// failure, which is best effort. Scripts are not covered: their side
+	// effects cannot be undone if a later change fails.
+	if len(args) == 0 {
+		err = ts.Evaluate()
+	} else {
+		err = ts.EvaluateEntries(entries)
 	}
-	if err := c.run(c.SourceDir, c.SourceVCS.Command, addArgs...); err != nil {
+	if err != nil {
 		return err
 	}
-	output, err := c.output(c.SourceDir, c.SourceVCS.Command, vcs.StatusArgs()...)
-	if err != nil {
+	if err := apply(chezmoi.NullMutator{}, true, false); err != nil {
 		return err
 	}
-	status, err := vcs.ParseStatusOutput(output)
+
+	// Apply the changes, undoing any changes already made if any step fails.
+	if err := apply(backupMutator, false, c.Verbose); err != nil {
+		if restoreErr := backupMutator.Restore(c.mutator); restoreErr != nil {
+			return fmt.Errorf("%w (undo failed: %v)", err, restoreErr)
+		}
+		return err
+	}
+	return c.pruneBackups()
+}
+
+func (c *Config) autoCommit(vcs vcsDriver) error {
+	status, err := c.addAndGetStatus(vcs)
 	if err != nil {
 		return err
 	}
-	if gitStatus, ok := status.(*git.Status); ok && gitStatus.Empty() {
-		return nil
+	templateData := status
+	switch status := status.(type) {
+	case *git.Status:
+		if status.Empty() {
+			return nil
+		}
+		encryption, err := c.getEncryption()
+		if err != nil {
+			return err
+		}
+		templateData, err = newCommitMessageData(status, encryption.EncryptedSuffix())
+		if err != nil {
+			return err
+		}
+	case *hg.Status:
+		if status.Empty() {
+			return nil
+		}
+		encryption, err := c.getEncryption()
+		if err != nil {
+			return err
+		}
+		templateData, err = newHgCommitMessageData(status, encryption.EncryptedSuffix())
+		if err != nil {
+			return err
+		}
 	}
-	commitMessageText, err := getAsset(commitMessageTemplateAsset)
+	commitMessageTemplateName, commitMessageText, err := c.getCommitMessageTemplate()
 	if err != nil {
 		return err
 	}
-	commitMessageTmpl, err := template.New("commit_message").Funcs(c.templateFuncs).Parse(string(commitMessageText))
+	commitMessageTmpl, err := template.New(commitMessageTemplateName).Funcs(c.templateFuncs).Parse(commitMessageText)
 	if err != nil {
 		return err
 	}
 	sb := &strings.Builder{}
-	if err := commitMessageTmpl.Execute(sb, status); err != nil {
+	if err := commitMessageTmpl.Execute(sb, templateData); err != nil {
 		return err
 	}
-	commitArgs := vcs.CommitArgs(sb.String())
-	return c.run(c.SourceDir, c.SourceVCS.Command, commitArgs...)
+	return vcs.Commit(c.SourceDir, sb.String())
+}
+
+// addAndGetStatus adds all changes in the source directory and returns the
+// resulting status.
+func (c *Config) addAndGetStatus(vcs vcsDriver) (interface{}, error) {
+	if err := vcs.Add(c.SourceDir); err != nil {
+		return nil, err
+	}
+	return vcs.Status(c.SourceDir)
 }
 
 func (c *Config) autoCommitAndAutoPush(cmd *cobra.Command, args []string) error {
@@ -267,12 +388,8 @@ func (c *Config) autoCommitAndAutoPush(cmd *cobra.Command, args []string) error
 	return nil
 }
 
-func (c *Config) autoPush(vcs VCS) error {
-	pushArgs := vcs.PushArgs()
-	if pushArgs == nil {
-		return fmt.Errorf("%s: autopush not supported", c.SourceVCS.Command)
-	}
-	return c.run(c.SourceDir, c.SourceVCS.Command, pushArgs...)
+func (c *Config) autoPush(vcs vcsDriver) error {
+	return vcs.Push(c.SourceDir)
 }
 
 // ensureNoError ensures that no error was encountered when loading c.
@@ -381,6 +498,12 @@ func (c *Config) getDefaultData() (map[string]interface{}, error) {
 		return nil, err
 	}
 
+	logf := func(string, ...interface{}) {}
+	if c.Debug {
+		logf = log.Printf
+	}
+	data["facts"] = getFacts(c.fs, os.Getenv, factCollectors, logf)
+
 	return data, nil
 }
 
@@ -396,6 +519,22 @@ func (c *Config) getEditor() (string, []string) {
 	return components[0], components[1:]
 }
 
+func (c *Config) getEncryption() (chezmoi.Encryption, error) {
+	switch c.Encryption {
+	case "age":
+		if c.AGE.PassphraseFunc == nil {
+			c.AGE.PassphraseFunc = c.readAGEPassphrase
+		}
+		return &c.AGE, nil
+	case "command":
+		return &c.EncryptionCommand, nil
+	case "gpg":
+		return &c.GPG, nil
+	default:
+		return nil, fmt.Errorf("%s: unknown encryption", c.Encryption)
+	}
+}
+
 func (c *Config) getEntries(ts *chezmoi.TargetState, args []string) ([]chezmoi.Entry, error) {
 	entries := []chezmoi.Entry{}
 	for _, arg := range args {
@@ -415,6 +554,38 @@ func (c *Config) getEntries(ts *chezmoi.TargetState, args []string) ([]chezmoi.E
 	return entries, nil
 }
 
+// getSourceEntries returns the entries for args, which must all be backed by
+// files in the source directory.
+func (c *Config) getSourceEntries(ts *chezmoi.TargetState, args []string) ([]chezmoi.Entry, error) {
+	entries, err := c.getEntries(ts, args)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if err := chezmoi.CheckSourceFile(entry); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+func (c *Config) getBackupsDir() string {
+	return filepath.Join(c.stateHome, "chezmoi", "backups")
+}
+
+// pruneBackups removes all but the newest c.Backups.Keep backup generations. If
+// c.Backups.Keep is zero or negative then all generations are kept.
+func (c *Config) pruneBackups() error {
+	if c.Backups.Keep <= 0 {
+		return nil
+	}
+	return chezmoi.PruneBackupGenerations(c.fs, c.getBackupsDir(), c.Backups.Keep)
+}
+
+func (c *Config) getCacheDir() string {
+	return filepath.Join(c.bds.CacheHome, "chezmoi")
+}
+
 func (c *Config) getPersistentState(options *bolt.Options) (chezmoi.PersistentState, error) {
 	persistentStateFile := c.getPersistentStateFile()
 	if options == nil {
@@ -447,8 +618,21 @@ func (c *Config) getPersistentStateFile() string {
 }
 
 func (c *Config) getTargetState(populateOptions *chezmoi.PopulateOptions) (*chezmoi.TargetState, error) {
-	fs := vfs.NewReadOnlyFS(c.fs)
+	ts, err := c.newTargetState()
+	if err != nil {
+		return nil, err
+	}
+	if err := ts.Populate(vfs.NewReadOnlyFS(c.fs), populateOptions); err != nil {
+		return nil, err
+	}
+	if Version != nil && ts.MinVersion != nil && Version.LessThan(*ts.MinVersion) {
+		return nil, fmt.Errorf("chezmoi version %s too old, source state requires at least %s", Version, ts.MinVersion)
+	}
+	return ts, nil
+}
 
+// newTargetState returns a new, empty, target state configured from c.
+func (c *Config) newTargetState() (*chezmoi.TargetState, error) {
 	data, err := c.getData()
 	if err != nil {
 		return nil, err
@@ -467,30 +651,41 @@ func (c *Config) getTargetState(populateOptions *chezmoi.PopulateOptions) (*chez
 		c.GPG.Recipient = c.GPGRecipient
 	}
 
-	ts := chezmoi.NewTargetState(
+	encryption, err := c.getEncryption()
+	if err != nil {
+		return nil, err
+	}
+
+	return chezmoi.NewTargetState(
+		chezmoi.WithCacheDir(c.getCacheDir()),
+		chezmoi.WithCacheFS(c.fs),
+		chezmoi.WithConcurrency(c.Concurrency),
 		chezmoi.WithDestDir(destDir),
-		chezmoi.WithGPG(&c.GPG),
+		chezmoi.WithEncryption(encryption),
 		chezmoi.WithSourceDir(c.SourceDir),
 		chezmoi.WithTemplateData(data),
 		chezmoi.WithTemplateFuncs(c.templateFuncs),
 		chezmoi.WithTemplateOptions(c.Template.Options),
 		chezmoi.WithUmask(os.FileMode(c.Umask)),
-	)
-	if err := ts.Populate(fs, populateOptions); err != nil {
-		return nil, err
-	}
-	if Version != nil && ts.MinVersion != nil && Version.LessThan(*ts.MinVersion) {
-		return nil, fmt.Errorf("chezmoi version %s too old, source state requires at least %s", Version, ts.MinVersion)
-	}
-	return ts, nil
+	), nil
 }
 
-func (c *Config) getVCS() (VCS, error) {
+// getVCS returns the vcsDriver for c's source VCS.
+func (c *Config) getVCS() (vcsDriver, error) {
+	if c.SourceVCS.Command == builtinVCSCommand {
+		return builtinGitVCS{
+			fs:     c.fs,
+			dryRun: c.DryRun,
+		}, nil
+	}
 	vcs, ok := vcses[filepath.Base(c.SourceVCS.Command)]
 	if !ok {
 		return nil, fmt.Errorf("%s: unsupported source VCS command", c.SourceVCS.Command)
 	}
-	return vcs, nil
+	return &execVCSDriver{
+		c:   c,
+		vcs: vcs,
+	}, nil
 }
 
 func (c *Config) output(dir, name string, argv ...string) ([]byte, error) {
@@ -524,6 +719,38 @@ func (c *Config) prompt(s, choices string) (byte, error) {
 	}
 }
 
+// readAGEPassphrase prompts for the age passphrase, remembering it for
+// subsequent calls.
+func (c *Config) readAGEPassphrase() (string, error) {
+	c.agePassphraseMutex.Lock()
+	defer c.agePassphraseMutex.Unlock()
+	if c.agePassphrase != nil {
+		return *c.agePassphrase, nil
+	}
+	if _, err := fmt.Fprint(c.Stderr, "age passphrase: "); err != nil {
+		return "", err
+	}
+	var passphrase string
+	if stdin, ok := c.Stdin.(*os.File); ok && term.IsTerminal(int(stdin.Fd())) {
+		passphraseBytes, err := term.ReadPassword(int(stdin.Fd()))
+		if err != nil {
+			return "", err
+		}
+		if _, err := fmt.Fprintln(c.Stderr); err != nil {
+			return "", err
+		}
+		passphrase = string(passphraseBytes)
+	} else {
+		line, err := bufio.NewReader(c.Stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", err
+		}
+		passphrase = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+	}
+	c.agePassphrase = &passphrase
+	return passphrase, nil
+}
+
 // run runs name argv... in dir.
 func (c *Config) run(dir, name string, argv ...string) error {
 	cmd := exec.Command(name, argv...)
@@ -545,6 +772,15 @@ func (c *Config) runEditor(argv ...string) error {
 	return c.run("", editorName, append(editorArgs, argv...)...)
 }
 
+// sourceVCSLooksLikeGit returns true if c's source VCS is git or, if it is not
+// supported, if its command looks like git.
+func (c *Config) sourceVCSLooksLikeGit() bool {
+	if vcs, err := c.getVCS(); err == nil {
+		return vcs.Git()
+	}
+	return strings.Contains(filepath.Base(c.SourceVCS.Command), "git")
+}
+
 func (c *Config) validateData() error {
 	return validateKeys(config.Data, identifierRegexp)
 }
@@ -583,6 +819,15 @@ func getDefaultSourceDir(bds *xdg.BaseDirectorySpecification) string {
 	return filepath.Join(bds.DataHome, "chezmoi")
 }
 
+// getDefaultStateHome returns the default state home directory, following the
+// XDG Base Directory Specification.
+func getDefaultStateHome(homeDir string) string {
+	if stateHome := os.Getenv("XDG_STATE_HOME"); stateHome != "" {
+		return stateHome
+	}
+	return filepath.Join(homeDir, ".local", "state")
+}
+
 // isWellKnownAbbreviation returns true if word is a well known abbreviation.
 func isWellKnownAbbreviation(word string) bool {
 	_, ok := wellKnownAbbreviations[word]
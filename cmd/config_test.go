@@ -14,6 +14,7 @@ import (
 	xdg "github.com/twpayne/go-xdg/v3"
 
 	"github.com/twpayne/chezmoi/internal/chezmoi"
+	"github.com/twpayne/chezmoi/internal/git"
 )
 
 func TestAutoCommitCommitMessage(t *testing.T) {
@@ -47,6 +48,19 @@ func TestAutoCommitCommitMessage(t *testing.T) {
 			statusStr:       "2 R. N... 100644 100644 100644 9d06c86ecba40e1c695e69b55a40843df6a79cef 9d06c86ecba40e1c695e69b55a40843df6a79cef R100 chezmoi_rename.go\tchezmoi.go\n",
 			expectedMessage: "Rename chezmoi.go to chezmoi_rename.go\n",
 		},
+		{
+			name: "target_names",
+			statusStr: "" +
+				"1 M. N... 100644 100644 100644 353dbbb3c29a80fb44d4e26dac111739d25294db 353dbbb3c29a80fb44d4e26dac111739d25294db dot_zshrc\n" +
+				"1 A. N... 000000 100644 100644 0000000000000000000000000000000000000000 cea5c3500651a923bacd80f960dd20f04f71d509 private_dot_config/starship.toml.tmpl\n" +
+				"1 D. N... 100644 000000 000000 cea5c3500651a923bacd80f960dd20f04f71d509 0000000000000000000000000000000000000000 .chezmoiignore\n" +
+				"2 R. N... 100644 100644 100644 9d06c86ecba40e1c695e69b55a40843df6a79cef 9d06c86ecba40e1c695e69b55a40843df6a79cef R100 dot_bashrc\tdot_bash_profile\n",
+			expectedMessage: "" +
+				"Update .zshrc\n" +
+				"Add .config/starship.toml\n" +
+				"Remove .chezmoiignore\n" +
+				"Rename .bash_profile to .bashrc\n",
+		},
 		{
 			name:      "unsupported_xy",
 			statusStr: "1 MM N... 100644 100644 100644 353dbbb3c29a80fb44d4e26dac111739d25294db 353dbbb3c29a80fb44d4e26dac111739d25294db main.go\n",
@@ -54,20 +68,41 @@ func TestAutoCommitCommitMessage(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			status, err := gitVCS{}.ParseStatusOutput([]byte(tc.statusStr))
+			status, err := git.ParseStatusPorcelainV2([]byte(tc.statusStr))
 			require.NoError(t, err)
-			b := &bytes.Buffer{}
-			err = commitMessageTmpl.Execute(b, status)
+			data, err := newCommitMessageData(status, ".asc")
 			if tc.wantErr {
 				require.Error(t, err)
-			} else {
-				require.NoError(t, err)
-				assert.Equal(t, tc.expectedMessage, b.String())
+				return
 			}
+			require.NoError(t, err)
+			b := &bytes.Buffer{}
+			require.NoError(t, commitMessageTmpl.Execute(b, data))
+			assert.Equal(t, tc.expectedMessage, b.String())
 		})
 	}
 }
 
+func TestReadAGEPassphraseConcurrently(t *testing.T) {
+	c := newTestConfig(
+		vfs.OSFS,
+		withStdin(bytes.NewBufferString("passphrase\n")),
+	)
+	c.Stderr = &bytes.Buffer{}
+	passphrases := make(chan string)
+	for i := 0; i < 8; i++ {
+		go func() {
+			passphrase, err := c.readAGEPassphrase()
+			assert.NoError(t, err)
+			passphrases <- passphrase
+		}()
+	}
+	for i := 0; i < 8; i++ {
+		assert.Equal(t, "passphrase", <-passphrases)
+	}
+	assert.Equal(t, "age passphrase: ", c.Stderr.(*bytes.Buffer).String())
+}
+
 func TestUpperSnakeCaseToCamelCase(t *testing.T) {
 	for s, want := range map[string]string{
 		"BUG_REPORT_URL":   "bugReportURL",
@@ -149,18 +184,36 @@ func withAddCmdConfig(add addCmdConfig) configOption {
 	}
 }
 
+func withApplyCmdConfig(apply applyCmdConfig) configOption {
+	return func(c *Config) {
+		c.apply = apply
+	}
+}
+
 func withData(data map[string]interface{}) configOption {
 	return func(c *Config) {
 		c.Data = data
 	}
 }
 
+func withDataCmdConfig(dataCmdConfig dataCmdConfig) configOption {
+	return func(c *Config) {
+		c.data = dataCmdConfig
+	}
+}
+
 func withDestDir(destDir string) configOption {
 	return func(c *Config) {
 		c.DestDir = destDir
 	}
 }
 
+func withDryRun(dryRun bool) configOption {
+	return func(c *Config) {
+		c.DryRun = dryRun
+	}
+}
+
 func withDumpCmdConfig(dumpCmdConfig dumpCmdConfig) configOption {
 	return func(c *Config) {
 		c.dump = dumpCmdConfig
@@ -185,6 +238,12 @@ func withMutator(mutator chezmoi.Mutator) configOption {
 	}
 }
 
+func withPlanCmdConfig(plan planCmdConfig) configOption {
+	return func(c *Config) {
+		c.plan = plan
+	}
+}
+
 func withRemove(remove bool) configOption {
 	return func(c *Config) {
 		c.Remove = remove
@@ -197,6 +256,24 @@ func withRemoveCmdConfig(remove removeCmdConfig) configOption {
 	}
 }
 
+func withRollbackCmdConfig(rollback rollbackCmdConfig) configOption {
+	return func(c *Config) {
+		c.rollback = rollback
+	}
+}
+
+func withSourceVCS(sourceVCS sourceVCSConfig) configOption {
+	return func(c *Config) {
+		c.SourceVCS = sourceVCS
+	}
+}
+
+func withStateCmdConfig(state stateCmdConfig) configOption {
+	return func(c *Config) {
+		c.state = state
+	}
+}
+
 func withStdin(stdin io.Reader) configOption {
 	return func(c *Config) {
 		c.Stdin = stdin
@@ -228,5 +305,12 @@ func withTestUser(username string) configOption {
 			CacheHome:  filepath.Join(homeDir, ".cache"),
 			RuntimeDir: filepath.Join(homeDir, ".run"),
 		}
+		c.stateHome = filepath.Join(homeDir, ".local", "state")
+	}
+}
+
+func withUpdateCmdConfig(update updateCmdConfig) configOption {
+	return func(c *Config) {
+		c.update = update
 	}
 }
@@ -2,13 +2,16 @@ package cmd
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
+	vfs "github.com/twpayne/go-vfs"
 )
 
 type dataCmdConfig struct {
-	format string
+	format  string
+	sources bool
 }
 
 var dataCmd = &cobra.Command{
@@ -26,6 +29,7 @@ func init() {
 
 	persistentFlags := dataCmd.PersistentFlags()
 	persistentFlags.StringVarP(&config.data.format, "format", "f", "json", "format (JSON, TOML, or YAML)")
+	persistentFlags.BoolVarP(&config.data.sources, "sources", "s", false, "print where each value came from")
 }
 
 func (c *Config) runDataCmd(cmd *cobra.Command, args []string) error {
@@ -33,9 +37,45 @@ func (c *Config) runDataCmd(cmd *cobra.Command, args []string) error {
 	if !ok {
 		return fmt.Errorf("%s: unknown format", c.data.format)
 	}
-	data, err := c.getData()
+	// Only the template data files are needed, so do not read the rest of
+	// the source state.
+	ts, err := c.newTargetState()
 	if err != nil {
 		return err
 	}
-	return format(c.Stdout, data)
+	if err := ts.PopulateTemplateData(vfs.NewReadOnlyFS(c.fs)); err != nil {
+		return err
+	}
+	if !c.data.sources {
+		return format(c.Stdout, ts.TemplateData)
+	}
+	sources := make(map[string]string)
+	for _, path := range appendDataPaths(nil, "", ts.TemplateData) {
+		switch source, ok := ts.TemplateDataSources[path]; {
+		case ok:
+			sources[path] = filepath.Join(c.SourceDir, source)
+		case strings.HasPrefix(path, "chezmoi.") && c.Data["chezmoi"] == nil:
+			sources[path] = "chezmoi"
+		default:
+			sources[path] = c.configFile
+		}
+	}
+	return format(c.Stdout, sources)
+}
+
+// appendDataPaths appends the dot-separated paths of all values in data that
+// are not maps, prefixed by prefix, to paths.
+func appendDataPaths(paths []string, prefix string, data map[string]interface{}) []string {
+	for key, value := range data {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if valueMap, ok := value.(map[string]interface{}); ok {
+			paths = appendDataPaths(paths, path, valueMap)
+		} else {
+			paths = append(paths, path)
+		}
+	}
+	return paths
 }
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestDataCmd(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			".chezmoidata.yaml": "" +
+				"email: john@home.org\n" +
+				"packages:\n" +
+				"  apt:\n" +
+				"  - git\n",
+			".chezmoidata/packages.toml": "" +
+				"[packages]\n" +
+				"  brew = [\"git\", \"jq\"]\n",
+			// The rest of the source state is not read, so errors in it do
+			// not prevent the data from being printed.
+			".chezmoiexternal.toml": "invalid",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	for _, tc := range []struct {
+		name    string
+		sources bool
+		check   func(*testing.T, map[string]interface{})
+	}{
+		{
+			name: "data",
+			check: func(t *testing.T, data map[string]interface{}) {
+				assert.Equal(t, "john.smith@company.com", data["email"])
+				assert.Equal(t, map[string]interface{}{
+					"apt":  []interface{}{"git"},
+					"brew": []interface{}{"git", "jq"},
+				}, data["packages"])
+				assert.Contains(t, data, "chezmoi")
+			},
+		},
+		{
+			name:    "sources",
+			sources: true,
+			check: func(t *testing.T, sources map[string]interface{}) {
+				assert.Equal(t, "", sources["email"])
+				assert.Equal(t, filepath.Join("/", "home", "user", ".local", "share", "chezmoi", ".chezmoidata.yaml"), sources["packages.apt"])
+				assert.Equal(t, filepath.Join("/", "home", "user", ".local", "share", "chezmoi", ".chezmoidata", "packages.toml"), sources["packages.brew"])
+				assert.Equal(t, "chezmoi", sources["chezmoi.os"])
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			c := newTestConfig(
+				fs,
+				withData(map[string]interface{}{
+					"email": "john.smith@company.com",
+				}),
+				withDataCmdConfig(dataCmdConfig{
+					format:  "json",
+					sources: tc.sources,
+				}),
+				withStdout(stdout),
+			)
+			require.NoError(t, c.runDataCmd(nil, nil))
+			var actual map[string]interface{}
+			require.NoError(t, json.Unmarshal(stdout.Bytes(), &actual))
+			tc.check(t, actual)
+		})
+	}
+}
@@ -66,16 +66,7 @@ func (c *Config) runDiffCmd(cmd *cobra.Command, args []string) error {
 	defer persistentState.Close()
 
 	if c.Diff.NoPager || c.Diff.Pager == "" {
-		switch c.Diff.Format {
-		case "chezmoi":
-			c.mutator = chezmoi.NewVerboseMutator(c.Stdout, c.mutator, c.colored, c.maxDiffDataSize)
-		case "git":
-			unifiedEncoder := diff.NewUnifiedEncoder(c.Stdout, diff.DefaultContextLines)
-			if c.colored {
-				unifiedEncoder.SetColor(diff.NewColorConfig())
-			}
-			c.mutator = chezmoi.NewGitDiffMutator(unifiedEncoder, c.mutator, c.DestDir+string(filepath.Separator))
-		}
+		c.mutator = c.newDiffMutator(c.Stdout, c.mutator)
 		return c.applyArgs(args, persistentState)
 	}
 
@@ -102,16 +93,7 @@ func (c *Config) runDiffCmd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	switch c.Diff.Format {
-	case "chezmoi":
-		c.mutator = chezmoi.NewVerboseMutator(pagerStdinPipe, c.mutator, c.colored, c.maxDiffDataSize)
-	case "git":
-		unifiedEncoder := diff.NewUnifiedEncoder(pagerStdinPipe, diff.DefaultContextLines)
-		if c.colored {
-			unifiedEncoder.SetColor(diff.NewColorConfig())
-		}
-		c.mutator = chezmoi.NewGitDiffMutator(unifiedEncoder, c.mutator, c.DestDir+string(filepath.Separator))
-	}
+	c.mutator = c.newDiffMutator(pagerStdinPipe, c.mutator)
 
 	if err := c.applyArgs(args, persistentState); err != nil {
 		return err
@@ -123,3 +105,16 @@ func (c *Config) runDiffCmd(cmd *cobra.Command, args []string) error {
 
 	return pagerCmd.Wait()
 }
+
+// newDiffMutator returns a new Mutator that writes the diff of all changes
+// made through mutator to w in c.Diff.Format.
+func (c *Config) newDiffMutator(w io.Writer, mutator chezmoi.Mutator) chezmoi.Mutator {
+	if c.Diff.Format == "git" {
+		unifiedEncoder := diff.NewUnifiedEncoder(w, diff.DefaultContextLines)
+		if c.colored {
+			unifiedEncoder.SetColor(diff.NewColorConfig())
+		}
+		return chezmoi.NewGitDiffMutator(unifiedEncoder, mutator, c.DestDir+string(filepath.Separator))
+	}
+	return chezmoi.NewVerboseMutator(w, mutator, c.colored, c.maxDiffDataSize)
+}
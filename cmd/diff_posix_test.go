@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -35,3 +36,73 @@ func TestDiffDoesNotRunScript(t *testing.T) {
 		),
 	)
 }
+
+func TestDiffGitFormatIncludesScript(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "chezmoi")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tempDir))
+	}()
+	fs := vfs.NewPathFS(vfs.OSFS, tempDir)
+	require.NoError(t, vfst.NewBuilder().Build(
+		fs,
+		map[string]interface{}{
+			"/home/user/.local/share/chezmoi/run_true": "#!/bin/sh\necho foo >>" + filepath.Join(tempDir, "evidence") + "\n",
+		},
+	))
+	stdout := &strings.Builder{}
+	c := newTestConfig(fs, withStdout(stdout))
+	c.Diff.Format = "git"
+	c.Diff.NoPager = true
+	assert.NoError(t, c.runDiffCmd(nil, nil))
+	assert.Contains(t, stdout.String(), "new file mode 100755\n")
+	assert.Contains(t, stdout.String(), "+++ b/true\n")
+	assert.Contains(t, stdout.String(), "+#!/bin/sh\n")
+	vfst.RunTests(t, vfs.OSFS, "",
+		vfst.TestPath(filepath.Join(tempDir, "evidence"),
+			vfst.TestDoesNotExist,
+		),
+	)
+}
+
+func TestDiffDoesNotRunModifyScript(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		runModifyScripts bool
+		test             vfst.PathTest
+	}{
+		{
+			name: "default",
+			test: vfst.TestDoesNotExist,
+		},
+		{
+			name:             "run_modify_scripts",
+			runModifyScripts: true,
+			test:             vfst.TestModeIsRegular,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir, err := ioutil.TempDir("", "chezmoi")
+			require.NoError(t, err)
+			defer func() {
+				require.NoError(t, os.RemoveAll(tempDir))
+			}()
+			fs := vfs.NewPathFS(vfs.OSFS, tempDir)
+			require.NoError(t, vfst.NewBuilder().Build(
+				fs,
+				map[string]interface{}{
+					"/home/user/.file": "# contents of .file\n",
+					"/home/user/.local/share/chezmoi/modify_dot_file": "#!/bin/sh\necho foo >>" + filepath.Join(tempDir, "evidence") + "\ncat\n",
+				},
+			))
+			c := newTestConfig(fs)
+			c.RunModifyScripts = tc.runModifyScripts
+			assert.NoError(t, c.runDiffCmd(nil, nil))
+			vfst.RunTests(t, vfs.OSFS, "",
+				vfst.TestPath(filepath.Join(tempDir, "evidence"),
+					tc.test,
+				),
+			)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -29,3 +30,30 @@ func TestIssue740(t *testing.T) {
 	c.Diff.Format = "git"
 	assert.NoError(t, c.runDiffCmd(nil, nil))
 }
+
+func TestDiffRemove(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".oldrc": "# contents of .oldrc\n",
+			".local/share/chezmoi": map[string]interface{}{
+				"remove_dot_oldrc":   "",
+				"remove_dot_missing": "",
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	sb := &strings.Builder{}
+	c := newTestConfig(fs, withStdout(sb))
+	c.Diff.Format = "git"
+	require.NoError(t, c.runDiffCmd(nil, nil))
+	assert.Equal(t, strings.Join([]string{
+		"diff --git a/.oldrc b/.oldrc",
+		"deleted file mode 100644",
+		"index 0000000000000000000000000000000000000000..0000000000000000000000000000000000000000",
+		"--- a/.oldrc",
+		"+++ /dev/null",
+		"",
+	}, "\n"), sb.String())
+}
@@ -641,6 +641,7 @@ func init() {
 		"* [Handle configuration files which are externally modified](#handle-configuration-files-which-are-externally-modified)\n" +
 		"* [Handle different file locations on different systems with the same contents](#handle-different-file-locations-on-different-systems-with-the-same-contents)\n" +
 		"* [Keep data private](#keep-data-private)\n" +
+		"  * [Use age to keep your secrets](#use-age-to-keep-your-secrets)\n" +
 		"  * [Use Bitwarden to keep your secrets](#use-bitwarden-to-keep-your-secrets)\n" +
 		"  * [Use gopass to keep your secrets](#use-gopass-to-keep-your-secrets)\n" +
 		"  * [Use gpg to keep your secrets](#use-gpg-to-keep-your-secrets)\n" +
@@ -651,6 +652,7 @@ func init() {
 		"  * [Use pass to keep your secrets](#use-pass-to-keep-your-secrets)\n" +
 		"  * [Use Vault to keep your secrets](#use-vault-to-keep-your-secrets)\n" +
 		"  * [Use a generic tool to keep your secrets](#use-a-generic-tool-to-keep-your-secrets)\n" +
+		"  * [Use a generic encryption command to encrypt files](#use-a-generic-encryption-command-to-encrypt-files)\n" +
 		"  * [Use templates variables to keep your secrets](#use-templates-variables-to-keep-your-secrets)\n" +
 		"* [Use scripts to perform actions](#use-scripts-to-perform-actions)\n" +
 		"  * [Understand how scripts work](#understand-how-scripts-work)\n" +
@@ -660,6 +662,7 @@ func init() {
 		"* [Run a PowerShell script as admin on Windows](#run-a-powershell-script-as-admin-on-windows)\n" +
 		"* [Import archives](#import-archives)\n" +
 		"* [Export archives](#export-archives)\n" +
+		"* [Use chezmoi without a git binary](#use-chezmoi-without-a-git-binary)\n" +
 		"* [Use a non-git version control system](#use-a-non-git-version-control-system)\n" +
 		"* [Customize the `diff` command](#customize-the-diff-command)\n" +
 		"* [Use a merge tool other than vimdiff](#use-a-merge-tool-other-than-vimdiff)\n" +
@@ -719,6 +722,20 @@ func init() {
 		"\n" +
 		"to apply them.\n" +
 		"\n" +
+		"Alternatively, run:\n" +
+		"\n" +
+		"    chezmoi update --interactive\n" +
+		"\n" +
+		"This fetches the latest changes from your repo without merging them, shows the\n" +
+		"incoming commits and the diff that they would make, and asks before pulling and\n" +
+		"applying them. To check for changes from a cron job without changing anything,\n" +
+		"run:\n" +
+		"\n" +
+		"    chezmoi update --fail-if-changes\n" +
+		"\n" +
+		"which exits with a non-zero status if there are incoming changes or if your\n" +
+		"destination directory differs from the latest target state.\n" +
+		"\n" +
 		"## Automatically commit and push changes to your repo\n" +
 		"\n" +
 		"chezmoi can automatically commit and push changes to your source directory to\n" +
@@ -736,6 +753,20 @@ func init() {
 		"changes. If you only set `autoCommit` to true then changes will be committed but\n" +
 		"not pushed.\n" +
 		"\n" +
+		"The commit message lists the targets that were added, updated, removed, or\n" +
+		"renamed. To customize it, create a `.chezmoicommitmessage.tmpl` template in your\n" +
+		"source directory, or set `sourceVCS.commitMessageTemplate` in your config file.\n" +
+		"For example, to generate commit messages like `update ~/.zshrc, add\n" +
+		"~/.config/starship.toml`, use:\n" +
+		"\n" +
+		"    {{- range $i, $change := .Changes -}}\n" +
+		"    {{ if $i }}, {{ end -}}\n" +
+		"    {{ if eq .Kind \"added\" }}add{{ else if eq .Kind \"deleted\" }}remove{{ else }}update{{ end }} ~/{{ .Name }}\n" +
+		"    {{- end }}\n" +
+		"\n" +
+		"See the [reference manual](REFERENCE.md#chezmoicommitmessagetmpl) for the\n" +
+		"available fields.\n" +
+		"\n" +
 		"Be careful when using `autoPush`. If your dotfiles repo is public and you\n" +
 		"accidentally add a secret in plain text, that secret will be pushed to your\n" +
 		"public repo.\n" +
@@ -1125,6 +1156,33 @@ func init() {
 		"\n" +
 		"    {{ gopass \"<pass-name>\" }}\n" +
 		"\n" +
+		"### Use age to keep your secrets\n" +
+		"\n" +
+		"chezmoi includes built-in support for encrypting files with\n" +
+		"[age](https://age-encryption.org), so no external binary is required. Set\n" +
+		"`encryption = \"age\"` in your configuration file and specify the identity file\n" +
+		"containing your age key:\n" +
+		"\n" +
+		"    encryption = \"age\"\n" +
+		"    [age]\n" +
+		"      identity = \"/home/user/.config/chezmoi/key.txt\"\n" +
+		"\n" +
+		"You can generate a key with `age-keygen -o ~/.config/chezmoi/key.txt`. By\n" +
+		"default files are encrypted for the recipients corresponding to your identities.\n" +
+		"To encrypt for additional recipients, set `age.recipients` or\n" +
+		"`age.recipientsFile`.\n" +
+		"\n" +
+		"Add files to be encrypted with the `--encrypt` flag, for example:\n" +
+		"\n" +
+		"    chezmoi add --encrypt ~/.ssh/id_rsa\n" +
+		"\n" +
+		"Encrypted files are stored in the source state in age's ASCII-armored format and\n" +
+		"are decrypted in-process by `chezmoi apply`, `chezmoi cat`, `chezmoi diff`, and\n" +
+		"`chezmoi edit`.\n" +
+		"\n" +
+		"To use passphrase-based encryption instead of keys, set `age.passphrase = true`.\n" +
+		"chezmoi will prompt for the passphrase once per command.\n" +
+		"\n" +
 		"### Use gpg to keep your secrets\n" +
 		"\n" +
 		"chezmoi supports encrypting files with [gpg](https://www.gnupg.org/). Encrypted\n" +
@@ -1342,6 +1400,26 @@ func init() {
 		"| KeePassXC       | `keepassxc-cli`         | Not possible (interactive command only)           |\n" +
 		"| pass            | `pass`                  | `{{ secret \"show\" <id> }}`                        |\n" +
 		"\n" +
+		"### Use a generic encryption command to encrypt files\n" +
+		"\n" +
+		"chezmoi can encrypt files with any command that reads from its standard input\n" +
+		"and writes to its standard output, for example\n" +
+		"[sops](https://github.com/mozilla/sops) or\n" +
+		"[openssl](https://www.openssl.org/). Set `encryption = \"command\"` in your\n" +
+		"configuration file and specify the commands to run:\n" +
+		"\n" +
+		"    encryption = \"command\"\n" +
+		"    [encryptionCommand]\n" +
+		"      encryptCommand = \"openssl\"\n" +
+		"      encryptArgs = [\"enc\", \"-aes-256-cbc\", \"-pbkdf2\", \"-a\", \"-pass\", \"env:DOTFILES_PASSWORD\"]\n" +
+		"      decryptCommand = \"openssl\"\n" +
+		"      decryptArgs = [\"enc\", \"-d\", \"-aes-256-cbc\", \"-pbkdf2\", \"-a\", \"-pass\", \"env:DOTFILES_PASSWORD\"]\n" +
+		"      suffix = \".enc\"\n" +
+		"\n" +
+		"Files added with `chezmoi add --encrypt` are then piped through\n" +
+		"`encryptCommand` and stored with the given suffix, and are piped through\n" +
+		"`decryptCommand` when generating the target state.\n" +
+		"\n" +
 		"### Use templates variables to keep your secrets\n" +
 		"\n" +
 		"Typically, `~/.config/chezmoi/chezmoi.toml` is not checked in to version control\n" +
@@ -1376,8 +1454,19 @@ func init() {
 		"dry-run mode, the script is not executed.\n" +
 		"\n" +
 		"Scripts are any file in the source directory with the prefix `run_`, and are\n" +
-		"executed in alphabetical order. Scripts that should only be run when their\n" +
-		"contents change have the prefix `run_once_`.\n" +
+		"executed in alphabetical order. Scripts that should only be run once for each\n" +
+		"distinct contents have the prefix `run_once_`. Scripts that should be run\n" +
+		"whenever their contents change since they were last run have the prefix\n" +
+		"`run_onchange_`. The state of these scripts can be inspected and reset with\n" +
+		"`chezmoi state`, for example `chezmoi state delete --bucket script --key\n" +
+		"install.sh` causes `run_onchange_install.sh` to be run again.\n" +
+		"\n" +
+		"By default, scripts are run in the same order as other entries are applied, so\n" +
+		"a script may run before or after any given file is written. Scripts with the\n" +
+		"prefix `run_before_` are run before any files, directories, or symlinks are\n" +
+		"applied, for example to install packages. Scripts with the prefix `run_after_`\n" +
+		"are run after all other entries are applied, for example to reload services.\n" +
+		"These can be combined with `once_`, for example `run_once_before_install.sh`.\n" +
 		"\n" +
 		"Scripts break chezmoi's declarative approach, and as such should be used\n" +
 		"sparingly. Any script should be idempotent, even `run_once_` scripts.\n" +
@@ -1589,6 +1678,24 @@ func init() {
 		"\n" +
 		"which lists all the targets in the target state.\n" +
 		"\n" +
+		"## Use chezmoi without a git binary\n" +
+		"\n" +
+		"chezmoi includes a builtin implementation of git, which is useful on minimal\n" +
+		"systems, like containers, that do not have git installed. To use it, specify\n" +
+		"`builtin` as the source VCS command in your config file:\n" +
+		"\n" +
+		"    [sourceVCS]\n" +
+		"      command = \"builtin\"\n" +
+		"\n" +
+		"The builtin git is used by `init`, `update`, and when automatically committing\n" +
+		"and pushing changes. It supports cloning (including submodules), pulling,\n" +
+		"committing, and pushing. Unlike `git pull --rebase`, the builtin git can only\n" +
+		"fast-forward, so if you have unpushed local commits and upstream has also\n" +
+		"changed then `update` fails with a non-fast-forward error, and you must use the\n" +
+		"git command to merge or rebase. The `git` and `source` commands still require a\n" +
+		"git binary. Commits use the author in your git config file, so set `user.name` and\n" +
+		"`user.email` there if you use `autoCommit` or `autoPush`.\n" +
+		"\n" +
 		"## Use a non-git version control system\n" +
 		"\n" +
 		"By default, chezmoi uses git, but you can use any version control system of your\n" +
@@ -1599,7 +1706,8 @@ func init() {
 		"      command = \"hg\"\n" +
 		"\n" +
 		"The source VCS command is used in the chezmoi commands `init`, `source`, and\n" +
-		"`update`, and support for VCSes other than git is limited but easy to add. If\n" +
+		"`update`. With Mercurial, `sourceVCS.autoCommit` and `sourceVCS.autoPush` work\n" +
+		"the same as with git. Support for other VCSes is limited but easy to add. If\n" +
 		"you'd like to see your VCS better supported, please [open an issue on\n" +
 		"GitHub](https://github.com/twpayne/chezmoi/issues/new/choose).\n" +
 		"\n" +
@@ -1863,6 +1971,7 @@ func init() {
 		"  * [`-n`, `--dry-run`](#-n---dry-run)\n" +
 		"  * [`-h`, `--help`](#-h---help)\n" +
 		"  * [`-r`. `--remove`](#-r---remove)\n" +
+		"  * [`--run-modify-scripts`](#--run-modify-scripts)\n" +
 		"  * [`-S`, `--source` *directory*](#-s---source-directory)\n" +
 		"  * [`-v`, `--verbose`](#-v---verbose)\n" +
 		"  * [`--version`](#--version)\n" +
@@ -1872,6 +1981,9 @@ func init() {
 		"* [Source state attributes](#source-state-attributes)\n" +
 		"* [Special files and directories](#special-files-and-directories)\n" +
 		"  * [`.chezmoi.<format>.tmpl`](#chezmoiformattmpl)\n" +
+		"  * [`.chezmoicommitmessage.tmpl`](#chezmoicommitmessagetmpl)\n" +
+		"  * [`.chezmoidata.<format>` and `.chezmoidata`](#chezmoidataformat-and-chezmoidata)\n" +
+		"  * [`.chezmoiexternal.<format>`](#chezmoiexternalformat)\n" +
 		"  * [`.chezmoiignore`](#chezmoiignore)\n" +
 		"  * [`.chezmoiremove`](#chezmoiremove)\n" +
 		"  * [`.chezmoitemplates`](#chezmoitemplates)\n" +
@@ -1896,17 +2008,21 @@ func init() {
 		"  * [`git` [*arguments*]](#git-arguments)\n" +
 		"  * [`help` *command*](#help-command)\n" +
 		"  * [`hg` [*arguments*]](#hg-arguments)\n" +
+		"  * [`ignored` [*targets*]](#ignored-targets)\n" +
 		"  * [`init` [*repo*]](#init-repo)\n" +
 		"  * [`import` *filename*](#import-filename)\n" +
 		"  * [`manage` *targets*](#manage-targets)\n" +
 		"  * [`managed`](#managed)\n" +
 		"  * [`merge` *targets*](#merge-targets)\n" +
+		"  * [`plan` [*targets*]](#plan-targets)\n" +
 		"  * [`purge`](#purge)\n" +
 		"  * [`remove` *targets*](#remove-targets)\n" +
 		"  * [`rm` *targets*](#rm-targets)\n" +
+		"  * [`rollback` [*generation*]](#rollback-generation)\n" +
 		"  * [`secret`](#secret)\n" +
 		"  * [`source` [*args*]](#source-args)\n" +
 		"  * [`source-path` [*targets*]](#source-path-targets)\n" +
+		"  * [`state`](#state)\n" +
 		"  * [`unmanage` *targets*](#unmanage-targets)\n" +
 		"  * [`unmanaged`](#unmanaged)\n" +
 		"  * [`update`](#update)\n" +
@@ -1919,10 +2035,15 @@ func init() {
 		"* [Template functions](#template-functions)\n" +
 		"  * [`bitwarden` [*args*]](#bitwarden-args)\n" +
 		"  * [`bitwardenFields` [*args*]](#bitwardenfields-args)\n" +
+		"  * [`fromIni` *text*](#fromini-text)\n" +
+		"  * [`fromJson` *text*](#fromjson-text)\n" +
+		"  * [`fromToml` *text*](#fromtoml-text)\n" +
+		"  * [`fromYaml` *text*](#fromyaml-text)\n" +
 		"  * [`gopass` *gopass-name*](#gopass-gopass-name)\n" +
 		"  * [`include` *filename*](#include-filename)\n" +
 		"  * [`ioreg`](#ioreg)\n" +
 		"  * [`joinPath` *elements*](#joinpath-elements)\n" +
+		"  * [`jq` *query* *input*](#jq-query-input)\n" +
 		"  * [`keepassxc` *entry*](#keepassxc-entry)\n" +
 		"  * [`keepassxcAttribute` *entry* *attribute*](#keepassxcattribute-entry-attribute)\n" +
 		"  * [`keyring` *service* *user*](#keyring-service-user)\n" +
@@ -1936,9 +2057,14 @@ func init() {
 		"  * [`promptBool` *prompt*](#promptbool-prompt)\n" +
 		"  * [`promptInt` *prompt*](#promptint-prompt)\n" +
 		"  * [`promptString` *prompt*](#promptstring-prompt)\n" +
+		"  * [`readDestFile` *filename*](#readdestfile-filename)\n" +
 		"  * [`secret` [*args*]](#secret-args)\n" +
 		"  * [`secretJSON` [*args*]](#secretjson-args)\n" +
+		"  * [`setValueAtPath` *path* *value* *dict*](#setvalueatpath-path-value-dict)\n" +
 		"  * [`stat` *name*](#stat-name)\n" +
+		"  * [`toIni` *dict*](#toini-dict)\n" +
+		"  * [`toToml` *dict*](#totoml-dict)\n" +
+		"  * [`toYaml` *value*](#toyaml-value)\n" +
 		"  * [`vault` *key*](#vault-key)\n" +
 		"\n" +
 		"## Concepts\n" +
@@ -2008,6 +2134,13 @@ func init() {
 		"\n" +
 		"Also remove targets according to `.chezmoiremove`.\n" +
 		"\n" +
+		"### `--run-modify-scripts`\n" +
+		"\n" +
+		"Run modify scripts in `chezmoi diff` and in dry run mode. By default, modify\n" +
+		"scripts are only run when changes are actually applied, and by `chezmoi plan`\n" +
+		"and `chezmoi verify`, as they can run arbitrary commands. When they are not run,\n" +
+		"their targets are treated as unchanged.\n" +
+		"\n" +
 		"### `-S`, `--source` *directory*\n" +
 		"\n" +
 		"Use *directory* as the source directory.\n" +
@@ -2038,41 +2171,57 @@ func init() {
 		"\n" +
 		"The following configuration variables are available:\n" +
 		"\n" +
-		"| Section         | Variable     | Type     | Default value             | Description                                         |\n" +
-		"| --------------- | ------------ | -------- | ------------------------- | --------------------------------------------------- |\n" +
-		"| Top level       | `color`      | string   | `auto`                    | Colorize diffs                                      |\n" +
-		"|                 | `data`       | any      | *none*                    | Template data                                       |\n" +
-		"|                 | `destDir`    | string   | `~`                       | Destination directory                               |\n" +
-		"|                 | `dryRun`     | bool     | `false`                   | Dry run mode                                        |\n" +
-		"|                 | `follow`     | bool     | `false`                   | Follow symlinks                                     |\n" +
-		"|                 | `remove`     | bool     | `false`                   | Remove targets                                      |\n" +
-		"|                 | `sourceDir`  | string   | `~/.local/share/chezmoi`  | Source directory                                    |\n" +
-		"|                 | `umask`      | int      | *from system*             | Umask                                               |\n" +
-		"|                 | `verbose`    | bool     | `false`                   | Verbose mode                                        |\n" +
-		"| `bitwarden`     | `command`    | string   | `bw`                      | Bitwarden CLI command                               |\n" +
-		"| `cd`            | `args`       | []string | *none*                    | Extra args to shell in `cd` command                 |\n" +
-		"|                 | `command`    | string   | *none*                    | Shell to run in `cd` command                        |\n" +
-		"| `diff`          | `format`     | string   | `chezmoi`                 | Diff format, either `chezmoi` or `git`              |\n" +
-		"|                 | `pager`      | string   | *none*                    | Pager                                               |\n" +
-		"| `genericSecret` | `command`    | string   | *none*                    | Generic secret command                              |\n" +
-		"| `gopass`        | `command`    | string   | `gopass`                  | gopass CLI command                                  |\n" +
-		"| `gpg`           | `command`    | string   | `gpg`                     | GPG CLI command                                     |\n" +
-		"|                 | `recipient`  | string   | *none*                    | GPG recipient                                       |\n" +
-		"|                 | `symmetric`  | bool     | `false`                   | Use symmetric GPG encryption                        |\n" +
-		"| `keepassxc`     | `args`       | []string | *none*                    | Extra args to KeePassXC CLI command                 |\n" +
-		"|                 | `command`    | string   | `keepassxc-cli`           | KeePassXC CLI command                               |\n" +
-		"|                 | `database`   | string   | *none*                    | KeePassXC database                                  |\n" +
-		"| `lastpass`      | `command`    | string   | `lpass`                   | Lastpass CLI command                                |\n" +
-		"| `merge`         | `args`       | []string | *none*                    | Extra args to 3-way merge command                   |\n" +
-		"|                 | `command`    | string   | `vimdiff`                 | 3-way merge command                                 |\n" +
-		"| `onepassword`   | `cache`      | bool     | `true`                    | Enable optional caching provided by `op`            |\n" +
-		"|                 | `command`    | string   | `op`                      | 1Password CLI command                               |\n" +
-		"| `pass`          | `command`    | string   | `pass`                    | Pass CLI command                                    |\n" +
-		"| `sourceVCS`     | `autoCommit` | bool     | `false`                   | Commit changes to the source state after any change |\n" +
-		"|                 | `autoPush`   | bool     | `false`                   | Push changes to the source state after any change   |\n" +
-		"|                 | `command`    | string   | `git`                     | Source version control system                       |\n" +
-		"| `template`      | `options`    | []string | `[\"missingkey=error\"]`    | Template options                                    |\n" +
-		"| `vault`         | `command`    | string   | `vault`                   | Vault CLI command                                   |\n" +
+		"| Section             | Variable                | Type     | Default value            | Description                                         |\n" +
+		"| ------------------- | ----------------------- | -------- | ------------------------ | --------------------------------------------------- |\n" +
+		"| Top level           | `color`                 | string   | `auto`                   | Colorize diffs                                      |\n" +
+		"|                     | `concurrency`           | int      | *number of CPUs*         | Maximum number of entries evaluated concurrently    |\n" +
+		"|                     | `data`                  | any      | *none*                   | Template data                                       |\n" +
+		"|                     | `destDir`               | string   | `~`                      | Destination directory                               |\n" +
+		"|                     | `dryRun`                | bool     | `false`                  | Dry run mode                                        |\n" +
+		"|                     | `encryption`            | string   | `gpg`                    | Encryption tool, either `age`, `command`, or `gpg`  |\n" +
+		"|                     | `follow`                | bool     | `false`                  | Follow symlinks                                     |\n" +
+		"|                     | `remove`                | bool     | `false`                  | Remove targets                                      |\n" +
+		"|                     | `sourceDir`             | string   | `~/.local/share/chezmoi` | Source directory                                    |\n" +
+		"|                     | `umask`                 | int      | *from system*            | Umask                                               |\n" +
+		"|                     | `verbose`               | bool     | `false`                  | Verbose mode                                        |\n" +
+		"| `age`               | `identity`              | string   | *none*                   | age identity file                                   |\n" +
+		"|                     | `identities`            | []string | *none*                   | Extra age identity files                            |\n" +
+		"|                     | `passphrase`            | bool     | `false`                  | Use age passphrase-based encryption                 |\n" +
+		"|                     | `recipient`             | string   | *none*                   | age recipient                                       |\n" +
+		"|                     | `recipients`            | []string | *none*                   | Extra age recipients                                |\n" +
+		"|                     | `recipientsFile`        | string   | *none*                   | File containing age recipients                      |\n" +
+		"| `backups`           | `keep`                  | int      | `10`                     | Number of backup generations to keep, 0 keeps all   |\n" +
+		"| `bitwarden`         | `command`               | string   | `bw`                     | Bitwarden CLI command                               |\n" +
+		"| `cd`                | `args`                  | []string | *none*                   | Extra args to shell in `cd` command                 |\n" +
+		"|                     | `command`               | string   | *none*                   | Shell to run in `cd` command                        |\n" +
+		"| `diff`              | `format`                | string   | `chezmoi`                | Diff format, either `chezmoi` or `git`              |\n" +
+		"|                     | `pager`                 | string   | *none*                   | Pager                                               |\n" +
+		"| `encryptionCommand` | `decryptArgs`           | []string | *none*                   | Extra args to decrypt command                       |\n" +
+		"|                     | `decryptCommand`        | string   | *none*                   | Command to decrypt stdin to stdout                  |\n" +
+		"|                     | `encryptArgs`           | []string | *none*                   | Extra args to encrypt command                       |\n" +
+		"|                     | `encryptCommand`        | string   | *none*                   | Command to encrypt stdin to stdout                  |\n" +
+		"|                     | `suffix`                | string   | *none*                   | Suffix of encrypted files in the source state       |\n" +
+		"| `genericSecret`     | `command`               | string   | *none*                   | Generic secret command                              |\n" +
+		"| `gopass`            | `command`               | string   | `gopass`                 | gopass CLI command                                  |\n" +
+		"| `gpg`               | `command`               | string   | `gpg`                    | GPG CLI command                                     |\n" +
+		"|                     | `recipient`             | string   | *none*                   | GPG recipient                                       |\n" +
+		"|                     | `suffix`                | string   | *none*                   | Suffix of encrypted files in the source state       |\n" +
+		"|                     | `symmetric`             | bool     | `false`                  | Use symmetric GPG encryption                        |\n" +
+		"| `keepassxc`         | `args`                  | []string | *none*                   | Extra args to KeePassXC CLI command                 |\n" +
+		"|                     | `command`               | string   | `keepassxc-cli`          | KeePassXC CLI command                               |\n" +
+		"|                     | `database`              | string   | *none*                   | KeePassXC database                                  |\n" +
+		"| `lastpass`          | `command`               | string   | `lpass`                  | Lastpass CLI command                                |\n" +
+		"| `merge`             | `args`                  | []string | *none*                   | Extra args to 3-way merge command                   |\n" +
+		"|                     | `command`               | string   | `vimdiff`                | 3-way merge command                                 |\n" +
+		"| `onepassword`       | `cache`                 | bool     | `true`                   | Enable optional caching provided by `op`            |\n" +
+		"|                     | `command`               | string   | `op`                     | 1Password CLI command                               |\n" +
+		"| `pass`              | `command`               | string   | `pass`                   | Pass CLI command                                    |\n" +
+		"| `sourceVCS`         | `autoCommit`            | bool     | `false`                  | Commit changes to the source state after any change |\n" +
+		"|                     | `autoPush`              | bool     | `false`                  | Push changes to the source state after any change   |\n" +
+		"|                     | `command`               | string   | `git`                    | Source version control system, or `builtin`         |\n" +
+		"|                     | `commitMessageTemplate` | string   | *none*                   | Commit message template                             |\n" +
+		"| `template`          | `options`               | []string | `[\"missingkey=error\"]`   | Template options                                    |\n" +
+		"| `vault`             | `command`               | string   | `vault`                  | Vault CLI command                                   |\n" +
 		"\n" +
 		"### Examples\n" +
 		"\n" +
@@ -2113,33 +2262,84 @@ func init() {
 		"source directory that begin with a `.`. The following prefixes and suffixes are\n" +
 		"special, and are collectively referred to as \"attributes\":\n" +
 		"\n" +
-		"| Prefix       | Effect                                                                         |\n" +
-		"| ------------ | ------------------------------------------------------------------------------ |\n" +
-		"| `encrypted_` | Encrypt the file in the source state.                                          |\n" +
-		"| `once_`      | Only run script once.                                                          |\n" +
-		"| `private_`   | Remove all group and world permissions from the target file or directory.      |\n" +
-		"| `empty_`     | Ensure the file exists, even if is empty. By default, empty files are removed. |\n" +
-		"| `exact_`     | Remove anything not managed by chezmoi.                                        |\n" +
-		"| `executable_`| Add executable permissions to the target file.                                 |\n" +
-		"| `run_`       | Treat the contents as a script to run.                                         |\n" +
-		"| `symlink_`   | Create a symlink instead of a regular file.                                    |\n" +
-		"| `dot_`       | Rename to use a leading dot, e.g. `dot_foo` becomes `.foo`.                    |\n" +
+		"| Prefix        | Effect                                                                         |\n" +
+		"| ------------- | ------------------------------------------------------------------------------ |\n" +
+		"| `encrypted_`  | Encrypt the file in the source state with the configured `encryption`.         |\n" +
+		"| `once_`       | Only run script once.                                                          |\n" +
+		"| `onchange_`   | Run script whenever its contents change.                                       |\n" +
+		"| `private_`    | Remove all group and world permissions from the target file or directory.      |\n" +
+		"| `remove_`     | Remove the target if it exists.                                                |\n" +
+		"| `empty_`      | Ensure the file exists, even if is empty. By default, empty files are removed. |\n" +
+		"| `exact_`      | Remove anything not managed by chezmoi.                                        |\n" +
+		"| `executable_` | Add executable permissions to the target file.                                 |\n" +
+		"| `modify_`     | Treat the contents as a script that modifies an existing file.                 |\n" +
+		"| `run_`        | Treat the contents as a script to run.                                         |\n" +
+		"| `symlink_`    | Create a symlink instead of a regular file.                                    |\n" +
+		"| `dot_`        | Rename to use a leading dot, e.g. `dot_foo` becomes `.foo`.                    |\n" +
+		"| `before_`     | Run script before applying all other entries.                                  |\n" +
+		"| `create_`     | Only create the target file if it does not already exist.                      |\n" +
+		"| `after_`      | Run script after applying all other entries.                                   |\n" +
 		"\n" +
 		"| Suffix  | Effect                                               |\n" +
 		"| ------- | ---------------------------------------------------- |\n" +
 		"| `.tmpl` | Treat the contents of the source file as a template. |\n" +
 		"\n" +
+		"Encrypted files additionally have a suffix that depends on the configured\n" +
+		"`encryption`: `.age` for `age`, `gpg.suffix` for `gpg`, and\n" +
+		"`encryptionCommand.suffix` for `command`. `gpg.suffix` is empty by default, so\n" +
+		"existing `gpg` source files are not renamed; set it to, for example, `.asc` to\n" +
+		"use a suffix. The suffix follows any `.tmpl` suffix, for example\n" +
+		"`encrypted_dot_netrc.tmpl.age`. Encrypted files without the suffix are still\n" +
+		"recognized.\n" +
+		"\n" +
 		"Order of prefixes is important, the order is `run_`, `exact_`, `private_`,\n" +
-		"`empty_`, `executable_`, `symlink_`, `once_`, `dot_`.\n" +
+		"`empty_`, `executable_`, `symlink_`, `once_`, `dot_`. For scripts, the order is\n" +
+		"`run_`, `once_` or `onchange_`, `before_` or `after_`, `encrypted_`, for example\n" +
+		"`run_once_before_encrypted_install.sh.age`. For modify scripts, `modify_` comes\n" +
+		"first, for example `modify_private_dot_gitconfig.tmpl`, and similarly `create_`\n" +
+		"comes first for regular files, for example `create_private_dot_gitconfig.local`.\n" +
 		"\n" +
 		"Different target types allow different prefixes and suffixes:\n" +
 		"\n" +
-		"| Target type   | Allowed prefixes                                          | Allowed suffixes |\n" +
-		"| ------------- | --------------------------------------------------------- | ---------------- |\n" +
-		"| Directory     | `exact_`, `private_`, `dot_`                              | *none*           |\n" +
-		"| Regular file  | `encrypted_`, `private_`, `empty_`, `executable_`, `dot_` | `.tmpl`          |\n" +
-		"| Script        | `run_`, `once_`                                           | `.tmpl`          |\n" +
-		"| Symbolic link | `symlink_`, `dot_`,                                       | `.tmpl`          |\n" +
+		"| Target type   | Allowed prefixes                                                     | Allowed suffixes |\n" +
+		"| ------------- | -------------------------------------------------------------------- | ---------------- |\n" +
+		"| Directory     | `exact_`, `private_`, `dot_`                                         | *none*           |\n" +
+		"| Regular file  | `create_`, `encrypted_`, `private_`, `empty_`, `executable_`, `dot_` | `.tmpl`          |\n" +
+		"| Script        | `run_`, `once_`, `onchange_`, `before_`, `after_`, `encrypted_`      | `.tmpl`          |\n" +
+		"| Modify script | `modify_`, `encrypted_`, `private_`, `executable_`, `dot_`           | `.tmpl`          |\n" +
+		"| Remove        | `remove_`, `dot_`                                                    | *none*           |\n" +
+		"| Symbolic link | `symlink_`, `dot_`,                                                  | `.tmpl`          |\n" +
+		"\n" +
+		"Files with the `create_` attribute are written by `apply` only if the target\n" +
+		"does not already exist. Once the target exists, chezmoi never changes it, so it\n" +
+		"does not appear in `diff` and does not cause `verify` to fail, whatever its\n" +
+		"contents.\n" +
+		"\n" +
+		"Entries with the `remove_` attribute declare targets that must not exist. The\n" +
+		"contents of the source file are ignored. `apply` removes the target, whether it\n" +
+		"is a file, directory, or symbolic link, `diff` shows it as a deletion, and\n" +
+		"`verify` fails if it exists. Unlike `.chezmoiremove`, the `--remove` flag is\n" +
+		"not required. For example, after renaming `~/.oldrc` to `~/.newrc`, create an\n" +
+		"empty `remove_dot_oldrc` in the source directory so that `~/.oldrc` is removed\n" +
+		"on every machine.\n" +
+		"\n" +
+		"Modify scripts manage files that are partly managed by other applications.\n" +
+		"chezmoi runs the script with the current contents of the target file on stdin,\n" +
+		"or with no input if the target file does not exist, and uses the script's\n" +
+		"standard output as the contents of the target file in `apply`, `diff`, `dump`,\n" +
+		"and `verify`. The script is run in the closest existing directory containing the\n" +
+		"target. If the script is empty, for example because it is a template that\n" +
+		"evaluates to nothing, then the target file is left unchanged. If the script's\n" +
+		"output is empty then the target file is emptied, never removed, and is not\n" +
+		"created if it does not exist. Modify scripts are not run by `chezmoi diff` or in\n" +
+		"dry run mode unless `--run-modify-scripts` is given.\n" +
+		"\n" +
+		"For example, to always set `color = blue` in `~/.config/app.conf` but leave all\n" +
+		"other settings to the application, create\n" +
+		"`dot_config/modify_app.conf` containing:\n" +
+		"\n" +
+		"    #!/bin/sh\n" +
+		"    sed 's/^color = .*/color = blue/'\n" +
 		"\n" +
 		"## Special files and directories\n" +
 		"\n" +
@@ -2158,31 +2358,171 @@ func init() {
 		"    data:\n" +
 		"        email: \"{{ $email }}\"\n" +
 		"\n" +
+		"### `.chezmoicommitmessage.tmpl`\n" +
+		"\n" +
+		"If a file called `.chezmoicommitmessage.tmpl` exists in the root of the source\n" +
+		"state then it is used as the template for commit messages when\n" +
+		"`sourceVCS.autoCommit` or `sourceVCS.autoPush` is true, unless\n" +
+		"`sourceVCS.commitMessageTemplate` is set in the config file.\n" +
+		"\n" +
+		"The template is executed with `.Changes`, a list of the changed files in the\n" +
+		"source directory. Each change has the following fields:\n" +
+		"\n" +
+		"| Field             | Description                                                       |\n" +
+		"| ----------------- | ----------------------------------------------------------------- |\n" +
+		"| `.Kind`           | One of `added`, `deleted`, `modified`, or `renamed`               |\n" +
+		"| `.Path`           | Path of the file relative to the source directory                 |\n" +
+		"| `.OrigPath`       | Original path of a renamed file                                   |\n" +
+		"| `.TargetName`     | Target name of the file, or empty if it is not a target           |\n" +
+		"| `.OrigTargetName` | Original target name of a renamed file                            |\n" +
+		"| `.Name`           | `.TargetName` if the file is a target, otherwise `.Path`          |\n" +
+		"| `.OrigName`       | `.OrigTargetName` if the file was a target, otherwise `.OrigPath` |\n" +
+		"\n" +
+		"The raw git status is also available as `.Ordinary`, `.RenamedOrCopied`,\n" +
+		"`.Unmerged`, and `.Untracked`. With Mercurial, only `.Untracked` is set, and\n" +
+		"added files whose copy source was removed are reported as `renamed`.\n" +
+		"\n" +
+		"#### `.chezmoicommitmessage.tmpl` examples\n" +
+		"\n" +
+		"    {{- range $i, $change := .Changes -}}\n" +
+		"    {{ if $i }}, {{ end -}}\n" +
+		"    {{ if eq .Kind \"added\" }}add{{ else if eq .Kind \"deleted\" }}remove{{ else }}update{{ end }} ~/{{ .Name }}\n" +
+		"    {{- end }}\n" +
+		"\n" +
+		"### `.chezmoidata.<format>` and `.chezmoidata`\n" +
+		"\n" +
+		"If files called `.chezmoidata.<format>` exist in the root of the source state,\n" +
+		"or files exist in a directory called `.chezmoidata`, then they are interpreted\n" +
+		"as template data. *format* must be one of `json`, `toml`, or `yaml`, and files\n" +
+		"in the `.chezmoidata` directory must have one of these extensions. This is\n" +
+		"useful for data that is shared between machines and is not secret, such as\n" +
+		"lists of packages.\n" +
+		"\n" +
+		"All files are deep merged, first the `.chezmoidata.<format>` files and then the\n" +
+		"files in the `.chezmoidata` directory, both in alphabetical order, with later\n" +
+		"files taking precedence over earlier files. Variables in the `data` section of\n" +
+		"the config file take precedence over variables from all files. Template data\n" +
+		"files are not templates themselves.\n" +
+		"\n" +
+		"Run `chezmoi data --sources` to see where each variable came from.\n" +
+		"\n" +
+		"#### `.chezmoidata.<format>` examples\n" +
+		"\n" +
+		"`.chezmoidata.yaml`:\n" +
+		"\n" +
+		"    packages:\n" +
+		"      brew:\n" +
+		"      - git\n" +
+		"      - jq\n" +
+		"\n" +
+		"`dot_Brewfile.tmpl`:\n" +
+		"\n" +
+		"    {{ range .packages.brew -}}\n" +
+		"    brew {{ . | quote }}\n" +
+		"    {{ end -}}\n" +
+		"\n" +
+		"### `.chezmoiexternal.<format>`\n" +
+		"\n" +
+		"If a file called `.chezmoiexternal.<format>` exists in the source state, then it\n" +
+		"is interpreted as a list of targets whose contents are downloaded from a URL.\n" +
+		"*format* must be one of `json`, `toml`, or `yaml`. If the filename has a `.tmpl`\n" +
+		"suffix then it is interpreted as a template.\n" +
+		"\n" +
+		"Each key is a target path relative to the directory containing\n" +
+		"`.chezmoiexternal.<format>`, and each value is a table with the following\n" +
+		"fields:\n" +
+		"\n" +
+		"| Variable          | Type     | Default value | Description                                             |\n" +
+		"| ----------------- | -------- | ------------- | ------------------------------------------------------- |\n" +
+		"| `type`            | string   | *none*        | Type of external, either `archive` or `file`            |\n" +
+		"| `url`             | string   | *none*        | URL to download                                         |\n" +
+		"| `executable`      | bool     | `false`       | For `file`s, make the target executable                 |\n" +
+		"| `exact`           | bool     | `false`       | For `archive`s, make the target directories exact       |\n" +
+		"| `format`          | string   | *detected*    | Archive format, e.g. `tar.gz`, `tar.xz`, or `zip`       |\n" +
+		"| `stripComponents` | int      | `0`           | Number of leading path components to strip from archive |\n" +
+		"| `include`         | []string | *none*        | Patterns of paths in the archive to include             |\n" +
+		"| `exclude`         | []string | *none*        | Patterns of paths in the archive to exclude             |\n" +
+		"| `refreshPeriod`   | duration | `0`           | How often to download the URL again, `0` means never    |\n" +
+		"\n" +
+		"Downloaded URLs are cached in `$XDG_CACHE_HOME/chezmoi/external`. A URL that is\n" +
+		"not in the cache is always downloaded. A cached URL is downloaded again by\n" +
+		"`chezmoi apply` and `chezmoi update` when it is older than `refreshPeriod`. If\n" +
+		"`refreshPeriod` is zero, the default, then a cached URL is never downloaded\n" +
+		"again, so to force a download remove it from the cache. All other commands,\n" +
+		"including `chezmoi diff` and `chezmoi apply --dry-run`, always use cached URLs.\n" +
+		"Downloads time out after one minute. `include` and `exclude` patterns are matched against\n" +
+		"paths in the archive after `stripComponents` has been applied. If a path matches\n" +
+		"any `exclude` pattern it is excluded, otherwise, if any `include` patterns are\n" +
+		"given, it is only included if it matches at least one of them.\n" +
+		"\n" +
+		"Externals are added to the target state after all other entries. Targets in the\n" +
+		"source state take precedence over targets from externals, so you can add your\n" +
+		"own files to a directory that is populated from an archive.\n" +
+		"\n" +
+		"Targets from externals have no source files of their own. `chezmoi add`,\n" +
+		"`chattr`, `edit`, `forget`, `merge`, `remove`, and `source-path` refuse to\n" +
+		"operate on them, and `chezmoi add` refuses to add new files inside directories\n" +
+		"that only exist because of an external. Edit `.chezmoiexternal.<format>`\n" +
+		"instead.\n" +
+		"\n" +
+		"#### `.chezmoiexternal.<format>` examples\n" +
+		"\n" +
+		"    [\".oh-my-zsh\"]\n" +
+		"        type = \"archive\"\n" +
+		"        url = \"https://github.com/ohmyzsh/ohmyzsh/archive/master.tar.gz\"\n" +
+		"        exact = true\n" +
+		"        stripComponents = 1\n" +
+		"        refreshPeriod = \"168h\"\n" +
+		"    [\".vim/autoload/plug.vim\"]\n" +
+		"        type = \"file\"\n" +
+		"        url = \"https://raw.githubusercontent.com/junegunn/vim-plug/master/plug.vim\"\n" +
+		"        refreshPeriod = \"168h\"\n" +
+		"\n" +
 		"### `.chezmoiignore`\n" +
 		"\n" +
 		"If a file called `.chezmoiignore` exists in the source state then it is\n" +
-		"interpreted as a set of patterns to ignore. Patterns are matched using\n" +
-		"[`doublestar.PathMatch`](https://pkg.go.dev/github.com/bmatcuk/doublestar?tab=doc#PathMatch)\n" +
-		"and match against the target path, not the source path.\n" +
-		"\n" +
-		"Patterns can be excluded by prefixing them with a `!` character. All excludes\n" +
-		"take priority over all includes.\n" +
+		"interpreted as a set of patterns to ignore. Patterns have the same semantics as\n" +
+		"`.gitignore` patterns and match against the target path, not the source path.\n" +
+		"Patterns are matched using\n" +
+		"[`doublestar.PathMatch`](https://pkg.go.dev/github.com/bmatcuk/doublestar?tab=doc#PathMatch),\n" +
+		"so `**` matches any number of directories.\n" +
+		"\n" +
+		"* A pattern that ends with a `/` only matches directories.\n" +
+		"* A pattern that begins with or contains a `/` is anchored to the directory\n" +
+		"  containing the `.chezmoiignore` file. Any other pattern matches at any depth,\n" +
+		"  so `*.txt` matches `a.txt` and `dir/b.txt`.\n" +
+		"* Patterns can be excluded by prefixing them with a `!` character.\n" +
+		"* Patterns are matched in order and the last matching pattern wins, so a later\n" +
+		"  pattern can ignore a subpath of a directory that an earlier `!` pattern\n" +
+		"  excluded.\n" +
+		"* If a directory is ignored then everything in it is ignored.\n" +
 		"\n" +
 		"Comments are introduced with the `#` character and run until the end of the\n" +
 		"line.\n" +
 		"\n" +
+		"Use `chezmoi ignored` to find out which pattern ignores a target.\n" +
+		"\n" +
 		"`.chezmoiignore` is interpreted as a template. This allows different files to be\n" +
 		"ignored on different machines.\n" +
 		"\n" +
-		"`.chezmoiignore` files in subdirectories apply only to that subdirectory.\n" +
+		"`.chezmoiignore` files in subdirectories apply only to that subdirectory, and\n" +
+		"their patterns are relative to that subdirectory. For example, the pattern\n" +
+		"`/cache/` in `dot_config/nvim/.chezmoiignore` ignores `~/.config/nvim/cache`.\n" +
+		"Patterns in `.chezmoiignore` files in deeper subdirectories are matched after\n" +
+		"patterns in their parents' `.chezmoiignore` files, so they take precedence.\n" +
+		"`.chezmoiignore` files in ignored directories are not read.\n" +
 		"\n" +
 		"#### `.chezmoiignore` examples\n" +
 		"\n" +
 		"    README.md\n" +
 		"\n" +
-		"    *.txt   # ignore *.txt in the target directory\n" +
-		"    */*.txt # ignore *.txt in subdirectories of the target directory\n" +
-		"    backups/** # ignore backups folder in chezmoi directory and all its contents\n" +
+		"    /*.txt     # ignore *.txt in the target directory\n" +
+		"    /*/*.txt   # ignore *.txt in subdirectories of the target directory\n" +
+		"    backups/   # ignore all backups directories and all their contents\n" +
+		"\n" +
+		"    .config/*      # ignore everything in .config\n" +
+		"    !.config/nvim  # except .config/nvim\n" +
+		"    .config/nvim/plugin/packer_compiled.lua # but not this generated file\n" +
 		"\n" +
 		"    {{- if ne .email \"john.smith@company.com\" }}\n" +
 		"    # Ignore .company-directory unless configured with a company email\n" +
@@ -2197,7 +2537,15 @@ func init() {
 		"\n" +
 		"If a file called `.chezmoiremove` exists in the source state then it is\n" +
 		"interpreted as a list of targets to remove. `.chezmoiremove` is interpreted as a\n" +
-		"template.\n" +
+		"template and its patterns have the same semantics as `.chezmoiignore` patterns,\n" +
+		"including in subdirectories, except that all patterns are anchored to the\n" +
+		"directory containing the `.chezmoiremove` file. For example, `.oldrc` only\n" +
+		"removes `~/.oldrc`, not `~/dir/.oldrc`. To remove targets at any depth, begin\n" +
+		"the pattern with `**/`, for example `**/.oldrc`. Such patterns require chezmoi\n" +
+		"to walk your entire destination directory.\n" +
+		"\n" +
+		"Targets matching `.chezmoiremove` are only removed when the `--remove` flag is\n" +
+		"given. To always remove a single target, use the `remove_` attribute instead.\n" +
 		"\n" +
 		"### `.chezmoitemplates`\n" +
 		"\n" +
@@ -2245,6 +2593,11 @@ func init() {
 		"the `data` section of the config file. Longer substitutions occur before shorter\n" +
 		"ones. This implies the `--template` option.\n" +
 		"\n" +
+		"#### `--create`\n" +
+		"\n" +
+		"Set the `create` attribute on added files. Files that already have the `create`\n" +
+		"attribute in the source state keep it.\n" +
+		"\n" +
 		"#### `-e`, `--empty`\n" +
 		"\n" +
 		"Set the `empty` attribute on added files.\n" +
@@ -2281,17 +2634,67 @@ func init() {
 		"Ensure that *targets* are in the target state, updating them if necessary. If no\n" +
 		"targets are specified, the state of all targets are ensured.\n" +
 		"\n" +
+		"After running any `before_` scripts, chezmoi evaluates the contents of up to\n" +
+		"`concurrency` targets concurrently, and then updates targets in order. Targets\n" +
+		"that cannot be evaluated are skipped and their errors are reported after all\n" +
+		"other targets have been updated.\n" +
+		"\n" +
+		"#### `-f`, `--format` *format*\n" +
+		"\n" +
+		"With `--dry-run`, write a plan of the operations that would be performed to\n" +
+		"stdout in the given format instead of performing them. *format* can be `json`,\n" +
+		"`toml`, or `yaml`. Each operation has a `type`, one of `chmod`, `mkdir`,\n" +
+		"`removeAll`, `rename`, `runCmd`, `runScript`, `writeFile`, or `writeSymlink`,\n" +
+		"and a `name`, and, depending on its type, the fields `dir`, `args`, `newName`,\n" +
+		"`linkname`, `mode`, `contents` (base64-encoded), and `scriptState` (the state\n" +
+		"recorded after running a `run_once_` or `run_onchange_` script).\n" +
+		"\n" +
+		"#### `--plan` *filename*\n" +
+		"\n" +
+		"Apply the plan in *filename*, created by `chezmoi plan`. chezmoi performs\n" +
+		"exactly the operations recorded in the plan, in order, with the recorded\n" +
+		"contents. The source state is not read, so no templates are executed and no\n" +
+		"externals are downloaded. chezmoi refuses to apply the plan if any destination\n" +
+		"path that the plan modifies has changed since the plan was created. Targets\n" +
+		"cannot be specified with `--plan`.\n" +
+		"\n" +
+		"#### `--rollback-on-error`\n" +
+		"\n" +
+		"Undo changes if any change fails. This is best effort, not a transaction.\n" +
+		"chezmoi first evaluates every target and performs a dry run of all changes,\n" +
+		"without running any scripts, and stops if this fails. chezmoi then applies the\n" +
+		"changes directly to the destination directory, as without this flag. If any\n" +
+		"change or script fails, then chezmoi undoes the changes it has already made\n" +
+		"from the backup generation (see `chezmoi rollback`). Other programs may observe\n" +
+		"the partially applied changes before they are undone, and if undoing a change\n" +
+		"fails then the destination directory is left partly updated.\n" +
+		"\n" +
+		"Scripts are not covered. Scripts run in their usual order, so `run_before_`\n" +
+		"scripts and scripts that sort before a failing change will already have run when\n" +
+		"the failure occurs. chezmoi cannot undo anything that a script did, and it does\n" +
+		"not re-run or roll back the persistent state of `run_once_` and `run_onchange_`\n" +
+		"scripts.\n" +
+		"\n" +
 		"#### `apply` examples\n" +
 		"\n" +
 		"    chezmoi apply\n" +
 		"    chezmoi apply --dry-run --verbose\n" +
+		"    chezmoi apply --dry-run --format=json\n" +
+		"    chezmoi apply --plan plan.json\n" +
+		"    chezmoi apply --rollback-on-error\n" +
 		"    chezmoi apply ~/.bashrc\n" +
 		"\n" +
 		"### `archive`\n" +
 		"\n" +
-		"Generate a tar archive of the target state. This can be piped into `tar` to\n" +
+		"Generate an archive of the target state. This can be piped into `tar` to\n" +
 		"inspect the target state.\n" +
 		"\n" +
+		"#### `-f`, `--format` *format*\n" +
+		"\n" +
+		"Write the archive in *format*. Supported formats are `tar`, `tar.gz`, `tar.xz`,\n" +
+		"`tar.zst`, and `zip`. If `--format` is not given then the format is guessed from\n" +
+		"the extension of the `--output` filename, defaulting to `tar`.\n" +
+		"\n" +
 		"#### `--output`, `-o` *filename*\n" +
 		"\n" +
 		"Write the output to *filename* instead of stdout.\n" +
@@ -2300,6 +2703,8 @@ func init() {
 		"\n" +
 		"    chezmoi archive | tar tvf -\n" +
 		"    chezmoi archive --output=dotfiles.tar\n" +
+		"    chezmoi archive --output=dotfiles.zip\n" +
+		"    chezmoi archive --format=tar.zst > dotfiles.tar.zst\n" +
 		"\n" +
 		"### `cat` *targets*\n" +
 		"\n" +
@@ -2332,6 +2737,9 @@ func init() {
 		"\n" +
 		"| Attribute    | Abbreviation |\n" +
 		"| ------------ | ------------ |\n" +
+		"| `after`      | `a`          |\n" +
+		"| `before`     | `b`          |\n" +
+		"| `create`     | *none*       |\n" +
 		"| `empty`      | `e`          |\n" +
 		"| `encrypted`  | *none*       |\n" +
 		"| `exact`      | *none*       |\n" +
@@ -2340,13 +2748,16 @@ func init() {
 		"| `template`   | `t`          |\n" +
 		"\n" +
 		"Multiple attributes modifications may be specified by separating them with a\n" +
-		"comma (`,`).\n" +
+		"comma (`,`). `after` and `before` only apply to scripts, and adding one removes\n" +
+		"the other. `create` only applies to regular files and cannot be added to modify\n" +
+		"scripts.\n" +
 		"\n" +
 		"#### `chattr` examples\n" +
 		"\n" +
 		"    chezmoi chattr template ~/.bashrc\n" +
 		"    chezmoi chattr noempty ~/.profile\n" +
 		"    chezmoi chattr private,template ~/.netrc\n" +
+		"    chezmoi chattr create ~/.gitconfig.local\n" +
 		"\n" +
 		"### `completion` *shell*\n" +
 		"\n" +
@@ -2372,10 +2783,18 @@ func init() {
 		"Print the computed template data in the given format. The accepted formats are\n" +
 		"`json` (JSON), `toml` (TOML), and `yaml` (YAML).\n" +
 		"\n" +
+		"#### `-s`, `--sources`\n" +
+		"\n" +
+		"Instead of printing the template data, print where each variable came from. Each\n" +
+		"variable is identified by its dot-separated path, and its source is either\n" +
+		"`chezmoi` for automatically populated variables, the config file, or a\n" +
+		"`.chezmoidata` file in the source state.\n" +
+		"\n" +
 		"#### `data` examples\n" +
 		"\n" +
 		"    chezmoi data\n" +
 		"    chezmoi data --format=yaml\n" +
+		"    chezmoi data --sources\n" +
 		"\n" +
 		"### `diff` [*targets*]\n" +
 		"\n" +
@@ -2553,6 +2972,17 @@ func init() {
 		"\n" +
 		"    chezmoi hg -- pull --rebase --update\n" +
 		"\n" +
+		"### `ignored` [*targets*]\n" +
+		"\n" +
+		"Print whether each of *targets* is ignored and, if a pattern matches it, the\n" +
+		"`.chezmoiignore` file, line number, and pattern that decided. If no targets are\n" +
+		"specified, print all ignored targets in the source state.\n" +
+		"\n" +
+		"#### `ignored` examples\n" +
+		"\n" +
+		"    chezmoi ignored\n" +
+		"    chezmoi ignored ~/.config/nvim/plugin/packer_compiled.lua\n" +
+		"\n" +
 		"### `init` [*repo*]\n" +
 		"\n" +
 		"Setup the source directory and update the destination directory to match the\n" +
@@ -2560,7 +2990,8 @@ func init() {
 		"\n" +
 		"First, if the source directory is not already contain a repository, then if\n" +
 		"*repo* is given it is checked out into the source directory, otherwise a new\n" +
-		"repository is initialized in the source directory.\n" +
+		"repository is initialized in the source directory. Any git submodules or\n" +
+		"Mercurial subrepos are also checked out.\n" +
 		"\n" +
 		"Second, if a file called `.chezmoi.format.tmpl` exists, where `format` is one of\n" +
 		"the supported file formats (e.g. `json`, `toml`, or `yaml`) then a new\n" +
@@ -2573,10 +3004,20 @@ func init() {
 		"Run `chezmoi apply` after checking out the repo and creating the config file.\n" +
 		"This is `false` by default.\n" +
 		"\n" +
+		"#### `--branch` *branch*\n" +
+		"\n" +
+		"Check out *branch* instead of the repo's default branch.\n" +
+		"\n" +
+		"#### `--depth` *depth*\n" +
+		"\n" +
+		"Clone the repo with a history truncated to the specified number of commits.\n" +
+		"This is not supported by Mercurial.\n" +
+		"\n" +
 		"#### `init` examples\n" +
 		"\n" +
 		"    chezmoi init https://github.com/user/dotfiles.git\n" +
 		"    chezmoi init https://github.com/user/dotfiles.git --apply\n" +
+		"    chezmoi init https://github.com/user/dotfiles.git --branch main --depth 1\n" +
 		"\n" +
 		"### `import` *filename*\n" +
 		"\n" +
@@ -2585,7 +3026,10 @@ func init() {
 		"exactly match the contents of a downloaded archive. You will generally always\n" +
 		"want to set the `--destination`, `--exact`, and `--remove-destination` flags.\n" +
 		"\n" +
-		"The only supported archive format is `.tar.gz`.\n" +
+		"Supported archive formats are `tar`, `tar.bz2`, `tar.gz`, `tar.xz`, `tar.zst`,\n" +
+		"and `zip`. The format is detected from the contents of the archive, falling back\n" +
+		"to the extension of *filename*. If *filename* is not given then the archive is\n" +
+		"read from stdin.\n" +
 		"\n" +
 		"#### `--destination` *directory*\n" +
 		"\n" +
@@ -2595,6 +3039,10 @@ func init() {
 		"\n" +
 		"Set the `exact` attribute on all imported directories.\n" +
 		"\n" +
+		"#### `-f`, `--format` *format*\n" +
+		"\n" +
+		"Set the archive format, overriding detection.\n" +
+		"\n" +
 		"#### `-r`, `--remove-destination`\n" +
 		"\n" +
 		"Remove destination (in the source state) before importing.\n" +
@@ -2607,6 +3055,7 @@ func init() {
 		"\n" +
 		"    curl -s -L -o oh-my-zsh-master.tar.gz https://github.com/robbyrussell/oh-my-zsh/archive/master.tar.gz\n" +
 		"    chezmoi import --strip-components 1 --destination ~/.oh-my-zsh oh-my-zsh-master.tar.gz\n" +
+		"    curl -s -L https://github.com/robbyrussell/oh-my-zsh/archive/master.zip | chezmoi import --strip-components 1 --destination ~/.oh-my-zsh\n" +
 		"\n" +
 		"### `manage` *targets*\n" +
 		"\n" +
@@ -2644,10 +3093,29 @@ func init() {
 		"\n" +
 		"    chezmoi merge ~/.bashrc\n" +
 		"\n" +
+		"### `plan` [*targets*]\n" +
+		"\n" +
+		"Write a plan of the operations that `chezmoi apply` would perform on *targets*,\n" +
+		"or on all targets if none are specified, in JSON format. The plan also records\n" +
+		"a hash of the current state of every destination path that it modifies. The\n" +
+		"plan can later be applied with `chezmoi apply --plan`.\n" +
+		"\n" +
+		"The plan includes the contents of target files and scripts, which may include\n" +
+		"decrypted secrets, so plan files are created readable only by the current user.\n" +
+		"\n" +
+		"#### `-o`, `--output` *filename*\n" +
+		"\n" +
+		"Write the plan to *filename* instead of stdout.\n" +
+		"\n" +
+		"#### `plan` examples\n" +
+		"\n" +
+		"    chezmoi plan -o plan.json\n" +
+		"    chezmoi plan ~/.bashrc\n" +
+		"\n" +
 		"### `purge`\n" +
 		"\n" +
-		"Remove chezmoi's configuration, state, and source directory, but leave the\n" +
-		"target state intact.\n" +
+		"Remove chezmoi's configuration, state, cache, and source directory, but leave\n" +
+		"the target state intact.\n" +
 		"\n" +
 		"#### `-f`, `--force`\n" +
 		"\n" +
@@ -2670,6 +3138,31 @@ func init() {
 		"\n" +
 		"`rm` is an alias for `remove`.\n" +
 		"\n" +
+		"### `rollback` [*generation*]\n" +
+		"\n" +
+		"Restore destination files, directories, and symlinks from backup generation\n" +
+		"*generation*, or from the latest generation if *generation* is not specified.\n" +
+		"\n" +
+		"Every time that chezmoi changes the destination directory, for example with\n" +
+		"`chezmoi apply`, it first backs up the previous contents, permissions, and\n" +
+		"symlink targets of every path that it changes into a new generation in\n" +
+		"`$XDG_STATE_HOME/chezmoi/backups` (`~/.local/state/chezmoi/backups` by default).\n" +
+		"Paths that did not exist are removed on rollback. A rollback is itself backed\n" +
+		"up as a new generation, so it can be undone with another rollback. Only the\n" +
+		"newest `backups.keep` generations (10 by default) are kept, older generations\n" +
+		"are removed after each change. Set `backups.keep` to 0 to keep all generations.\n" +
+		"\n" +
+		"#### `-l`, `--list`\n" +
+		"\n" +
+		"List the available generations with the time that they were created and the\n" +
+		"number of paths that they contain.\n" +
+		"\n" +
+		"#### `rollback` examples\n" +
+		"\n" +
+		"    chezmoi rollback --list\n" +
+		"    chezmoi rollback\n" +
+		"    chezmoi rollback 3\n" +
+		"\n" +
 		"### `secret`\n" +
 		"\n" +
 		"Run a secret manager's CLI, passing any extra arguments to the secret manager's\n" +
@@ -2716,6 +3209,39 @@ func init() {
 		"    chezmoi source-path\n" +
 		"    chezmoi source-path ~/.bashrc\n" +
 		"\n" +
+		"### `state`\n" +
+		"\n" +
+		"Manipulate the persistent state. chezmoi stores the state of `run_onchange_`\n" +
+		"scripts in the `script` bucket keyed by the script's target name, and the state\n" +
+		"of `run_once_` scripts keyed by the script's target name, a colon, and the\n" +
+		"SHA256 of the script's contents.\n" +
+		"\n" +
+		"#### `state dump`\n" +
+		"\n" +
+		"Write a dump of the persistent state to stdout in the format given by\n" +
+		"`--format`/`-f` (`json`, `toml`, or `yaml`, default `json`).\n" +
+		"\n" +
+		"#### `state get --bucket` *bucket* `--key` *key*\n" +
+		"\n" +
+		"Print the value associated with *key* in *bucket*.\n" +
+		"\n" +
+		"#### `state delete --bucket` *bucket* `--key` *key*\n" +
+		"\n" +
+		"Delete the value associated with *key* in *bucket*. This can be used to make\n" +
+		"chezmoi re-run a single `run_once_` or `run_onchange_` script.\n" +
+		"\n" +
+		"#### `state reset` [`--bucket` *bucket*]\n" +
+		"\n" +
+		"Delete all values in *bucket*, or in all buckets if `--bucket` is not given.\n" +
+		"chezmoi prompts before resetting each bucket unless `--force`/`-f` is given.\n" +
+		"\n" +
+		"#### `state` examples\n" +
+		"\n" +
+		"    chezmoi state dump\n" +
+		"    chezmoi state get --bucket script --key install.sh\n" +
+		"    chezmoi state delete --bucket script --key install.sh\n" +
+		"    chezmoi state reset --bucket script\n" +
+		"\n" +
 		"### `unmanage` *targets*\n" +
 		"\n" +
 		"`unmanage` is an alias for `forget` for symmetry with `manage`.\n" +
@@ -2730,11 +3256,35 @@ func init() {
 		"\n" +
 		"### `update`\n" +
 		"\n" +
-		"Pull changes from the source VCS and apply any changes.\n" +
+		"Pull changes from the source VCS and apply any changes. Any git submodules or\n" +
+		"Mercurial subrepos are updated to match.\n" +
+		"\n" +
+		"#### `-a`, `--apply`\n" +
+		"\n" +
+		"Apply changes after pulling. This is `true` by default.\n" +
+		"\n" +
+		"#### `--fail-if-changes`\n" +
+		"\n" +
+		"Fetch changes from the source VCS without pulling them, print the incoming\n" +
+		"commits and the diff that applying them would make, and exit with a non-zero\n" +
+		"status if there are any. Nothing is pulled or applied. This is useful for\n" +
+		"checking for drift from cron jobs.\n" +
+		"\n" +
+		"#### `--interactive`\n" +
+		"\n" +
+		"Fetch changes from the source VCS without pulling them, print the incoming\n" +
+		"commits and the diff that applying them would make, and then prompt before\n" +
+		"pulling and applying them.\n" +
+		"\n" +
+		"The diff is computed without running anything from the incoming changes:\n" +
+		"scripts are not run, files with `modify_` scripts are shown as unchanged,\n" +
+		"and only externals that are already cached are included.\n" +
 		"\n" +
 		"#### `update` examples\n" +
 		"\n" +
 		"    chezmoi update\n" +
+		"    chezmoi update --interactive\n" +
+		"    chezmoi update --fail-if-changes\n" +
 		"\n" +
 		"### `upgrade`\n" +
 		"\n" +
@@ -2818,21 +3368,43 @@ func init() {
 		"\n" +
 		"chezmoi provides the following automatically populated variables:\n" +
 		"\n" +
-		"| Variable                | Value                                                                                                                           |\n" +
-		"| ----------------------- | ------------------------------------------------------------------------------------------------------------------------------- |\n" +
-		"| `.chezmoi.arch`         | Architecture, e.g. `amd64`, `arm`, etc. as returned by [runtime.GOARCH](https://pkg.go.dev/runtime?tab=doc#pkg-constants).      |\n" +
-		"| `.chezmoi.fullHostname` | The full hostname of the machine chezmoi is running on.                                                                         |\n" +
-		"| `.chezmoi.group`        | The group of the user running chezmoi.                                                                                          |\n" +
-		"| `.chezmoi.homedir`      | The home directory of the user running chezmoi.                                                                                 |\n" +
-		"| `.chezmoi.hostname`     | The hostname of the machine chezmoi is running on, up to the first `.`.                                                         |\n" +
-		"| `.chezmoi.kernel`       | Contains information from `/proc/sys/kernel`. Linux only, useful for detecting specific kernels (i.e. Microsoft's WSL kernel).  |\n" +
-		"| `.chezmoi.os`           | Operating system, e.g. `darwin`, `linux`, etc. as returned by [runtime.GOOS](https://pkg.go.dev/runtime?tab=doc#pkg-constants). |\n" +
-		"| `.chezmoi.osRelease`    | The information from `/etc/os-release`, Linux only, run `chezmoi data` to see its output.                                       |\n" +
-		"| `.chezmoi.sourceDir`    | The source directory.                                                                                                           |\n" +
-		"| `.chezmoi.username`     | The username of the user running chezmoi.                                                                                       |\n" +
-		"\n" +
-		"Additional variables can be defined in the config file in the `data` section.\n" +
-		"Variable names must consist of a letter and be followed by zero or more letters\n" +
+		"| Variable                        | Value                                                                                                                                                                                                                                        |\n" +
+		"| ------------------------------- | -------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------- |\n" +
+		"| `.chezmoi.arch`                 | Architecture, e.g. `amd64`, `arm`, etc. as returned by [runtime.GOARCH](https://pkg.go.dev/runtime?tab=doc#pkg-constants).                                                                                                                   |\n" +
+		"| `.chezmoi.facts.cpu`            | The number of logical CPUs (`count`), and on Linux the number of physical cores (`cores`) and the CPU model (`model`).                                                                                                                       |\n" +
+		"| `.chezmoi.facts.desktop`        | The desktop environment (`desktop`) and session type (`session`, e.g. `x11` or `wayland`), Linux only, only set when running in a graphical session.                                                                                         |\n" +
+		"| `.chezmoi.facts.memory`         | The total memory in bytes (`total`), Linux only.                                                                                                                                                                                             |\n" +
+		"| `.chezmoi.facts.network`        | The network interfaces (`interfaces`) with their MAC addresses (`address`), states (`operstate`), and IP addresses (`ips`), and, on Linux only, the interface of the default route (`primaryInterface`) and its IP addresses (`primaryIPs`). |\n" +
+		"| `.chezmoi.facts.packageManager` | The installed package managers (`all`) and the preferred one (`default`), e.g. `apt`, `dnf`, `pacman`, or `brew`.                                                                                                                            |\n" +
+		"| `.chezmoi.facts.virtualization` | The container (`container`, e.g. `docker`, `podman`, `kubepods`, or `lxc`) and virtual machine (`vm`, e.g. `qemu`, `kvm`, or `vmware`) chezmoi is running in, and whether it is running in WSL (`wsl`), Linux only.                          |\n" +
+		"| `.chezmoi.fullHostname`         | The full hostname of the machine chezmoi is running on.                                                                                                                                                                                      |\n" +
+		"| `.chezmoi.group`                | The group of the user running chezmoi.                                                                                                                                                                                                       |\n" +
+		"| `.chezmoi.homedir`              | The home directory of the user running chezmoi.                                                                                                                                                                                              |\n" +
+		"| `.chezmoi.hostname`             | The hostname of the machine chezmoi is running on, up to the first `.`.                                                                                                                                                                      |\n" +
+		"| `.chezmoi.kernel`               | Contains information from `/proc/sys/kernel`. Linux only, useful for detecting specific kernels (i.e. Microsoft's WSL kernel).                                                                                                               |\n" +
+		"| `.chezmoi.os`                   | Operating system, e.g. `darwin`, `linux`, etc. as returned by [runtime.GOOS](https://pkg.go.dev/runtime?tab=doc#pkg-constants).                                                                                                              |\n" +
+		"| `.chezmoi.osRelease`            | The information from `/etc/os-release`, Linux only, run `chezmoi data` to see its output.                                                                                                                                                    |\n" +
+		"| `.chezmoi.sourceDir`            | The source directory.                                                                                                                                                                                                                        |\n" +
+		"| `.chezmoi.username`             | The username of the user running chezmoi.                                                                                                                                                                                                    |\n" +
+		"\n" +
+		"Facts that are not available on the current machine are omitted. In\n" +
+		"particular, the `desktop`, `memory`, and `virtualization` facts, the `cores` and\n" +
+		"`model` CPU facts, and the `primaryInterface` and `primaryIPs` network facts are\n" +
+		"only collected on Linux, so on macOS, Windows, and other operating systems only\n" +
+		"`cpu.count`, `network.interfaces`, and `packageManager` are available. Facts\n" +
+		"that cannot be read, for example because a file in `/proc` is malformed, are\n" +
+		"also omitted, and the reason is printed when `--debug` is given. For example,\n" +
+		"to install packages with the machine's package manager:\n" +
+		"\n" +
+		"    {{ if eq .chezmoi.facts.packageManager.default \"apt\" -}}\n" +
+		"    sudo apt-get install -y ripgrep\n" +
+		"    {{ else if eq .chezmoi.facts.packageManager.default \"brew\" -}}\n" +
+		"    brew install ripgrep\n" +
+		"    {{ end -}}\n" +
+		"\n" +
+		"Additional variables can be defined in the config file in the `data` section and\n" +
+		"in `.chezmoidata.<format>` files and the `.chezmoidata` directory in the source\n" +
+		"state. Variable names must consist of a letter and be followed by zero or more letters\n" +
 		"and/or digits.\n" +
 		"\n" +
 		"## Template functions\n" +
@@ -2921,6 +3493,41 @@ func init() {
 		"\n" +
 		"    {{ (bitwardenFields \"item\" \"example.com\").token.value }}\n" +
 		"\n" +
+		"### `fromIni` *text*\n" +
+		"\n" +
+		"`fromIni` parses *text* as INI and returns a map. Keys outside any section are\n" +
+		"at the top level and each section is a map of its keys. All values are strings.\n" +
+		"\n" +
+		"#### `fromIni` examples\n" +
+		"\n" +
+		"    {{ (fromIni (readDestFile \".gitconfig\")).user.email }}\n" +
+		"\n" +
+		"### `fromJson` *text*\n" +
+		"\n" +
+		"`fromJson` parses *text* as JSON and returns the resulting value. Use the\n" +
+		"[`toJson` and `toPrettyJson`](http://masterminds.github.io/sprig/defaults.html)\n" +
+		"functions to convert values back to JSON.\n" +
+		"\n" +
+		"#### `fromJson` examples\n" +
+		"\n" +
+		"    {{ (fromJson (readDestFile \".config/Code/User/settings.json\")).editor.fontSize }}\n" +
+		"\n" +
+		"### `fromToml` *text*\n" +
+		"\n" +
+		"`fromToml` parses *text* as TOML and returns a map.\n" +
+		"\n" +
+		"#### `fromToml` examples\n" +
+		"\n" +
+		"    {{ (fromToml (readDestFile \".config/starship.toml\")).add_newline }}\n" +
+		"\n" +
+		"### `fromYaml` *text*\n" +
+		"\n" +
+		"`fromYaml` parses *text* as YAML and returns the resulting value.\n" +
+		"\n" +
+		"#### `fromYaml` examples\n" +
+		"\n" +
+		"    {{ (fromYaml (readDestFile \".kube/config\")).\"current-context\" }}\n" +
+		"\n" +
 		"### `gopass` *gopass-name*\n" +
 		"\n" +
 		"`gopass` returns passwords stored in [gopass](https://www.gopass.pw/) using the\n" +
@@ -2966,6 +3573,18 @@ func init() {
 		"\n" +
 		"    {{ joinPath .chezmoi.homedir \".zshrc\" }}\n" +
 		"\n" +
+		"### `jq` *query* *input*\n" +
+		"\n" +
+		"`jq` runs the [jq](https://stedolan.github.io/jq/) query *query* against\n" +
+		"*input* and returns a list of the results. *input* can be any value, for example\n" +
+		"one returned by `fromJson` or `fromYaml`.\n" +
+		"\n" +
+		"#### `jq` examples\n" +
+		"\n" +
+		"    {{ range jq \".contexts[].name\" (fromYaml (readDestFile \".kube/config\")) }}\n" +
+		"    {{ . }}\n" +
+		"    {{ end }}\n" +
+		"\n" +
 		"### `keepassxc` *entry*\n" +
 		"\n" +
 		"`keepassxc` returns structured data retrieved from a\n" +
@@ -3182,6 +3801,21 @@ func init() {
 		"    [data]\n" +
 		"        email = \"{{ $email }}\"\n" +
 		"\n" +
+		"### `readDestFile` *filename*\n" +
+		"\n" +
+		"`readDestFile` returns the current contents of the file named *filename*,\n" +
+		"relative to the destination directory, or an empty string if the file does not\n" +
+		"exist. Together with the parsing functions, it allows a template to own only\n" +
+		"some of the keys in a file that is otherwise managed by another application.\n" +
+		"\n" +
+		"#### `readDestFile` examples\n" +
+		"\n" +
+		"To set the font size in Visual Studio Code's settings while keeping all other\n" +
+		"settings, create `dot_config/Code/User/settings.json.tmpl` containing:\n" +
+		"\n" +
+		"    {{- $settings := readDestFile \".config/Code/User/settings.json\" | default \"{}\" | fromJson -}}\n" +
+		"    {{- mergeOverwrite $settings (dict \"editor.fontSize\" 14) | toPrettyJson -}}\n" +
+		"\n" +
 		"### `secret` [*args*]\n" +
 		"\n" +
 		"`secret` returns the output of the generic secret command defined by the\n" +
@@ -3196,6 +3830,15 @@ func init() {
 		"parsed as JSON. The output is cached so multiple calls to `secret` with the same\n" +
 		"*args* will only invoke the generic secret command once.\n" +
 		"\n" +
+		"### `setValueAtPath` *path* *value* *dict*\n" +
+		"\n" +
+		"`setValueAtPath` sets the value at the dot-separated *path* in the map *dict*\n" +
+		"to *value*, creating intermediate maps as needed, and returns *dict*.\n" +
+		"\n" +
+		"#### `setValueAtPath` examples\n" +
+		"\n" +
+		"    {{ fromToml (readDestFile \".config/alacritty/alacritty.toml\") | setValueAtPath \"font.size\" 12 | toToml }}\n" +
+		"\n" +
 		"### `stat` *name*\n" +
 		"\n" +
 		"`stat` runs `stat(2)` on *name*. If *name* exists it returns structured data. If\n" +
@@ -3214,6 +3857,31 @@ func init() {
 		"    # ~/.pyenv exists\n" +
 		"    {{ end }}\n" +
 		"\n" +
+		"### `toIni` *dict*\n" +
+		"\n" +
+		"`toIni` returns *dict* as INI. Values that are maps become sections and all\n" +
+		"other values are written before the first section.\n" +
+		"\n" +
+		"#### `toIni` examples\n" +
+		"\n" +
+		"    {{ fromIni (readDestFile \".gitconfig\") | setValueAtPath \"user.email\" \"me@home.org\" | toIni }}\n" +
+		"\n" +
+		"### `toToml` *dict*\n" +
+		"\n" +
+		"`toToml` returns *dict* as TOML.\n" +
+		"\n" +
+		"#### `toToml` examples\n" +
+		"\n" +
+		"    {{ dict \"add_newline\" false | toToml }}\n" +
+		"\n" +
+		"### `toYaml` *value*\n" +
+		"\n" +
+		"`toYaml` returns *value* as YAML.\n" +
+		"\n" +
+		"#### `toYaml` examples\n" +
+		"\n" +
+		"    {{ fromYaml (readDestFile \".kube/config\") | setValueAtPath \"current-context\" \"home\" | toYaml }}\n" +
+		"\n" +
 		"### `vault` *key*\n" +
 		"\n" +
 		"`vault` returns structured data from [Vault](https://www.vaultproject.io/) using\n" +
@@ -96,12 +96,7 @@ func (c *Config) runDoctorCmd(cmd *cobra.Command, args []string) error {
 
 	var vcsCommandCheck doctorCheck
 	if vcs, err := c.getVCS(); err == nil {
-		vcsCommandCheck = &doctorBinaryCheck{
-			name:          "source VCS command",
-			binaryName:    c.SourceVCS.Command,
-			versionArgs:   vcs.VersionArgs(),
-			versionRegexp: vcs.VersionRegexp(),
-		}
+		vcsCommandCheck = vcs.doctorCheck()
 	} else {
 		vcsCommandCheck = &doctorBinaryCheck{
 			name:       "source VCS command",
@@ -149,6 +144,11 @@ func (c *Config) runDoctorCmd(cmd *cobra.Command, args []string) error {
 			path:    c.KeePassXC.Database,
 			canSkip: true,
 		},
+		&doctorFileCheck{
+			name:    "age identity file",
+			path:    c.AGE.Identity,
+			canSkip: true,
+		},
 		editorCheck,
 		&doctorBinaryCheck{
 			name:       "merge command",
@@ -42,8 +42,10 @@ func TestDumpCmd(t *testing.T) {
 					"type":       "file",
 					"sourcePath": filepath.Join("/", "home", "user", ".local", "share", "chezmoi", "dir", "file"),
 					"targetPath": filepath.Join("dir", "file"),
+					"create":     false,
 					"empty":      false,
 					"encrypted":  false,
+					"modify":     false,
 					"perm":       float64(0o644),
 					"template":   false,
 					"contents":   "contents",
@@ -60,3 +62,44 @@ func TestDumpCmd(t *testing.T) {
 	}
 	assert.Equal(t, expected, actual)
 }
+
+func TestDumpCmdNestedIgnore(t *testing.T) {
+	fs, cleanup, err := newNestedIgnoreTestFS()
+	require.NoError(t, err)
+	defer cleanup()
+	stdout := &bytes.Buffer{}
+	c := newTestConfig(
+		fs,
+		withDumpCmdConfig(dumpCmdConfig{
+			format:    "json",
+			recursive: true,
+		}),
+		withStdout(stdout),
+	)
+	require.NoError(t, c.runDumpCmd(nil, []string{"/home/user/.config", "/home/user/.ssh"}))
+	var actual []map[string]interface{}
+	require.NoError(t, json.NewDecoder(stdout).Decode(&actual))
+	var targetPaths []string
+	for _, entry := range actual {
+		targetPaths = appendDumpTargetPaths(targetPaths, entry)
+	}
+	assert.Equal(t, []string{
+		".config",
+		filepath.Join(".config", "keep.log"),
+		filepath.Join(".config", "nvim"),
+		filepath.Join(".config", "nvim", "init.vim"),
+		".ssh",
+		filepath.Join(".ssh", "known_hosts"),
+	}, targetPaths)
+}
+
+// appendDumpTargetPaths appends the target paths of entry and all of its
+// entries to targetPaths.
+func appendDumpTargetPaths(targetPaths []string, entry map[string]interface{}) []string {
+	targetPaths = append(targetPaths, entry["targetPath"].(string))
+	entries, _ := entry["entries"].([]interface{})
+	for _, childEntry := range entries {
+		targetPaths = appendDumpTargetPaths(targetPaths, childEntry.(map[string]interface{}))
+	}
+	return targetPaths
+}
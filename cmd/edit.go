@@ -71,7 +71,7 @@ func (c *Config) runEditCmd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	entries, err := c.getEntries(ts, args)
+	entries, err := c.getSourceEntries(ts, args)
 	if err != nil {
 		return err
 	}
@@ -133,7 +133,7 @@ func (c *Config) runEditCmd(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return err
 		}
-		ciphertext, err := ts.GPG.Encrypt(ef.plaintextPath, plaintext)
+		ciphertext, err := ts.Encryption.Encrypt(ef.plaintextPath, plaintext)
 		if err != nil {
 			return err
 		}
@@ -148,7 +148,7 @@ func (c *Config) runEditCmd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	entries, err = c.getEntries(ts, args)
+	entries, err = c.getSourceEntries(ts, args)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// An execVCSDriver is a vcsDriver that runs the source VCS command with the
+// arguments returned by a VCS.
+type execVCSDriver struct {
+	c   *Config
+	vcs VCS
+}
+
+func (d *execVCSDriver) Add(dir string) error {
+	addArgs := d.vcs.AddArgs(".")
+	if addArgs == nil {
+		return d.notSupportedError("add")
+	}
+	return d.c.run(dir, d.c.SourceVCS.Command, addArgs...)
+}
+
+func (d *execVCSDriver) CheckoutUpstream(dir, dst string) error {
+	rawDst, err := d.c.fs.RawPath(dst)
+	if err != nil {
+		return err
+	}
+	checkoutArgs := d.vcs.UpstreamCheckoutArgs(rawDst)
+	if checkoutArgs == nil {
+		return d.notSupportedError("checking out upstream")
+	}
+	_, err = d.c.output(dir, d.c.SourceVCS.Command, checkoutArgs...)
+	return err
+}
+
+func (d *execVCSDriver) Clone(repo, dir string, options vcsCloneOptions) error {
+	rawDir, err := d.c.fs.RawPath(dir)
+	if err != nil {
+		return err
+	}
+	cloneArgs, err := d.vcs.CloneArgs(repo, rawDir, options)
+	if err != nil {
+		return err
+	}
+	if cloneArgs == nil {
+		return d.notSupportedError("cloning")
+	}
+	if err := d.c.run("", d.c.SourceVCS.Command, cloneArgs...); err != nil {
+		return err
+	}
+	postCloneArgs, err := d.vcs.PostCloneArgs(d.c.fs, dir, options)
+	if err != nil {
+		return err
+	}
+	return d.runAll(dir, postCloneArgs)
+}
+
+func (d *execVCSDriver) Commit(dir, message string) error {
+	return d.c.run(dir, d.c.SourceVCS.Command, d.vcs.CommitArgs(message)...)
+}
+
+func (d *execVCSDriver) Fetch(dir string) error {
+	fetchArgs := d.vcs.FetchArgs()
+	if fetchArgs == nil {
+		return d.notSupportedError("fetch")
+	}
+	_, err := d.c.output(dir, d.c.SourceVCS.Command, fetchArgs...)
+	return err
+}
+
+func (d *execVCSDriver) Git() bool {
+	_, ok := d.vcs.(gitVCS)
+	return ok
+}
+
+func (d *execVCSDriver) IncomingLog(dir string) ([]byte, error) {
+	incomingLogArgs := d.vcs.IncomingLogArgs()
+	if incomingLogArgs == nil {
+		return nil, d.notSupportedError("incoming log")
+	}
+	return d.c.output(dir, d.c.SourceVCS.Command, incomingLogArgs...)
+}
+
+// Init runs sourceVCS.init if it is set, otherwise the VCS's default init
+// command.
+func (d *execVCSDriver) Init(dir string) error {
+	initArgs := d.vcs.InitArgs()
+	if d.c.SourceVCS.Init != nil {
+		var err error
+		initArgs, err = parseSourceVCSArgs("sourceVCS.init", d.c.SourceVCS.Init)
+		if err != nil {
+			return err
+		}
+	}
+	return d.c.run(dir, d.c.SourceVCS.Command, initArgs...)
+}
+
+func (d *execVCSDriver) Initialized(dir string) (bool, error) {
+	rawDir, err := d.c.fs.RawPath(dir)
+	if err != nil {
+		return false, err
+	}
+	return d.vcs.Initialized(rawDir)
+}
+
+func (d *execVCSDriver) Merge(dir string) error {
+	mergeArgs := d.vcs.MergeArgs()
+	if mergeArgs == nil {
+		return d.notSupportedError("merge")
+	}
+	if err := d.c.run(dir, d.c.SourceVCS.Command, mergeArgs...); err != nil {
+		return err
+	}
+	return d.runPostPullCmds(dir)
+}
+
+// Pull runs sourceVCS.pull if it is set, otherwise the VCS's default pull
+// command, and then any post-pull commands.
+func (d *execVCSDriver) Pull(dir string) error {
+	pullArgs := d.vcs.PullArgs()
+	if d.c.SourceVCS.Pull != nil {
+		var err error
+		pullArgs, err = parseSourceVCSArgs("sourceVCS.pull", d.c.SourceVCS.Pull)
+		if err != nil {
+			return err
+		}
+	}
+	if pullArgs == nil {
+		return d.notSupportedError("pull")
+	}
+	if err := d.c.run(dir, d.c.SourceVCS.Command, pullArgs...); err != nil {
+		return err
+	}
+	return d.runPostPullCmds(dir)
+}
+
+func (d *execVCSDriver) Push(dir string) error {
+	pushArgs := d.vcs.PushArgs()
+	if pushArgs == nil {
+		return d.notSupportedError("push")
+	}
+	err := d.c.run(dir, d.c.SourceVCS.Command, pushArgs...)
+	// hg push exits with status 1 if there is nothing to push.
+	var exitErr *exec.ExitError
+	if _, ok := d.vcs.(hgVCS); ok && errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return nil
+	}
+	return err
+}
+
+func (d *execVCSDriver) RemoveUpstreamCheckout(dir, dst string) error {
+	rawDst, err := d.c.fs.RawPath(dst)
+	if err != nil {
+		return err
+	}
+	cleanupArgs := d.vcs.UpstreamCheckoutCleanupArgs(rawDst)
+	if cleanupArgs == nil {
+		return nil
+	}
+	_, err = d.c.output(dir, d.c.SourceVCS.Command, cleanupArgs...)
+	return err
+}
+
+func (d *execVCSDriver) Run(dir string, args []string) error {
+	return d.c.run(dir, d.c.SourceVCS.Command, args...)
+}
+
+func (d *execVCSDriver) Status(dir string) (interface{}, error) {
+	output, err := d.c.output(dir, d.c.SourceVCS.Command, d.vcs.StatusArgs()...)
+	if err != nil {
+		return nil, err
+	}
+	return d.vcs.ParseStatusOutput(output)
+}
+
+func (d *execVCSDriver) doctorCheck() *doctorBinaryCheck {
+	return &doctorBinaryCheck{
+		name:          "source VCS command",
+		binaryName:    d.c.SourceVCS.Command,
+		versionArgs:   d.vcs.VersionArgs(),
+		versionRegexp: d.vcs.VersionRegexp(),
+	}
+}
+
+func (d *execVCSDriver) notSupportedError(operation string) error {
+	return fmt.Errorf("%s: %s not supported", d.c.SourceVCS.Command, operation)
+}
+
+func (d *execVCSDriver) runAll(dir string, argss [][]string) error {
+	for _, args := range argss {
+		if err := d.c.run(dir, d.c.SourceVCS.Command, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runPostPullCmds runs the VCS's post-pull commands in dir.
+func (d *execVCSDriver) runPostPullCmds(dir string) error {
+	postPullArgs, err := d.vcs.PostPullArgs(d.c.fs, dir)
+	if err != nil {
+		return err
+	}
+	return d.runAll(dir, postPullArgs)
+}
+
+// parseSourceVCSArgs parses the value of the config variable key as command
+// arguments.
+func parseSourceVCSArgs(key string, value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return strings.Split(v, " "), nil
+	case []string:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("%s: cannot parse value", key)
+	}
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"os"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// A factCollector collects a single fact about the machine. collect returns
+// nil if the fact is not available.
+type factCollector struct {
+	name    string
+	collect func(fs vfs.FS, getenv func(string) string) (interface{}, error)
+}
+
+// packageManagers is a list of package managers and the paths of their
+// executables, in order of preference.
+var packageManagers = []struct {
+	name  string
+	paths []string
+}{
+	{name: "apt", paths: []string{"/usr/bin/apt-get"}},
+	{name: "dnf", paths: []string{"/usr/bin/dnf"}},
+	{name: "yum", paths: []string{"/usr/bin/yum"}},
+	{name: "zypper", paths: []string{"/usr/bin/zypper"}},
+	{name: "pacman", paths: []string{"/usr/bin/pacman"}},
+	{name: "apk", paths: []string{"/sbin/apk"}},
+	{name: "emerge", paths: []string{"/usr/bin/emerge"}},
+	{name: "xbps", paths: []string{"/usr/bin/xbps-install"}},
+	{name: "nix", paths: []string{"/run/current-system/sw/bin/nix-env", "/nix/var/nix/profiles/default/bin/nix-env"}},
+	{name: "pkg", paths: []string{"/usr/sbin/pkg"}},
+	{name: "port", paths: []string{"/opt/local/bin/port"}},
+	{name: "brew", paths: []string{"/opt/homebrew/bin/brew", "/usr/local/bin/brew", "/home/linuxbrew/.linuxbrew/bin/brew"}},
+}
+
+// getFacts returns the facts collected by collectors. Facts that are not
+// available, or that cannot be collected for any other reason, for example
+// because a file in /proc cannot be parsed, are omitted. Errors other than
+// missing files and permissions are reported with logf.
+func getFacts(fs vfs.FS, getenv func(string) string, collectors []factCollector, logf func(string, ...interface{})) map[string]interface{} {
+	facts := make(map[string]interface{})
+	for _, collector := range collectors {
+		fact, err := collector.collect(fs, getenv)
+		switch {
+		case os.IsNotExist(err) || os.IsPermission(err):
+			continue
+		case err != nil:
+			logf("facts.%s: %v", collector.name, err)
+			continue
+		case fact == nil:
+			continue
+		}
+		facts[collector.name] = fact
+	}
+	return facts
+}
+
+// getPackageManagerFacts returns the installed package managers in fs.
+func getPackageManagerFacts(fs vfs.FS, getenv func(string) string) (interface{}, error) {
+	var names []interface{}
+	for _, packageManager := range packageManagers {
+		if anyRegularFile(fs, packageManager.paths) {
+			names = append(names, packageManager.name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	return map[string]interface{}{
+		"default": names[0],
+		"all":     names,
+	}, nil
+}
+
+// anyRegularFile returns true if any of paths in fs is a regular file.
+func anyRegularFile(fs vfs.FS, paths []string) bool {
+	for _, path := range paths {
+		if info, err := fs.Stat(path); err == nil && info.Mode().IsRegular() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,410 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+var factCollectors = []factCollector{
+	{name: "cpu", collect: getCPUFacts},
+	{name: "desktop", collect: getDesktopFacts},
+	{name: "memory", collect: getMemoryFacts},
+	{name: "network", collect: getNetworkFacts},
+	{name: "packageManager", collect: getPackageManagerFacts},
+	{name: "virtualization", collect: getVirtualizationFacts},
+}
+
+// An ipv4Route is a route from /proc/net/route.
+type ipv4Route struct {
+	iface       string
+	destination net.IP
+	mask        net.IPMask
+	metric      int
+}
+
+// getCPUFacts returns the number of logical CPUs, physical cores, and the CPU
+// model from /proc/cpuinfo.
+func getCPUFacts(fs vfs.FS, getenv func(string) string) (interface{}, error) {
+	data, err := fs.ReadFile("/proc/cpuinfo")
+	if err != nil {
+		return nil, err
+	}
+	count := 0
+	model := ""
+	physicalID := ""
+	cores := make(map[string]struct{})
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		fields := strings.SplitN(s.Text(), ":", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		key, value := strings.TrimSpace(fields[0]), strings.TrimSpace(fields[1])
+		switch key {
+		case "processor":
+			count++
+		case "model name":
+			if model == "" {
+				model = value
+			}
+		case "physical id":
+			physicalID = value
+		case "core id":
+			cores[physicalID+":"+value] = struct{}{}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	cpuFacts := map[string]interface{}{
+		"count": count,
+	}
+	if len(cores) != 0 {
+		cpuFacts["cores"] = len(cores)
+	}
+	if model != "" {
+		cpuFacts["model"] = model
+	}
+	return cpuFacts, nil
+}
+
+// getDesktopFacts returns the desktop environment and session type from the
+// environment.
+func getDesktopFacts(fs vfs.FS, getenv func(string) string) (interface{}, error) {
+	desktopFacts := make(map[string]interface{})
+	if desktop := getenv("XDG_CURRENT_DESKTOP"); desktop != "" {
+		desktopFacts["desktop"] = desktop
+	} else if desktop := getenv("DESKTOP_SESSION"); desktop != "" {
+		desktopFacts["desktop"] = desktop
+	}
+	switch sessionType := getenv("XDG_SESSION_TYPE"); {
+	case sessionType != "":
+		desktopFacts["session"] = sessionType
+	case getenv("WAYLAND_DISPLAY") != "":
+		desktopFacts["session"] = "wayland"
+	case getenv("DISPLAY") != "":
+		desktopFacts["session"] = "x11"
+	}
+	if len(desktopFacts) == 0 {
+		return nil, nil
+	}
+	return desktopFacts, nil
+}
+
+// getMemoryFacts returns the total memory in bytes from /proc/meminfo.
+func getMemoryFacts(fs vfs.FS, getenv func(string) string) (interface{}, error) {
+	data, err := fs.ReadFile("/proc/meminfo")
+	if err != nil {
+		return nil, err
+	}
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 2 || fields[0] != "MemTotal:" {
+			continue
+		}
+		total, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("/proc/meminfo: %w", err)
+		}
+		if len(fields) >= 3 && fields[2] == "kB" {
+			total *= 1024
+		}
+		return map[string]interface{}{
+			"total": total,
+		}, nil
+	}
+	return nil, s.Err()
+}
+
+// getNetworkFacts returns the network interfaces from /sys/class/net, their
+// IPv4 addresses from /proc/net/fib_trie and /proc/net/route, their IPv6
+// addresses from /proc/net/if_inet6, and the primary interface and IP
+// addresses, which are those of the interface with the default route.
+func getNetworkFacts(fs vfs.FS, getenv func(string) string) (interface{}, error) {
+	infos, err := fs.ReadDir("/sys/class/net")
+	if err != nil {
+		return nil, err
+	}
+
+	routes, err := readIPv4Routes(fs)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	ipv4Addrs, err := readLocalIPv4Addrs(fs)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	ipsByIface := make(map[string][]interface{})
+	for _, ip := range ipv4Addrs {
+		if iface := ipv4Iface(ip, routes); iface != "" {
+			ipsByIface[iface] = append(ipsByIface[iface], ip.String())
+		}
+	}
+	ipv6AddrsByIface, err := readIPv6Addrs(fs)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for iface, ipv6Addrs := range ipv6AddrsByIface {
+		for _, ip := range ipv6Addrs {
+			ipsByIface[iface] = append(ipsByIface[iface], ip.String())
+		}
+	}
+
+	interfaces := make(map[string]interface{})
+	for _, info := range infos {
+		name := info.Name()
+		ips := ipsByIface[name]
+		if ips == nil {
+			ips = []interface{}{}
+		}
+		iface := map[string]interface{}{
+			"ips": ips,
+		}
+		for _, key := range []string{"address", "operstate"} {
+			data, err := fs.ReadFile(filepath.Join("/sys/class/net", name, key))
+			if err == nil {
+				iface[key] = string(bytes.TrimSpace(data))
+			}
+		}
+		interfaces[name] = iface
+	}
+
+	networkFacts := map[string]interface{}{
+		"interfaces": interfaces,
+	}
+	if primaryInterface := defaultIPv4Route(routes); primaryInterface != "" {
+		networkFacts["primaryInterface"] = primaryInterface
+		primaryIPs := []interface{}{}
+		for _, ip := range ipv4Addrs {
+			if ipv4Iface(ip, routes) == primaryInterface {
+				primaryIPs = append(primaryIPs, ip.String())
+			}
+		}
+		for _, ip := range ipv6AddrsByIface[primaryInterface] {
+			if ip.IsGlobalUnicast() {
+				primaryIPs = append(primaryIPs, ip.String())
+			}
+		}
+		networkFacts["primaryIPs"] = primaryIPs
+	}
+	return networkFacts, nil
+}
+
+// getVirtualizationFacts returns whether we are running in a container, in
+// WSL, or in a virtual machine.
+func getVirtualizationFacts(fs vfs.FS, getenv func(string) string) (interface{}, error) {
+	virtualizationFacts := make(map[string]interface{})
+
+	switch {
+	case fileExists(fs, "/.dockerenv"):
+		virtualizationFacts["container"] = "docker"
+	case fileExists(fs, "/run/.containerenv"):
+		virtualizationFacts["container"] = "podman"
+	default:
+		if data, err := fs.ReadFile("/proc/1/cgroup"); err == nil {
+			for _, container := range []string{"docker", "kubepods", "lxc"} {
+				if bytes.Contains(data, []byte(container)) {
+					virtualizationFacts["container"] = container
+					break
+				}
+			}
+		}
+	}
+
+	wsl := fileExists(fs, "/proc/sys/fs/binfmt_misc/WSLInterop")
+	if data, err := fs.ReadFile("/proc/sys/kernel/osrelease"); err == nil {
+		osRelease := strings.ToLower(string(data))
+		wsl = wsl || strings.Contains(osRelease, "microsoft") || strings.Contains(osRelease, "wsl")
+	}
+	virtualizationFacts["wsl"] = wsl
+
+	var dmi []string
+	for _, name := range []string{"sys_vendor", "product_name"} {
+		if data, err := fs.ReadFile(filepath.Join("/sys/class/dmi/id", name)); err == nil {
+			dmi = append(dmi, strings.TrimSpace(string(data)))
+		}
+	}
+	switch dmiStr := strings.Join(dmi, " "); {
+	case strings.Contains(dmiStr, "QEMU"):
+		virtualizationFacts["vm"] = "qemu"
+	case strings.Contains(dmiStr, "KVM"):
+		virtualizationFacts["vm"] = "kvm"
+	case strings.Contains(dmiStr, "VMware"):
+		virtualizationFacts["vm"] = "vmware"
+	case strings.Contains(dmiStr, "VirtualBox") || strings.Contains(dmiStr, "innotek"):
+		virtualizationFacts["vm"] = "virtualbox"
+	case strings.Contains(dmiStr, "Xen"):
+		virtualizationFacts["vm"] = "xen"
+	case strings.Contains(dmiStr, "Microsoft Corporation Virtual Machine"):
+		virtualizationFacts["vm"] = "hyperv"
+	case strings.Contains(dmiStr, "Amazon EC2"):
+		virtualizationFacts["vm"] = "amazon"
+	case strings.Contains(dmiStr, "Google Compute Engine"):
+		virtualizationFacts["vm"] = "google"
+	case strings.Contains(dmiStr, "Parallels"):
+		virtualizationFacts["vm"] = "parallels"
+	}
+
+	return virtualizationFacts, nil
+}
+
+// defaultIPv4Route returns the interface of the default route with the lowest
+// metric in routes.
+func defaultIPv4Route(routes []ipv4Route) string {
+	iface := ""
+	metric := 0
+	for _, route := range routes {
+		if ones, _ := route.mask.Size(); ones != 0 || !route.destination.Equal(net.IPv4zero) {
+			continue
+		}
+		if iface == "" || route.metric < metric {
+			iface = route.iface
+			metric = route.metric
+		}
+	}
+	return iface
+}
+
+// fileExists returns true if path exists in fs.
+func fileExists(fs vfs.FS, path string) bool {
+	_, err := fs.Lstat(path)
+	return err == nil
+}
+
+// ipv4Iface returns the interface of the most specific non-default route in
+// routes that contains ip.
+func ipv4Iface(ip net.IP, routes []ipv4Route) string {
+	if ip.IsLoopback() {
+		return "lo"
+	}
+	iface := ""
+	bestOnes := 0
+	for _, route := range routes {
+		ones, _ := route.mask.Size()
+		if ones == 0 || ones < bestOnes {
+			continue
+		}
+		if ip.Mask(route.mask).Equal(route.destination) {
+			iface = route.iface
+			bestOnes = ones
+		}
+	}
+	return iface
+}
+
+// parseHexIPv4 parses a little-endian hexadecimal IPv4 address, as used in
+// /proc/net/route.
+func parseHexIPv4(s string) (net.IP, error) {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != net.IPv4len {
+		return nil, fmt.Errorf("%s: invalid IPv4 address", s)
+	}
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, binary.LittleEndian.Uint32(data))
+	return ip, nil
+}
+
+// readIPv4Routes reads the IPv4 routes from /proc/net/route.
+func readIPv4Routes(fs vfs.FS) ([]ipv4Route, error) {
+	data, err := fs.ReadFile("/proc/net/route")
+	if err != nil {
+		return nil, err
+	}
+	var routes []ipv4Route
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 8 || fields[0] == "Iface" {
+			continue
+		}
+		destination, err := parseHexIPv4(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("/proc/net/route: %w", err)
+		}
+		metric, err := strconv.Atoi(fields[6])
+		if err != nil {
+			return nil, fmt.Errorf("/proc/net/route: %w", err)
+		}
+		mask, err := parseHexIPv4(fields[7])
+		if err != nil {
+			return nil, fmt.Errorf("/proc/net/route: %w", err)
+		}
+		routes = append(routes, ipv4Route{
+			iface:       fields[0],
+			destination: destination,
+			mask:        net.IPMask(mask),
+			metric:      metric,
+		})
+	}
+	return routes, s.Err()
+}
+
+// readIPv6Addrs reads the IPv6 addresses of each interface from
+// /proc/net/if_inet6.
+func readIPv6Addrs(fs vfs.FS) (map[string][]net.IP, error) {
+	data, err := fs.ReadFile("/proc/net/if_inet6")
+	if err != nil {
+		return nil, err
+	}
+	ipv6Addrs := make(map[string][]net.IP)
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) != 6 {
+			continue
+		}
+		ipData, err := hex.DecodeString(fields[0])
+		if err != nil || len(ipData) != net.IPv6len {
+			return nil, fmt.Errorf("/proc/net/if_inet6: %s: invalid IPv6 address", fields[0])
+		}
+		ipv6Addrs[fields[5]] = append(ipv6Addrs[fields[5]], net.IP(ipData))
+	}
+	return ipv6Addrs, s.Err()
+}
+
+// readLocalIPv4Addrs reads the sorted local IPv4 addresses from
+// /proc/net/fib_trie.
+func readLocalIPv4Addrs(fs vfs.FS) ([]net.IP, error) {
+	data, err := fs.ReadFile("/proc/net/fib_trie")
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]struct{})
+	var ips []net.IP
+	var lastIP net.IP
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		text := strings.TrimSpace(s.Text())
+		switch {
+		case strings.HasPrefix(text, "|-- "):
+			lastIP = net.ParseIP(strings.TrimPrefix(text, "|-- "))
+		case strings.HasPrefix(text, "/32 host LOCAL") && lastIP != nil:
+			if _, ok := seen[lastIP.String()]; !ok {
+				seen[lastIP.String()] = struct{}{}
+				ips = append(ips, lastIP)
+			}
+		}
+	}
+	sort.Slice(ips, func(i, j int) bool {
+		return bytes.Compare(ips[i].To4(), ips[j].To4()) < 0
+	})
+	return ips, s.Err()
+}
@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestGetCPUFacts(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/proc/cpuinfo": "" +
+			"processor\t: 0\n" +
+			"model name\t: Intel(R) Core(TM) i7-8550U CPU @ 1.80GHz\n" +
+			"physical id\t: 0\n" +
+			"core id\t\t: 0\n" +
+			"\n" +
+			"processor\t: 1\n" +
+			"model name\t: Intel(R) Core(TM) i7-8550U CPU @ 1.80GHz\n" +
+			"physical id\t: 0\n" +
+			"core id\t\t: 1\n" +
+			"\n" +
+			"processor\t: 2\n" +
+			"model name\t: Intel(R) Core(TM) i7-8550U CPU @ 1.80GHz\n" +
+			"physical id\t: 0\n" +
+			"core id\t\t: 0\n",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+	cpuFacts, err := getCPUFacts(fs, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"count": 3,
+		"cores": 2,
+		"model": "Intel(R) Core(TM) i7-8550U CPU @ 1.80GHz",
+	}, cpuFacts)
+}
+
+func TestGetDesktopFacts(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		env      map[string]string
+		expected interface{}
+	}{
+		{
+			name:     "headless",
+			expected: nil,
+		},
+		{
+			name: "gnome_wayland",
+			env: map[string]string{
+				"XDG_CURRENT_DESKTOP": "GNOME",
+				"XDG_SESSION_TYPE":    "wayland",
+			},
+			expected: map[string]interface{}{
+				"desktop": "GNOME",
+				"session": "wayland",
+			},
+		},
+		{
+			name: "display_only",
+			env: map[string]string{
+				"DESKTOP_SESSION": "i3",
+				"DISPLAY":         ":0",
+			},
+			expected: map[string]interface{}{
+				"desktop": "i3",
+				"session": "x11",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := getDesktopFacts(nil, func(key string) string {
+				return tc.env[key]
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestGetMemoryFacts(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/proc/meminfo": "" +
+			"MemTotal:       16310112 kB\n" +
+			"MemFree:         9313180 kB\n",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+	memoryFacts, err := getMemoryFacts(fs, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"total": int64(16310112 * 1024),
+	}, memoryFacts)
+}
+
+func TestGetNetworkFacts(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/proc/net": map[string]interface{}{
+			"fib_trie": "" +
+				"Main:\n" +
+				"  +-- 0.0.0.0/0 3 0 5\n" +
+				"     |-- 0.0.0.0\n" +
+				"        /0 universe UNICAST\n" +
+				"     +-- 127.0.0.0/8 2 0 2\n" +
+				"        |-- 127.0.0.1\n" +
+				"           /32 host LOCAL\n" +
+				"     +-- 192.168.1.0/24 2 0 2\n" +
+				"        |-- 192.168.1.0\n" +
+				"           /24 link UNICAST\n" +
+				"        |-- 192.168.1.23\n" +
+				"           /32 host LOCAL\n" +
+				"     |-- 172.17.0.1\n" +
+				"        /32 host LOCAL\n" +
+				"Local:\n" +
+				"  +-- 0.0.0.0/0 3 0 5\n" +
+				"        |-- 192.168.1.23\n" +
+				"           /32 host LOCAL\n",
+			"route": "" +
+				"Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\t\tMTU\tWindow\tIRTT\n" +
+				"wlan0\t00000000\t0101A8C0\t0003\t0\t0\t600\t00000000\t0\t0\t0\n" +
+				"eth0\t00000000\t0101A8C0\t0003\t0\t0\t100\t00000000\t0\t0\t0\n" +
+				"eth0\t0001A8C0\t00000000\t0001\t0\t0\t100\t00FFFFFF\t0\t0\t0\n" +
+				"docker0\t000011AC\t00000000\t0001\t0\t0\t0\t0000FFFF\t0\t0\t0\n",
+			"if_inet6": "" +
+				"00000000000000000000000000000001 01 80 10 80       lo\n" +
+				"fe800000000000000000000000000001 02 40 20 80     eth0\n" +
+				"20010db8000000000000000000000023 02 40 00 00     eth0\n",
+		},
+		"/sys/class/net": map[string]interface{}{
+			"docker0": map[string]interface{}{
+				"address":   "02:42:00:00:00:01\n",
+				"operstate": "down\n",
+			},
+			"eth0": map[string]interface{}{
+				"address":   "00:11:22:33:44:55\n",
+				"operstate": "up\n",
+			},
+			"lo": map[string]interface{}{
+				"address":   "00:00:00:00:00:00\n",
+				"operstate": "unknown\n",
+			},
+			"wlan0": map[string]interface{}{
+				"address":   "66:77:88:99:aa:bb\n",
+				"operstate": "down\n",
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+	networkFacts, err := getNetworkFacts(fs, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"interfaces": map[string]interface{}{
+			"docker0": map[string]interface{}{
+				"address":   "02:42:00:00:00:01",
+				"ips":       []interface{}{"172.17.0.1"},
+				"operstate": "down",
+			},
+			"eth0": map[string]interface{}{
+				"address":   "00:11:22:33:44:55",
+				"ips":       []interface{}{"192.168.1.23", "fe80::1", "2001:db8::23"},
+				"operstate": "up",
+			},
+			"lo": map[string]interface{}{
+				"address":   "00:00:00:00:00:00",
+				"ips":       []interface{}{"127.0.0.1", "::1"},
+				"operstate": "unknown",
+			},
+			"wlan0": map[string]interface{}{
+				"address":   "66:77:88:99:aa:bb",
+				"ips":       []interface{}{},
+				"operstate": "down",
+			},
+		},
+		"primaryInterface": "eth0",
+		"primaryIPs":       []interface{}{"192.168.1.23", "2001:db8::23"},
+	}, networkFacts)
+}
+
+func TestGetVirtualizationFacts(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		root     interface{}
+		expected interface{}
+	}{
+		{
+			name: "bare_metal",
+			root: map[string]interface{}{
+				"/proc/sys/kernel/osrelease":     "5.8.0-1-amd64\n",
+				"/sys/class/dmi/id/sys_vendor":   "LENOVO\n",
+				"/sys/class/dmi/id/product_name": "20KHCTO1WW\n",
+			},
+			expected: map[string]interface{}{
+				"wsl": false,
+			},
+		},
+		{
+			name: "docker",
+			root: map[string]interface{}{
+				"/.dockerenv": "",
+			},
+			expected: map[string]interface{}{
+				"container": "docker",
+				"wsl":       false,
+			},
+		},
+		{
+			name: "kubernetes",
+			root: map[string]interface{}{
+				"/proc/1/cgroup": "12:memory:/kubepods/besteffort/pod1234\n",
+			},
+			expected: map[string]interface{}{
+				"container": "kubepods",
+				"wsl":       false,
+			},
+		},
+		{
+			name: "wsl",
+			root: map[string]interface{}{
+				"/proc/sys/kernel/osrelease": "4.19.104-microsoft-standard\n",
+			},
+			expected: map[string]interface{}{
+				"wsl": true,
+			},
+		},
+		{
+			name: "qemu",
+			root: map[string]interface{}{
+				"/sys/class/dmi/id/sys_vendor":   "QEMU\n",
+				"/sys/class/dmi/id/product_name": "Standard PC (Q35 + ICH9, 2009)\n",
+			},
+			expected: map[string]interface{}{
+				"vm":  "qemu",
+				"wsl": false,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			actual, err := getVirtualizationFacts(fs, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
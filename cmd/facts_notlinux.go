@@ -0,0 +1,61 @@
+// +build !linux
+
+package cmd
+
+import (
+	"net"
+	"runtime"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+var factCollectors = []factCollector{
+	{name: "cpu", collect: getCPUFacts},
+	{name: "network", collect: getNetworkFacts},
+	{name: "packageManager", collect: getPackageManagerFacts},
+}
+
+// getCPUFacts returns the number of logical CPUs.
+func getCPUFacts(fs vfs.FS, getenv func(string) string) (interface{}, error) {
+	return map[string]interface{}{
+		"count": runtime.NumCPU(),
+	}, nil
+}
+
+// getNetworkFacts returns the network interfaces with their MAC addresses,
+// states, and IP addresses. The primary interface is not available as it
+// requires the routing table.
+func getNetworkFacts(fs vfs.FS, getenv func(string) string) (interface{}, error) {
+	netInterfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	interfaces := make(map[string]interface{})
+	for _, netInterface := range netInterfaces {
+		addrs, err := netInterface.Addrs()
+		if err != nil {
+			return nil, err
+		}
+		ips := []interface{}{}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok {
+				ips = append(ips, ipNet.IP.String())
+			}
+		}
+		operstate := "down"
+		if netInterface.Flags&net.FlagUp != 0 {
+			operstate = "up"
+		}
+		iface := map[string]interface{}{
+			"ips":       ips,
+			"operstate": operstate,
+		}
+		if address := netInterface.HardwareAddr.String(); address != "" {
+			iface["address"] = address
+		}
+		interfaces[netInterface.Name] = iface
+	}
+	return map[string]interface{}{
+		"interfaces": interfaces,
+	}, nil
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	vfs "github.com/twpayne/go-vfs"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestGetFacts(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/usr/bin/dnf": "",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	var logs []string
+	logf := func(format string, args ...interface{}) {
+		logs = append(logs, fmt.Sprintf(format, args...))
+	}
+	facts := getFacts(fs, func(string) string { return "" }, []factCollector{
+		{
+			name: "missing",
+			collect: func(vfs.FS, func(string) string) (interface{}, error) {
+				return nil, os.ErrNotExist
+			},
+		},
+		{
+			name: "nil",
+			collect: func(vfs.FS, func(string) string) (interface{}, error) {
+				return nil, nil
+			},
+		},
+		{
+			name: "error",
+			collect: func(vfs.FS, func(string) string) (interface{}, error) {
+				return nil, errors.New("error")
+			},
+		},
+		{name: "packageManager", collect: getPackageManagerFacts},
+	}, logf)
+	assert.Equal(t, map[string]interface{}{
+		"packageManager": map[string]interface{}{
+			"default": "dnf",
+			"all":     []interface{}{"dnf"},
+		},
+	}, facts)
+	assert.Equal(t, []string{"facts.error: error"}, logs)
+}
+
+func TestGetPackageManagerFacts(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		root     interface{}
+		expected interface{}
+	}{
+		{
+			name: "none",
+			root: map[string]interface{}{
+				"/usr/bin": &vfst.Dir{Perm: 0o755},
+			},
+			expected: nil,
+		},
+		{
+			name: "apt_and_brew",
+			root: map[string]interface{}{
+				"/usr/bin/apt-get":                    "",
+				"/home/linuxbrew/.linuxbrew/bin/brew": "",
+			},
+			expected: map[string]interface{}{
+				"default": "apt",
+				"all":     []interface{}{"apt", "brew"},
+			},
+		},
+		{
+			name: "directory_is_not_executable",
+			root: map[string]interface{}{
+				"/usr/bin/pacman": &vfst.Dir{Perm: 0o755},
+			},
+			expected: nil,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			actual, err := getPackageManagerFacts(fs, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
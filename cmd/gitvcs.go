@@ -4,6 +4,9 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
+
+	vfs "github.com/twpayne/go-vfs"
 
 	"github.com/twpayne/chezmoi/internal/git"
 )
@@ -16,14 +19,29 @@ func (gitVCS) AddArgs(path string) []string {
 	return []string{"add", path}
 }
 
-func (gitVCS) CloneArgs(repo, dir string) []string {
-	return []string{"clone", repo, dir}
+func (gitVCS) CloneArgs(repo, dir string, options vcsCloneOptions) ([]string, error) {
+	args := []string{"clone"}
+	if options.Branch != "" {
+		args = append(args, "--branch", options.Branch)
+	}
+	if options.Depth != 0 {
+		args = append(args, "--depth", strconv.Itoa(options.Depth))
+	}
+	return append(args, repo, dir), nil
 }
 
 func (gitVCS) CommitArgs(message string) []string {
 	return []string{"commit", "--message", message}
 }
 
+func (gitVCS) FetchArgs() []string {
+	return []string{"fetch"}
+}
+
+func (gitVCS) IncomingLogArgs() []string {
+	return []string{"log", "--oneline", "HEAD..@{upstream}"}
+}
+
 func (gitVCS) InitArgs() []string {
 	return []string{"init"}
 }
@@ -40,10 +58,40 @@ func (gitVCS) Initialized(dir string) (bool, error) {
 	}
 }
 
+// MergeArgs returns the arguments to rebase onto the fetched upstream, which
+// is equivalent to the default pull.
+func (gitVCS) MergeArgs() []string {
+	return []string{"rebase", "@{upstream}"}
+}
+
 func (gitVCS) ParseStatusOutput(output []byte) (interface{}, error) {
 	return git.ParseStatusPorcelainV2(output)
 }
 
+func (gitVCS) PostCloneArgs(fs vfs.FS, dir string, options vcsCloneOptions) ([][]string, error) {
+	hasSubmodules, err := gitHasSubmodules(fs, dir)
+	if err != nil || !hasSubmodules {
+		return nil, err
+	}
+	args := []string{"submodule", "update", "--init", "--recursive"}
+	if options.Depth != 0 {
+		args = append(args, "--depth", strconv.Itoa(options.Depth))
+	}
+	return [][]string{args}, nil
+}
+
+func (gitVCS) PostPullArgs(fs vfs.FS, dir string) ([][]string, error) {
+	hasSubmodules, err := gitHasSubmodules(fs, dir)
+	if err != nil || !hasSubmodules {
+		return nil, err
+	}
+	// Sync first in case any submodule URLs changed upstream.
+	return [][]string{
+		{"submodule", "sync", "--recursive"},
+		{"submodule", "update", "--init", "--recursive"},
+	}, nil
+}
+
 func (gitVCS) PullArgs() []string {
 	return []string{"pull", "--rebase"}
 }
@@ -56,6 +104,14 @@ func (gitVCS) StatusArgs() []string {
 	return []string{"status", "--porcelain=v2"}
 }
 
+func (gitVCS) UpstreamCheckoutArgs(dir string) []string {
+	return []string{"worktree", "add", "--detach", dir, "@{upstream}"}
+}
+
+func (gitVCS) UpstreamCheckoutCleanupArgs(dir string) []string {
+	return []string{"worktree", "remove", "--force", dir}
+}
+
 func (gitVCS) VersionArgs() []string {
 	return []string{"version"}
 }
@@ -63,3 +119,15 @@ func (gitVCS) VersionArgs() []string {
 func (gitVCS) VersionRegexp() *regexp.Regexp {
 	return gitVersionRegexp
 }
+
+// gitHasSubmodules returns true if the repo in dir in fs has submodules.
+func gitHasSubmodules(fs vfs.FS, dir string) (bool, error) {
+	switch _, err := fs.Stat(filepath.Join(dir, ".gitmodules")); {
+	case err == nil:
+		return true, nil
+	case os.IsNotExist(err):
+		return false, nil
+	default:
+		return false, err
+	}
+}
@@ -21,6 +21,11 @@ var helps = map[string]help{
 			"  from the `data` section of the config file. Longer substitutions occur\n" +
 			"  before shorter ones. This implies the `--template` option.\n" +
 			"\n" +
+			"  `--create`\n" +
+			"\n" +
+			"  Set the `create` attribute on added files. Files that already have the\n" +
+			"  `create` attribute in the source state keep it.\n" +
+			"\n" +
 			"  `-e`, `--empty`\n" +
 			"\n" +
 			"  Set the `empty` attribute on added files.\n" +
@@ -55,24 +60,77 @@ var helps = map[string]help{
 		long: "" +
 			"Description:\n" +
 			"  Ensure that *targets* are in the target state, updating them if necessary.\n" +
-			"  If no targets are specified, the state of all targets are ensured.",
+			"  If no targets are specified, the state of all targets are ensured.\n" +
+			"\n" +
+			"  After running any `before_` scripts, chezmoi evaluates the contents of up to\n" +
+			"  `concurrency` targets concurrently, and then updates targets in order.\n" +
+			"  Targets that cannot be evaluated are skipped and their errors are reported\n" +
+			"  after all other targets have been updated.\n" +
+			"\n" +
+			"  `-f`, `--format` *format*\n" +
+			"\n" +
+			"  With `--dry-run`, write a plan of the operations that would be performed to\n" +
+			"  stdout in the given format instead of performing them. *format* can be\n" +
+			"  `json`, `toml`, or `yaml`. Each operation has a `type`, one of `chmod`,\n" +
+			"  `mkdir`, `removeAll`, `rename`, `runCmd`, `runScript`, `writeFile`, or\n" +
+			"  `writeSymlink`, and a `name`, and, depending on its type, the fields `dir`,\n" +
+			"  `args`, `newName`, `linkname`, `mode`, `contents` (base64-encoded), and\n" +
+			"  `scriptState` (the state recorded after running a `run_once_` or\n" +
+			"  `run_onchange_` script).\n" +
+			"\n" +
+			"  `--plan` *filename*\n" +
+			"\n" +
+			"  Apply the plan in *filename*, created by `chezmoi plan`. chezmoi performs\n" +
+			"  exactly the operations recorded in the plan, in order, with the recorded\n" +
+			"  contents. The source state is not read, so no templates are executed and no\n" +
+			"  externals are downloaded. chezmoi refuses to apply the plan if any\n" +
+			"  destination path that the plan modifies has changed since the plan was\n" +
+			"  created. Targets cannot be specified with `--plan`.\n" +
+			"\n" +
+			"  `--rollback-on-error`\n" +
+			"\n" +
+			"  Undo changes if any change fails. This is best effort, not a transaction.\n" +
+			"  chezmoi first evaluates every target and performs a dry run of all changes,\n" +
+			"  without running any scripts, and stops if this fails. chezmoi then applies\n" +
+			"  the changes directly to the destination directory, as without this flag. If\n" +
+			"  any change or script fails, then chezmoi undoes the changes it has already\n" +
+			"  made from the backup generation (see `chezmoi rollback`). Other programs may\n" +
+			"  observe the partially applied changes before they are undone, and if undoing\n" +
+			"  a change fails then the destination directory is left partly updated.\n" +
+			"\n" +
+			"  Scripts are not covered. Scripts run in their usual order, so `run_before_`\n" +
+			"  scripts and scripts that sort before a failing change will already have run\n" +
+			"  when the failure occurs. chezmoi cannot undo anything that a script did, and\n" +
+			"  it does not re-run or roll back the persistent state of `run_once_` and\n" +
+			"  `run_onchange_` scripts.",
 		example: "" +
 			"    chezmoi apply\n" +
 			"    chezmoi apply --dry-run --verbose\n" +
+			"    chezmoi apply --dry-run --format=json\n" +
+			"    chezmoi apply --plan plan.json\n" +
+			"    chezmoi apply --rollback-on-error\n" +
 			"    chezmoi apply ~/.bashrc",
 	},
 	"archive": {
 		long: "" +
 			"Description:\n" +
-			"  Generate a tar archive of the target state. This can be piped into `tar` to\n" +
+			"  Generate an archive of the target state. This can be piped into `tar` to\n" +
 			"  inspect the target state.\n" +
 			"\n" +
+			"  `-f`, `--format` *format*\n" +
+			"\n" +
+			"  Write the archive in *format*. Supported formats are `tar`, `tar.gz`,\n" +
+			"  `tar.xz`, `tar.zst`, and `zip`. If `--format` is not given then the format is\n" +
+			"  guessed from the extension of the `--output` filename, defaulting to `tar`.\n" +
+			"\n" +
 			"  `--output`, `-o` *filename*\n" +
 			"\n" +
 			"  Write the output to *filename* instead of stdout.",
 		example: "" +
 			"    chezmoi archive | tar tvf -\n" +
-			"    chezmoi archive --output=dotfiles.tar",
+			"    chezmoi archive --output=dotfiles.tar\n" +
+			"    chezmoi archive --output=dotfiles.zip\n" +
+			"    chezmoi archive --format=tar.zst > dotfiles.tar.zst",
 	},
 	"cat": {
 		long: "" +
@@ -104,6 +162,9 @@ var helps = map[string]help{
 			"\n" +
 			"    ATTRIBUTE  | ABBREVIATION\n" +
 			"  -------------+---------------\n" +
+			"    after      | a\n" +
+			"    before     | b\n" +
+			"    create     | none\n" +
 			"    empty      | e\n" +
 			"    encrypted  | none\n" +
 			"    exact      | none\n" +
@@ -112,11 +173,14 @@ var helps = map[string]help{
 			"    template   | t\n" +
 			"\n" +
 			"  Multiple attributes modifications may be specified by separating them with a\n" +
-			"  comma (`,`).",
+			"  comma (`,`). `after` and `before` only apply to scripts, and adding one\n" +
+			"  removes the other. `create` only applies to regular files and cannot be\n" +
+			"  added to modify scripts.",
 		example: "" +
 			"    chezmoi chattr template ~/.bashrc\n" +
 			"    chezmoi chattr noempty ~/.profile\n" +
-			"    chezmoi chattr private,template ~/.netrc",
+			"    chezmoi chattr private,template ~/.netrc\n" +
+			"    chezmoi chattr create ~/.gitconfig.local",
 	},
 	"completion": {
 		long: "" +
@@ -140,10 +204,18 @@ var helps = map[string]help{
 			"  `-f`, `--format` *format*\n" +
 			"\n" +
 			"  Print the computed template data in the given format. The accepted formats\n" +
-			"  are `json` (JSON), `toml` (TOML), and `yaml` (YAML).",
+			"  are `json` (JSON), `toml` (TOML), and `yaml` (YAML).\n" +
+			"\n" +
+			"  `-s`, `--sources`\n" +
+			"\n" +
+			"  Instead of printing the template data, print where each variable came from.\n" +
+			"  Each variable is identified by its dot-separated path, and its source is\n" +
+			"  either `chezmoi` for automatically populated variables, the config file, or\n" +
+			"  a `.chezmoidata` file in the source state.",
 		example: "" +
 			"    chezmoi data\n" +
-			"    chezmoi data --format=yaml",
+			"    chezmoi data --format=yaml\n" +
+			"    chezmoi data --sources",
 	},
 	"diff": {
 		long: "" +
@@ -318,6 +390,16 @@ var helps = map[string]help{
 		example: "" +
 			"    chezmoi hg -- pull --rebase --update",
 	},
+	"ignored": {
+		long: "" +
+			"Description:\n" +
+			"  Print whether each of *targets* is ignored and, if a pattern matches it, the\n" +
+			"  `.chezmoiignore` file, line number, and pattern that decided. If no targets\n" +
+			"  are specified, print all ignored targets in the source state.",
+		example: "" +
+			"    chezmoi ignored\n" +
+			"    chezmoi ignored ~/.config/nvim/plugin/packer_compiled.lua",
+	},
 	"import": {
 		long: "" +
 			"Description:\n" +
@@ -327,7 +409,10 @@ var helps = map[string]help{
 			"  always want to set the `--destination`, `--exact`, and `--remove-destination`\n" +
 			"  flags.\n" +
 			"\n" +
-			"  The only supported archive format is `.tar.gz`.\n" +
+			"  Supported archive formats are `tar`, `tar.bz2`, `tar.gz`, `tar.xz`,\n" +
+			"  `tar.zst`, and `zip`. The format is detected from the contents of the\n" +
+			"  archive, falling back to the extension of *filename*. If *filename* is not\n" +
+			"  given then the archive is read from stdin.\n" +
 			"\n" +
 			"  `--destination` *directory*\n" +
 			"\n" +
@@ -338,6 +423,10 @@ var helps = map[string]help{
 			"\n" +
 			"  Set the `exact` attribute on all imported directories.\n" +
 			"\n" +
+			"  `-f`, `--format` *format*\n" +
+			"\n" +
+			"  Set the archive format, overriding detection.\n" +
+			"\n" +
 			"  `-r`, `--remove-destination`\n" +
 			"\n" +
 			"  Remove destination (in the source state) before importing.\n" +
@@ -349,7 +438,9 @@ var helps = map[string]help{
 			"    curl -s -L -o oh-my-zsh-master.tar.gz https://github.com/robbyrussell/oh-my-\n" +
 			"  zsh/archive/master.tar.gz\n" +
 			"    chezmoi import --strip-components 1 --destination ~/.oh-my-zsh oh-my-zsh-\n" +
-			"  master.tar.gz",
+			"  master.tar.gz\n" +
+			"    curl -s -L https://github.com/robbyrussell/oh-my-zsh/archive/master.zip |\n" +
+			"  chezmoi import --strip-components 1 --destination ~/.oh-my-zsh",
 	},
 	"init": {
 		long: "" +
@@ -359,7 +450,8 @@ var helps = map[string]help{
 			"\n" +
 			"  First, if the source directory is not already contain a repository, then if\n" +
 			"  *repo* is given it is checked out into the source directory, otherwise a new\n" +
-			"  repository is initialized in the source directory.\n" +
+			"  repository is initialized in the source directory. Any git submodules or\n" +
+			"  Mercurial subrepos are also checked out.\n" +
 			"\n" +
 			"  Second, if a file called `.chezmoi.format.tmpl` exists, where `format` is\n" +
 			"  one of the supported file formats (e.g. `json`, `toml`, or `yaml`) then a\n" +
@@ -370,10 +462,20 @@ var helps = map[string]help{
 			"  `--apply`\n" +
 			"\n" +
 			"  Run `chezmoi apply` after checking out the repo and creating the config\n" +
-			"  file. This is `false` by default.",
+			"  file. This is `false` by default.\n" +
+			"\n" +
+			"  `--branch` *branch*\n" +
+			"\n" +
+			"  Check out *branch* instead of the repo's default branch.\n" +
+			"\n" +
+			"  `--depth` *depth*\n" +
+			"\n" +
+			"  Clone the repo with a history truncated to the specified number of commits.\n" +
+			"  This is not supported by Mercurial.",
 		example: "" +
 			"    chezmoi init https://github.com/user/dotfiles.git\n" +
-			"    chezmoi init https://github.com/user/dotfiles.git --apply",
+			"    chezmoi init https://github.com/user/dotfiles.git --apply\n" +
+			"    chezmoi init https://github.com/user/dotfiles.git --branch main --depth 1",
 	},
 	"manage": {
 		long: "" +
@@ -411,11 +513,30 @@ var helps = map[string]help{
 		example: "" +
 			"    chezmoi merge ~/.bashrc",
 	},
+	"plan": {
+		long: "" +
+			"Description:\n" +
+			"  Write a plan of the operations that `chezmoi apply` would perform on\n" +
+			"  *targets*, or on all targets if none are specified, in JSON format. The plan\n" +
+			"  also records a hash of the current state of every destination path that it\n" +
+			"  modifies. The plan can later be applied with `chezmoi apply --plan`.\n" +
+			"\n" +
+			"  The plan includes the contents of target files and scripts, which may\n" +
+			"  include decrypted secrets, so plan files are created readable only by the\n" +
+			"  current user.\n" +
+			"\n" +
+			"  `-o`, `--output` *filename*\n" +
+			"\n" +
+			"  Write the plan to *filename* instead of stdout.",
+		example: "" +
+			"    chezmoi plan -o plan.json\n" +
+			"    chezmoi plan ~/.bashrc",
+	},
 	"purge": {
 		long: "" +
 			"Description:\n" +
-			"  Remove chezmoi's configuration, state, and source directory, but leave the\n" +
-			"  target state intact.\n" +
+			"  Remove chezmoi's configuration, state, cache, and source directory, but\n" +
+			"  leave the target state intact.\n" +
 			"\n" +
 			"  `-f`, `--force`\n" +
 			"\n" +
@@ -438,6 +559,32 @@ var helps = map[string]help{
 			"Description:\n" +
 			"  `rm` is an alias for `remove`.",
 	},
+	"rollback": {
+		long: "" +
+			"Description:\n" +
+			"  Restore destination files, directories, and symlinks from backup generation\n" +
+			"  *generation*, or from the latest generation if *generation* is not\n" +
+			"  specified.\n" +
+			"\n" +
+			"  Every time that chezmoi changes the destination directory, for example with\n" +
+			"  `chezmoi apply`, it first backs up the previous contents, permissions, and\n" +
+			"  symlink targets of every path that it changes into a new generation in\n" +
+			"  `$XDG_STATE_HOME/chezmoi/backups` (`~/.local/state/chezmoi/backups` by\n" +
+			"  default). Paths that did not exist are removed on rollback. A rollback is\n" +
+			"  itself backed up as a new generation, so it can be undone with another\n" +
+			"  rollback. Only the newest `backups.keep` generations (10 by default) are\n" +
+			"  kept, older generations are removed after each change. Set `backups.keep` to\n" +
+			"  0 to keep all generations.\n" +
+			"\n" +
+			"  `-l`, `--list`\n" +
+			"\n" +
+			"  List the available generations with the time that they were created and the\n" +
+			"  number of paths that they contain.",
+		example: "" +
+			"    chezmoi rollback --list\n" +
+			"    chezmoi rollback\n" +
+			"    chezmoi rollback 3",
+	},
 	"secret": {
 		long: "" +
 			"Description:\n" +
@@ -484,6 +631,38 @@ var helps = map[string]help{
 			"    chezmoi source-path\n" +
 			"    chezmoi source-path ~/.bashrc",
 	},
+	"state": {
+		long: "" +
+			"Description:\n" +
+			"  Manipulate the persistent state. chezmoi stores the state of `run_onchange_`\n" +
+			"  scripts in the `script` bucket keyed by the script's target name, and the\n" +
+			"  state of `run_once_` scripts keyed by the script's target name, a colon, and\n" +
+			"  the SHA256 of the script's contents.\n" +
+			"\n" +
+			"  `state dump`\n" +
+			"\n" +
+			"  Write a dump of the persistent state to stdout in the format given by `--\n" +
+			"  format`/`-f` (`json`, `toml`, or `yaml`, default `json`).\n" +
+			"\n" +
+			"  `state get --bucket` *bucket* `--key` *key*\n" +
+			"\n" +
+			"  Print the value associated with *key* in *bucket*.\n" +
+			"\n" +
+			"  `state delete --bucket` *bucket* `--key` *key*\n" +
+			"\n" +
+			"  Delete the value associated with *key* in *bucket*. This can be used to make\n" +
+			"  chezmoi re-run a single `run_once_` or `run_onchange_` script.\n" +
+			"\n" +
+			"  `state reset` [`--bucket` *bucket*]\n" +
+			"\n" +
+			"  Delete all values in *bucket*, or in all buckets if `--bucket` is not given.\n" +
+			"  chezmoi prompts before resetting each bucket unless `--force`/`-f` is given.",
+		example: "" +
+			"    chezmoi state dump\n" +
+			"    chezmoi state get --bucket script --key install.sh\n" +
+			"    chezmoi state delete --bucket script --key install.sh\n" +
+			"    chezmoi state reset --bucket script",
+	},
 	"unmanage": {
 		long: "" +
 			"Description:\n" +
@@ -499,9 +678,33 @@ var helps = map[string]help{
 	"update": {
 		long: "" +
 			"Description:\n" +
-			"  Pull changes from the source VCS and apply any changes.",
+			"  Pull changes from the source VCS and apply any changes. Any git submodules\n" +
+			"  or Mercurial subrepos are updated to match.\n" +
+			"\n" +
+			"  `-a`, `--apply`\n" +
+			"\n" +
+			"  Apply changes after pulling. This is `true` by default.\n" +
+			"\n" +
+			"  `--fail-if-changes`\n" +
+			"\n" +
+			"  Fetch changes from the source VCS without pulling them, print the incoming\n" +
+			"  commits and the diff that applying them would make, and exit with a non-zero\n" +
+			"  status if there are any. Nothing is pulled or applied. This is useful for\n" +
+			"  checking for drift from cron jobs.\n" +
+			"\n" +
+			"  `--interactive`\n" +
+			"\n" +
+			"  Fetch changes from the source VCS without pulling them, print the incoming\n" +
+			"  commits and the diff that applying them would make, and then prompt before\n" +
+			"  pulling and applying them.\n" +
+			"\n" +
+			"  The diff is computed without running anything from the incoming changes:\n" +
+			"  scripts are not run, files with `modify_` scripts are shown as unchanged,\n" +
+			"  and only externals that are already cached are included.",
 		example: "" +
-			"    chezmoi update",
+			"    chezmoi update\n" +
+			"    chezmoi update --interactive\n" +
+			"    chezmoi update --fail-if-changes",
 	},
 	"upgrade": {
 		long: "" +
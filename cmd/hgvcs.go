@@ -1,25 +1,52 @@
 package cmd
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"regexp"
+
+	vfs "github.com/twpayne/go-vfs"
+
+	"github.com/twpayne/chezmoi/internal/hg"
 )
 
 var hgVersionRegexp = regexp.MustCompile(`^Mercurial Distributed SCM \(version (\d+\.\d+(\.\d+)?\))`)
 
+// hgUpstreamRevset is the revision that hg update would update to.
+const hgUpstreamRevset = "max(branch(.))"
+
 type hgVCS struct{}
 
+// AddArgs returns the arguments to add all new files and remove all missing
+// files in path, which is equivalent to git add.
 func (hgVCS) AddArgs(path string) []string {
-	return nil
+	return []string{"addremove", path}
 }
 
-func (hgVCS) CloneArgs(repo, dir string) []string {
-	return []string{"clone", repo, dir}
+func (hgVCS) CloneArgs(repo, dir string, options vcsCloneOptions) ([]string, error) {
+	if options.Depth != 0 {
+		return nil, errors.New("hg: shallow clones not supported")
+	}
+	args := []string{"clone"}
+	if options.Branch != "" {
+		args = append(args, "--branch", options.Branch)
+	}
+	return append(args, repo, dir), nil
 }
 
 func (hgVCS) CommitArgs(message string) []string {
-	return nil
+	return []string{"commit", "--message", message}
+}
+
+// FetchArgs returns the arguments to pull without updating the working
+// directory.
+func (hgVCS) FetchArgs() []string {
+	return []string{"pull"}
+}
+
+func (hgVCS) IncomingLogArgs() []string {
+	return []string{"log", "--template", "{node|short} {desc|firstline}\n", "--rev", "only(" + hgUpstreamRevset + ", .)"}
 }
 
 func (hgVCS) InitArgs() []string {
@@ -38,19 +65,46 @@ func (hgVCS) Initialized(dir string) (bool, error) {
 	}
 }
 
+func (hgVCS) MergeArgs() []string {
+	return []string{"update"}
+}
+
 func (hgVCS) ParseStatusOutput(output []byte) (interface{}, error) {
+	return hg.ParseStatus(output)
+}
+
+// PostCloneArgs returns nil because hg clone already clones subrepos.
+func (hgVCS) PostCloneArgs(fs vfs.FS, dir string, options vcsCloneOptions) ([][]string, error) {
 	return nil, nil
 }
 
+// PostPullArgs updates the working directory, which also updates any subrepos
+// to the revisions recorded in .hgsubstate.
+func (hgVCS) PostPullArgs(fs vfs.FS, dir string) ([][]string, error) {
+	return [][]string{
+		{"update"},
+	}, nil
+}
+
 func (hgVCS) PullArgs() []string {
-	return []string{"pull", "--update"}
+	return []string{"pull"}
 }
 
 func (hgVCS) PushArgs() []string {
-	return nil
+	return []string{"push"}
 }
 
 func (hgVCS) StatusArgs() []string {
+	return []string{"status", "--copies"}
+}
+
+func (hgVCS) UpstreamCheckoutArgs(dir string) []string {
+	return []string{"archive", "--rev", hgUpstreamRevset, "--subrepos", dir}
+}
+
+// UpstreamCheckoutCleanupArgs returns nil because hg archive does not record
+// anything in the repo.
+func (hgVCS) UpstreamCheckoutCleanupArgs(dir string) []string {
 	return nil
 }
 
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+	vfs "github.com/twpayne/go-vfs"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var ignoredCmd = &cobra.Command{
+	Use:     "ignored [targets...]",
+	Short:   "Explain why targets are ignored",
+	Long:    mustGetLongHelp("ignored"),
+	Example: getExample("ignored"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runIgnoredCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(ignoredCmd)
+
+	markRemainingZshCompPositionalArgumentsAsFiles(ignoredCmd, 1)
+}
+
+func (c *Config) runIgnoredCmd(cmd *cobra.Command, args []string) error {
+	ts, err := c.getTargetState(nil)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		patternMatches := make(map[string]*chezmoi.PatternMatch)
+		for _, entry := range ts.AllEntries() {
+			_, isDir := entry.(*chezmoi.Dir)
+			patternMatch := ts.TargetIgnore.Explain(entry.TargetName(), isDir)
+			// Only list the topmost ignored targets.
+			if patternMatch == nil || !patternMatch.Pattern.Include || patternMatch.Name != entry.TargetName() {
+				continue
+			}
+			patternMatches[entry.TargetName()] = patternMatch
+		}
+		targetNames := make([]string, 0, len(patternMatches))
+		for targetName := range patternMatches {
+			targetNames = append(targetNames, targetName)
+		}
+		sort.Strings(targetNames)
+		for _, targetName := range targetNames {
+			if err := c.printPatternMatch(filepath.Join(ts.DestDir, targetName), targetName, patternMatches[targetName]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, arg := range args {
+		targetPath, err := filepath.Abs(arg)
+		if err != nil {
+			return err
+		}
+		contains, err := vfs.Contains(c.fs, targetPath, ts.DestDir)
+		if err != nil {
+			return err
+		}
+		if !contains {
+			return fmt.Errorf("%s: outside target directory", arg)
+		}
+		targetName, err := filepath.Rel(ts.DestDir, targetPath)
+		if err != nil {
+			return err
+		}
+		var info os.FileInfo
+		if c.Follow {
+			info, err = c.fs.Stat(targetPath)
+		} else {
+			info, err = c.fs.Lstat(targetPath)
+		}
+		var isDir bool
+		switch {
+		case err == nil:
+			isDir = info.IsDir()
+		case os.IsNotExist(err):
+			entry, _ := ts.Get(c.fs, targetPath)
+			_, isDir = entry.(*chezmoi.Dir)
+		default:
+			return err
+		}
+		if err := c.printPatternMatch(targetPath, targetName, ts.TargetIgnore.Explain(targetName, isDir)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Config) printPatternMatch(targetPath, targetName string, patternMatch *chezmoi.PatternMatch) error {
+	if patternMatch == nil {
+		_, err := fmt.Fprintf(c.Stdout, "%s: not ignored\n", targetPath)
+		return err
+	}
+	verb := "not ignored"
+	if patternMatch.Pattern.Include {
+		verb = "ignored"
+	}
+	p := patternMatch.Pattern
+	var err error
+	if patternMatch.Name != targetName {
+		_, err = fmt.Fprintf(c.Stdout, "%s: %s by %s:%d: %s (matches %s)\n", targetPath, verb, p.SourceName, p.LineNumber, p, patternMatch.Name)
+	} else {
+		_, err = fmt.Fprintf(c.Stdout, "%s: %s by %s:%d: %s\n", targetPath, verb, p.SourceName, p.LineNumber, p)
+	}
+	return err
+}
@@ -0,0 +1,67 @@
+// +build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestIgnoredCmd(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".bashrc": "# contents of .bashrc\n",
+			".local/share/chezmoi": map[string]interface{}{
+				".chezmoiignore": "" +
+					".config/*\n" +
+					"!.config/nvim\n" +
+					".config/nvim/cache/\n",
+				"dot_bashrc":                      "# contents of .bashrc\n",
+				"dot_config/fish/config.fish":     "# contents of .config/fish/config.fish\n",
+				"dot_config/nvim/init.vim":        "\" contents of .config/nvim/init.vim\n",
+				"dot_config/nvim/cache/file":      "# contents of .config/nvim/cache/file\n",
+				"dot_config/nvim/cache/dir/file2": "# contents of .config/nvim/cache/dir/file2\n",
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	for _, tc := range []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "all",
+			want: "" +
+				"/home/user/.config/fish: ignored by .chezmoiignore:1: .config/*\n" +
+				"/home/user/.config/nvim/cache: ignored by .chezmoiignore:3: .config/nvim/cache/\n",
+		},
+		{
+			name: "targets",
+			args: []string{
+				"/home/user/.bashrc",
+				"/home/user/.config/fish/config.fish",
+				"/home/user/.config/nvim/init.vim",
+				"/home/user/.config/nvim",
+			},
+			want: "" +
+				"/home/user/.bashrc: not ignored\n" +
+				"/home/user/.config/fish/config.fish: ignored by .chezmoiignore:1: .config/* (matches .config/fish)\n" +
+				"/home/user/.config/nvim/init.vim: not ignored\n" +
+				"/home/user/.config/nvim: not ignored by .chezmoiignore:2: !.config/nvim\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			c := newTestConfig(fs, withStdout(stdout))
+			require.NoError(t, c.runIgnoredCmd(nil, tc.args))
+			assert.Equal(t, tc.want, stdout.String())
+		})
+	}
+}
@@ -1,9 +1,7 @@
 package cmd
 
 import (
-	"archive/tar"
-	"compress/bzip2"
-	"compress/gzip"
+	"bufio"
 	"fmt"
 	"io"
 	"os"
@@ -18,7 +16,7 @@ import (
 var _importCmd = &cobra.Command{
 	Use:     "import [filename]",
 	Args:    cobra.MaximumNArgs(1),
-	Short:   "Import a tar archive into the source state",
+	Short:   "Import an archive into the source state",
 	Long:    mustGetLongHelp("import"),
 	Example: getExample("import"),
 	PreRunE: config.ensureNoError,
@@ -26,20 +24,22 @@ var _importCmd = &cobra.Command{
 }
 
 type importCmdConfig struct {
-	removeDestination bool
-	importTAROptions  chezmoi.ImportTAROptions
+	format               string
+	removeDestination    bool
+	importArchiveOptions chezmoi.ImportArchiveOptions
 }
 
 func init() {
 	rootCmd.AddCommand(_importCmd)
 
 	persistentFlags := _importCmd.PersistentFlags()
-	persistentFlags.StringVarP(&config._import.importTAROptions.DestinationDir, "destination", "d", "", "destination prefix")
-	persistentFlags.BoolVarP(&config._import.importTAROptions.Exact, "exact", "x", false, "import directories exactly")
-	persistentFlags.IntVar(&config._import.importTAROptions.StripComponents, "strip-components", 0, "strip components")
+	persistentFlags.StringVarP(&config._import.importArchiveOptions.DestinationDir, "destination", "d", "", "destination prefix")
+	persistentFlags.BoolVarP(&config._import.importArchiveOptions.Exact, "exact", "x", false, "import directories exactly")
+	persistentFlags.StringVarP(&config._import.format, "format", "f", "", "format ("+strings.Join(chezmoi.ArchiveFormats, ", ")+")")
+	persistentFlags.IntVar(&config._import.importArchiveOptions.StripComponents, "strip-components", 0, "strip components")
 	persistentFlags.BoolVarP(&config._import.removeDestination, "remove-destination", "r", false, "remove destination before import")
 
-	panicOnError(_importCmd.MarkZshCompPositionalArgumentFile(1, "*.tar", "*.tar.bz2", "*.tar.gz", "*.tgz"))
+	panicOnError(_importCmd.MarkZshCompPositionalArgumentFile(1, "*.tar", "*.tar.bz2", "*.tar.gz", "*.tar.xz", "*.tar.zst", "*.tbz2", "*.tgz", "*.txz", "*.zip"))
 }
 
 func (c *Config) runImportCmd(cmd *cobra.Command, args []string) error {
@@ -48,33 +48,41 @@ func (c *Config) runImportCmd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	var r io.Reader
+	name := "stdin"
 	if len(args) == 0 {
 		r = c.Stdin
 	} else {
-		arg := args[0]
-		f, err := c.fs.Open(arg)
+		name = args[0]
+		f, err := c.fs.Open(name)
 		if err != nil {
 			return err
 		}
 		defer f.Close()
-		switch {
-		case strings.HasSuffix(arg, ".tar.gz") || strings.HasSuffix(arg, ".tgz"):
-			r, err = gzip.NewReader(f)
-			if err != nil {
-				return err
-			}
-		case strings.HasSuffix(arg, ".tar.bz2"):
-			r = bzip2.NewReader(f)
-		case strings.HasSuffix(arg, ".tar"):
-			r = f
-		default:
-			return fmt.Errorf("%s: unknown format", arg)
+		r = f
+	}
+	// Read the archive through a buffer so that its format can be detected
+	// without reading it into memory.
+	br := bufio.NewReader(r)
+	format := c._import.format
+	if format == "" {
+		format, err = chezmoi.PeekArchiveFormat(br)
+		if err != nil {
+			return err
 		}
 	}
+	if format == "" && len(args) != 0 {
+		format = chezmoi.GuessArchiveFormat(name)
+	}
+	if format == "" {
+		return fmt.Errorf("%s: unknown format", name)
+	}
 	if c._import.removeDestination {
-		entry, err := ts.Get(c.fs, c._import.importTAROptions.DestinationDir)
+		entry, err := ts.Get(c.fs, c._import.importArchiveOptions.DestinationDir)
 		switch {
 		case err == nil:
+			if err := chezmoi.CheckSourceFile(entry); err != nil {
+				return err
+			}
 			if err := c.mutator.RemoveAll(filepath.Join(c.SourceDir, entry.SourceName())); err != nil {
 				return err
 			}
@@ -83,5 +91,5 @@ func (c *Config) runImportCmd(cmd *cobra.Command, args []string) error {
 			return err
 		}
 	}
-	return ts.ImportTAR(tar.NewReader(r), c._import.importTAROptions, c.mutator)
+	return ts.ImportArchive(br, format, c._import.importArchiveOptions, c.mutator)
 }
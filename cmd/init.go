@@ -28,7 +28,9 @@ var initCmd = &cobra.Command{
 }
 
 type initCmdConfig struct {
-	apply bool
+	apply  bool
+	branch string
+	depth  int
 }
 
 func init() {
@@ -36,6 +38,8 @@ func init() {
 
 	persistentFlags := initCmd.PersistentFlags()
 	persistentFlags.BoolVar(&config.init.apply, "apply", false, "update destination directory")
+	persistentFlags.StringVar(&config.init.branch, "branch", "", "check out branch instead of the default")
+	persistentFlags.IntVar(&config.init.depth, "depth", 0, "create a shallow clone with the given number of commits")
 }
 
 func (c *Config) runInitCmd(cmd *cobra.Command, args []string) error {
@@ -48,11 +52,6 @@ func (c *Config) runInitCmd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	rawSourceDir, err := c.fs.RawPath(c.SourceDir)
-	if err != nil {
-		return err
-	}
-
 	initialized, err := vcs.Initialized(c.SourceDir)
 	if err != nil {
 		return err
@@ -60,43 +59,16 @@ func (c *Config) runInitCmd(cmd *cobra.Command, args []string) error {
 	if !initialized {
 		switch len(args) {
 		case 0: // init
-			var initArgs []string
-			if c.SourceVCS.Init != nil {
-				switch v := c.SourceVCS.Init.(type) {
-				case string:
-					initArgs = strings.Split(v, " ")
-				case []string:
-					initArgs = v
-				default:
-					return fmt.Errorf("sourceVCS.init: cannot parse value")
-				}
-			} else {
-				initArgs = vcs.InitArgs()
-			}
-			if err := c.run(c.SourceDir, c.SourceVCS.Command, initArgs...); err != nil {
+			if err := vcs.Init(c.SourceDir); err != nil {
 				return err
 			}
 		case 1: // clone
-			cloneArgs := vcs.CloneArgs(args[0], rawSourceDir)
-			if cloneArgs == nil {
-				return fmt.Errorf("%s: cloning not supported", c.SourceVCS.Command)
-			}
-			if err := c.run("", c.SourceVCS.Command, cloneArgs...); err != nil {
+			if err := vcs.Clone(args[0], c.SourceDir, vcsCloneOptions{
+				Branch: c.init.branch,
+				Depth:  c.init.depth,
+			}); err != nil {
 				return err
 			}
-			// FIXME this should be part of VCS
-			if filepath.Base(c.SourceVCS.Command) == "git" {
-				if _, err := c.fs.Stat(filepath.Join(c.SourceDir, ".gitmodules")); err == nil {
-					for _, args := range [][]string{
-						{"submodule", "init"},
-						{"submodule", "update"},
-					} {
-						if err := c.run(c.SourceDir, c.SourceVCS.Command, args...); err != nil {
-							return err
-						}
-					}
-				}
-			}
 		}
 	}
 
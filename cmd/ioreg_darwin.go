@@ -3,11 +3,13 @@ package cmd
 import (
 	"fmt"
 	"os/exec"
+	"sync"
 
 	"howett.net/plist"
 )
 
 type ioregData struct {
+	sync.Mutex
 	value map[string]interface{}
 }
 
@@ -16,6 +18,8 @@ func init() {
 }
 
 func (c *Config) ioregFunc() map[string]interface{} {
+	c.ioregData.Lock()
+	defer c.ioregData.Unlock()
 	if c.ioregData.value != nil {
 		return c.ioregData.value
 	}
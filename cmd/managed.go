@@ -59,7 +59,8 @@ func (c *Config) runManagedCmd(cmd *cobra.Command, args []string) error {
 
 	targetNames := make([]string, 0, len(allEntries))
 	for _, entry := range allEntries {
-		if _, ok := entry.(*chezmoi.Dir); ok && !includeDirs {
+		_, isDir := entry.(*chezmoi.Dir)
+		if isDir && !includeDirs {
 			continue
 		}
 		if _, ok := entry.(*chezmoi.File); ok && !includeFiles {
@@ -68,14 +69,14 @@ func (c *Config) runManagedCmd(cmd *cobra.Command, args []string) error {
 		if _, ok := entry.(*chezmoi.Symlink); ok && !includeSymlinks {
 			continue
 		}
+		if ts.TargetIgnore.Match(entry.TargetName(), isDir) {
+			continue
+		}
 		targetNames = append(targetNames, entry.TargetName())
 	}
 
 	sort.Strings(targetNames)
 	for _, targetName := range targetNames {
-		if ts.TargetIgnore.Match(targetName) {
-			continue
-		}
 		fmt.Fprintln(c.Stdout, filepath.Join(ts.DestDir, targetName))
 	}
 
@@ -117,3 +117,69 @@ func withManaged(managed managedCmdConfig) configOption {
 		c.managed = managed
 	}
 }
+
+func TestManagedCmdNestedIgnore(t *testing.T) {
+	fs, cleanup, err := newNestedIgnoreTestFS()
+	require.NoError(t, err)
+	defer cleanup()
+	stdout := &bytes.Buffer{}
+	c := newTestConfig(
+		fs,
+		withStdout(stdout),
+		withManaged(managedCmdConfig{
+			include: []string{"dirs", "files", "symlinks"},
+		}),
+	)
+	require.NoError(t, c.runManagedCmd(nil, nil))
+	posixTargetNames, err := extractPOSIXTargetNames(stdout.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"/home/user/.bashrc",
+		"/home/user/.config",
+		"/home/user/.config/keep.log",
+		"/home/user/.config/nvim",
+		"/home/user/.config/nvim/init.vim",
+		"/home/user/.ssh",
+		"/home/user/.ssh/known_hosts",
+		"/home/user/config",
+		"/home/user/fish",
+		"/home/user/fish/file",
+	}, posixTargetNames)
+}
+
+// newNestedIgnoreTestFS returns a new test filesystem with a source state that
+// contains .chezmoiignore files in subdirectories.
+func newNestedIgnoreTestFS() (*vfst.TestFS, func(), error) {
+	return vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".config/fish/config.fish": "# contents of .config/fish/config.fish\n",
+			".config/other":            "# contents of .config/other\n",
+			".ssh": &vfst.Dir{
+				Perm: 0o700,
+				Entries: map[string]interface{}{
+					"config": "# contents of .ssh/config\n",
+					"id_rsa": "# contents of .ssh/id_rsa\n",
+				},
+			},
+			"debug.log": "# contents of debug.log\n",
+			".local/share/chezmoi": map[string]interface{}{
+				".chezmoiignore": "*.log\n",
+				"config":         "# contents of config\n",
+				"dot_bashrc":     "# contents of .bashrc\n",
+				"dot_config": map[string]interface{}{
+					".chezmoiignore":   "fish/\n!keep.log\n",
+					"debug.log":        "# contents of .config/debug.log\n",
+					"fish/config.fish": "# contents of .config/fish/config.fish\n",
+					"keep.log":         "# contents of .config/keep.log\n",
+					"nvim/init.vim":    "\" contents of .config/nvim/init.vim\n",
+				},
+				"fish/file": "# contents of fish/file\n",
+				"private_dot_ssh": map[string]interface{}{
+					".chezmoiignore": "/config\n",
+					"config":         "# contents of .ssh/config\n",
+					"known_hosts":    "# contents of .ssh/known_hosts\n",
+				},
+			},
+		},
+	})
+}
@@ -38,7 +38,7 @@ func (c *Config) runMergeCmd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	entries, err := c.getEntries(ts, args)
+	entries, err := c.getSourceEntries(ts, args)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var planCmd = &cobra.Command{
+	Use:     "plan [targets...]",
+	Short:   "Write a plan of the changes that apply would make",
+	Long:    mustGetLongHelp("plan"),
+	Example: getExample("plan"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runPlanCmd,
+}
+
+type planCmdConfig struct {
+	output string
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+
+	persistentFlags := planCmd.PersistentFlags()
+	persistentFlags.StringVarP(&config.plan.output, "output", "o", "", "output filename")
+
+	markRemainingZshCompPositionalArgumentsAsFiles(planCmd, 1)
+}
+
+func (c *Config) runPlanCmd(cmd *cobra.Command, args []string) error {
+	persistentState, err := c.getPersistentState(&bolt.Options{
+		ReadOnly: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer persistentState.Close()
+
+	// Record absolute target paths so that the plan can be applied from any
+	// working directory.
+	targets := make([]string, 0, len(args))
+	for _, arg := range args {
+		target, err := filepath.Abs(arg)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, target)
+	}
+
+	plan, err := c.computePlan(targets, persistentState)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if c.plan.output == "" {
+		_, err = c.Stdout.Write(data)
+		return err
+	}
+	// The plan contains the contents of the target files, which may include
+	// secrets, so it is only readable by the user.
+	return c.fs.WriteFile(c.plan.output, data, 0o600)
+}
+
+// computePlan returns the plan of operations that applying targets would
+// perform, including the hashes of the destination paths that they modify.
+func (c *Config) computePlan(targets []string, persistentState chezmoi.PersistentState) (*chezmoi.Plan, error) {
+	mutator, dryRun, runModifyScripts := c.mutator, c.DryRun, c.RunModifyScripts
+	defer func() {
+		c.mutator, c.DryRun, c.RunModifyScripts = mutator, dryRun, runModifyScripts
+	}()
+
+	planMutator := chezmoi.NewPlanMutator(chezmoi.NullMutator{})
+	c.mutator = planMutator
+	c.DryRun = true
+	// Plans record the output of modify scripts, so that applying the plan
+	// writes it.
+	c.RunModifyScripts = true
+	if err := c.applyArgs(targets, persistentState); err != nil {
+		return nil, err
+	}
+
+	plan := planMutator.Plan()
+	plan.Targets = targets
+	plan.DestHashes = make(map[string]string)
+	for _, destPath := range plan.DestPaths() {
+		destHash, err := chezmoi.DestHash(c.fs, destPath)
+		if err != nil {
+			return nil, err
+		}
+		plan.DestHashes[destPath] = destHash
+	}
+	return plan, nil
+}
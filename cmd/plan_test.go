@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestPlanCmd(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		mutate    func(*testing.T, *vfst.TestFS)
+		wantErr   bool
+		wantTests []vfst.Test
+	}{
+		{
+			name: "unchanged",
+			wantTests: []vfst.Test{
+				vfst.TestPath("/home/user/.bashrc",
+					vfst.TestContentsString("# contents of .bashrc\n"),
+				),
+				vfst.TestPath("/home/user/dir/file",
+					vfst.TestContentsString("# contents of dir/file\n"),
+				),
+			},
+		},
+		{
+			name: "dest_changed",
+			mutate: func(t *testing.T, fs *vfst.TestFS) {
+				require.NoError(t, fs.WriteFile("/home/user/.bashrc", []byte("# edited contents of .bashrc\n"), 0o644))
+			},
+			wantErr: true,
+			wantTests: []vfst.Test{
+				vfst.TestPath("/home/user/.bashrc",
+					vfst.TestContentsString("# edited contents of .bashrc\n"),
+				),
+				vfst.TestPath("/home/user/dir",
+					vfst.TestDoesNotExist,
+				),
+			},
+		},
+		{
+			name: "dest_created",
+			mutate: func(t *testing.T, fs *vfst.TestFS) {
+				require.NoError(t, fs.Mkdir("/home/user/dir", 0o755))
+			},
+			wantErr: true,
+			wantTests: []vfst.Test{
+				vfst.TestPath("/home/user/.bashrc",
+					vfst.TestContentsString("# old contents of .bashrc\n"),
+				),
+			},
+		},
+		{
+			name: "source_changed",
+			mutate: func(t *testing.T, fs *vfst.TestFS) {
+				require.NoError(t, fs.WriteFile("/home/user/.local/share/chezmoi/dot_bashrc", []byte("# new contents of .bashrc\n"), 0o644))
+			},
+			wantTests: []vfst.Test{
+				vfst.TestPath("/home/user/.bashrc",
+					vfst.TestContentsString("# contents of .bashrc\n"),
+				),
+				vfst.TestPath("/home/user/dir/file",
+					vfst.TestContentsString("# contents of dir/file\n"),
+				),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".bashrc": "# old contents of .bashrc\n",
+					".local/share/chezmoi": map[string]interface{}{
+						"dot_bashrc": "# contents of .bashrc\n",
+						"dir/file":   "# contents of dir/file\n",
+					},
+				},
+			})
+			require.NoError(t, err)
+			defer cleanup()
+
+			c := newTestConfig(
+				fs,
+				withPlanCmdConfig(planCmdConfig{
+					output: "/home/user/plan.json",
+				}),
+			)
+			require.NoError(t, c.runPlanCmd(nil, nil))
+			vfst.RunTests(t, fs, "",
+				vfst.TestPath("/home/user/plan.json",
+					vfst.TestModeIsRegular,
+				),
+				vfst.TestPath("/home/user/.bashrc",
+					vfst.TestContentsString("# old contents of .bashrc\n"),
+				),
+			)
+
+			if tc.mutate != nil {
+				tc.mutate(t, fs)
+			}
+
+			c = newTestConfig(
+				fs,
+				withApplyCmdConfig(applyCmdConfig{
+					plan: "/home/user/plan.json",
+				}),
+			)
+			if tc.wantErr {
+				assert.Error(t, c.runApplyCmd(nil, nil))
+			} else {
+				assert.NoError(t, c.runApplyCmd(nil, nil))
+			}
+			vfst.RunTests(t, fs, "", tc.wantTests)
+		})
+	}
+}
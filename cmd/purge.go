@@ -40,6 +40,8 @@ func (c *Config) runPurgeCmd(cmd *cobra.Command, args []string) error {
 		}
 	}
 	paths = append(paths,
+		c.getCacheDir(),
+		filepath.Join(c.stateHome, "chezmoi"),
 		c.configFile,
 		c.getPersistentStateFile(),
 		c.SourceDir,
@@ -38,7 +38,7 @@ func (c *Config) runRemoveCmd(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	entries, err := c.getEntries(ts, args)
+	entries, err := c.getSourceEntries(ts, args)
 	if err != nil {
 		return nil
 	}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var rollbackCmd = &cobra.Command{
+	Use:     "rollback [generation]",
+	Args:    cobra.MaximumNArgs(1),
+	Short:   "Restore destination files from a backup generation",
+	Long:    mustGetLongHelp("rollback"),
+	Example: getExample("rollback"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runRollbackCmd,
+}
+
+type rollbackCmdConfig struct {
+	list bool
+}
+
+func init() {
+	rootCmd.AddCommand(rollbackCmd)
+
+	persistentFlags := rollbackCmd.PersistentFlags()
+	persistentFlags.BoolVarP(&config.rollback.list, "list", "l", false, "list generations")
+}
+
+func (c *Config) runRollbackCmd(cmd *cobra.Command, args []string) error {
+	backupsDir := c.getBackupsDir()
+	generations, err := chezmoi.BackupGenerations(c.fs, backupsDir)
+	if err != nil {
+		return err
+	}
+
+	if c.rollback.list {
+		for _, generation := range generations {
+			backupGeneration, err := chezmoi.ReadBackupGeneration(c.fs, chezmoi.BackupGenerationDir(backupsDir, generation))
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(c.Stdout, "%d\t%s\t%d\n", generation, backupGeneration.CreatedAt.Format(time.RFC3339), len(backupGeneration.Entries)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(generations) == 0 {
+		return errors.New("no backup generations")
+	}
+	generation := generations[len(generations)-1]
+	if len(args) > 0 {
+		generation, err = strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("%s: invalid generation", args[0])
+		}
+		found := false
+		for _, g := range generations {
+			if g == generation {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%d: no such generation", generation)
+		}
+	}
+
+	// Back up the current state so that the rollback can itself be rolled
+	// back.
+	mutator := c.mutator
+	if !c.DryRun {
+		backupDir, err := chezmoi.NextBackupGenerationDir(c.fs, backupsDir)
+		if err != nil {
+			return err
+		}
+		mutator = chezmoi.NewBackupMutator(mutator, c.fs, backupDir)
+	}
+
+	if err := chezmoi.RestoreBackupGeneration(c.fs, mutator, chezmoi.BackupGenerationDir(backupsDir, generation)); err != nil {
+		return err
+	}
+	if c.DryRun {
+		return nil
+	}
+	return c.pruneBackups()
+}
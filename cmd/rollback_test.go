@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestRollbackCmd(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".bashrc": "# old contents of .bashrc\n",
+			".local/share/chezmoi": map[string]interface{}{
+				"dot_bashrc": "# contents of .bashrc\n",
+				"dot_hgrc":   "# contents of .hgrc\n",
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	c := newTestConfig(fs)
+	assert.Error(t, c.runRollbackCmd(nil, nil))
+
+	require.NoError(t, c.runApplyCmd(nil, nil))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestContentsString("# contents of .bashrc\n"),
+		),
+		vfst.TestPath("/home/user/.hgrc",
+			vfst.TestContentsString("# contents of .hgrc\n"),
+		),
+		vfst.TestPath("/home/user/.local/state/chezmoi/backups/1",
+			vfst.TestIsDir,
+		),
+	)
+
+	// Applying again changes nothing, so no new generation is created.
+	require.NoError(t, c.runApplyCmd(nil, nil))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/state/chezmoi/backups/2",
+			vfst.TestDoesNotExist,
+		),
+	)
+
+	assert.Error(t, c.runRollbackCmd(nil, []string{"2"}))
+
+	require.NoError(t, c.runRollbackCmd(nil, []string{"1"}))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestContentsString("# old contents of .bashrc\n"),
+		),
+		vfst.TestPath("/home/user/.hgrc",
+			vfst.TestDoesNotExist,
+		),
+	)
+
+	stdout := &bytes.Buffer{}
+	c = newTestConfig(
+		fs,
+		withRollbackCmdConfig(rollbackCmdConfig{
+			list: true,
+		}),
+		withStdout(stdout),
+	)
+	require.NoError(t, c.runRollbackCmd(nil, nil))
+	lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "1\t"))
+	assert.True(t, strings.HasPrefix(lines[1], "2\t"))
+
+	// Rolling back the rollback restores the applied state.
+	c = newTestConfig(fs)
+	require.NoError(t, c.runRollbackCmd(nil, nil))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestContentsString("# contents of .bashrc\n"),
+		),
+		vfst.TestPath("/home/user/.hgrc",
+			vfst.TestContentsString("# contents of .hgrc\n"),
+		),
+	)
+}
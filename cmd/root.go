@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
@@ -55,6 +54,7 @@ func init() {
 		initErr = err
 		return
 	}
+	config.stateHome = getDefaultStateHome(homeDir)
 
 	persistentFlags := rootCmd.PersistentFlags()
 
@@ -70,6 +70,9 @@ func init() {
 	persistentFlags.BoolVar(&config.Remove, "remove", false, "remove targets")
 	panicOnError(viper.BindPFlag("remove", persistentFlags.Lookup("remove")))
 
+	persistentFlags.BoolVar(&config.RunModifyScripts, "run-modify-scripts", false, "run modify scripts in dry runs and diffs")
+	panicOnError(viper.BindPFlag("run-modify-scripts", persistentFlags.Lookup("run-modify-scripts")))
+
 	persistentFlags.StringVarP(&config.SourceDir, "source", "S", getDefaultSourceDir(config.bds), "source directory")
 	panicOnError(viper.BindPFlag("source", persistentFlags.Lookup("source")))
 	panicOnError(rootCmd.MarkPersistentFlagDirname("source"))
@@ -108,7 +111,7 @@ func init() {
 					"warning: to disable this warning, set gpg.recipient in your config file instead\n",
 				)
 			}
-			if config.SourceVCS.Command != "" && !config.SourceVCS.NotGit && !strings.Contains(filepath.Base(config.SourceVCS.Command), "git") {
+			if config.SourceVCS.Command != "" && !config.SourceVCS.NotGit && !config.sourceVCSLooksLikeGit() {
 				rootCmd.Printf("" +
 					"warning: it looks like you are using a version control system that is not git which will be deprecated in v2\n" +
 					"warning: please report this at https://github.com/twpayne/chezmoi/issues/459\n" +
@@ -1,6 +1,10 @@
 package cmd
 
-import "github.com/spf13/cobra"
+import (
+	"sync"
+
+	"github.com/spf13/cobra"
+)
 
 var secretCmd = &cobra.Command{
 	Use:     "secret",
@@ -13,3 +17,19 @@ var secretCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(secretCmd)
 }
+
+// A lockingCache is a cache of secrets that allows the lookup of each key to
+// be serialized, so that concurrently executed templates only run a secret
+// manager once for each key.
+type lockingCache struct {
+	sync.Map
+	mutexes sync.Map
+}
+
+// lock locks key in c and returns a function that unlocks it.
+func (c *lockingCache) lock(key interface{}) func() {
+	value, _ := c.mutexes.LoadOrStore(key, &sync.Mutex{})
+	mutex := value.(*sync.Mutex)
+	mutex.Lock()
+	return mutex.Unlock
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockingCache(t *testing.T) {
+	t.Parallel()
+
+	var cache lockingCache
+	var misses int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer cache.lock("key")()
+			if _, ok := cache.Load("key"); ok {
+				return
+			}
+			atomic.AddInt32(&misses, 1)
+			cache.Store("key", "value")
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), misses)
+}
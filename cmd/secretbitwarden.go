@@ -23,7 +23,7 @@ type bitwardenCmdConfig struct {
 	Command string
 }
 
-var bitwardenOutputCache = make(map[string][]byte)
+var bitwardenOutputCache lockingCache
 
 func init() {
 	config.Bitwarden.Command = "bw"
@@ -39,8 +39,9 @@ func (c *Config) runBitwardenCmd(cmd *cobra.Command, args []string) error {
 
 func (c *Config) bitwardenOutput(args []string) []byte {
 	key := strings.Join(args, "\x00")
-	if output, ok := bitwardenOutputCache[key]; ok {
-		return output
+	defer bitwardenOutputCache.lock(key)()
+	if output, ok := bitwardenOutputCache.Load(key); ok {
+		return output.([]byte)
 	}
 
 	//nolint:gosec
@@ -52,7 +53,7 @@ func (c *Config) bitwardenOutput(args []string) []byte {
 		panic(fmt.Errorf("%s %s: %w\n%s", c.Bitwarden.Command, chezmoi.ShellQuoteArgs(args), err, output))
 	}
 
-	bitwardenOutputCache[key] = output
+	bitwardenOutputCache.Store(key, output)
 	return output
 }
 
@@ -25,8 +25,8 @@ type genericSecretCmdConfig struct {
 }
 
 var (
-	secretCache     = make(map[string]string)
-	secretJSONCache = make(map[string]interface{})
+	secretCache     lockingCache
+	secretJSONCache lockingCache
 )
 
 func init() {
@@ -42,8 +42,9 @@ func (c *Config) runGenericSecretCmd(cmd *cobra.Command, args []string) error {
 
 func (c *Config) secretFunc(args ...string) string {
 	key := strings.Join(args, "\x00")
-	if value, ok := secretCache[key]; ok {
-		return value
+	defer secretCache.lock(key)()
+	if value, ok := secretCache.Load(key); ok {
+		return value.(string)
 	}
 	name := c.GenericSecret.Command
 	cmd := exec.Command(name, args...)
@@ -54,13 +55,14 @@ func (c *Config) secretFunc(args ...string) string {
 		panic(fmt.Errorf("%s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
 	}
 	value := string(bytes.TrimSpace(output))
-	secretCache[key] = value
+	secretCache.Store(key, value)
 	return value
 }
 
 func (c *Config) secretJSONFunc(args ...string) interface{} {
 	key := strings.Join(args, "\x00")
-	if value, ok := secretJSONCache[key]; ok {
+	defer secretJSONCache.lock(key)()
+	if value, ok := secretJSONCache.Load(key); ok {
 		return value
 	}
 	name := c.GenericSecret.Command
@@ -75,6 +77,6 @@ func (c *Config) secretJSONFunc(args ...string) interface{} {
 	if err := json.Unmarshal(output, &value); err != nil {
 		panic(fmt.Errorf("%s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
 	}
-	secretJSONCache[key] = value
+	secretJSONCache.Store(key, value)
 	return value
 }
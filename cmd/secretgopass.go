@@ -33,7 +33,7 @@ type gopassCmdConfig struct {
 	versionCheckOnce sync.Once
 }
 
-var gopassCache = make(map[string]string)
+var gopassCache lockingCache
 
 func init() {
 	secretCmd.AddCommand(gopassCmd)
@@ -62,8 +62,9 @@ func (c *Config) gopassFunc(id string) string {
 	c.Gopass.versionCheckOnce.Do(func() {
 		panicOnError(c.gopassVersionCheck())
 	})
-	if s, ok := gopassCache[id]; ok {
-		return s
+	defer gopassCache.lock(id)()
+	if s, ok := gopassCache.Load(id); ok {
+		return s.(string)
 	}
 	output, err := c.gopassOutput("show", "--password", id)
 	panicOnError(err)
@@ -73,8 +74,8 @@ func (c *Config) gopassFunc(id string) string {
 	} else {
 		password = string(output)
 	}
-	gopassCache[id] = password
-	return gopassCache[id]
+	gopassCache.Store(id, password)
+	return password
 }
 
 func (c *Config) gopassVersionCheck() error {
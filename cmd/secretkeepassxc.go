@@ -10,6 +10,7 @@ import (
 	"os/exec"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/coreos/go-semver/semver"
 	"github.com/spf13/cobra"
@@ -38,10 +39,12 @@ type keePassXCAttributeCacheKey struct {
 
 var (
 	keePassXCVersion                     *semver.Version
-	keePassXCCache                       = make(map[string]map[string]string)
-	keePassXCAttributeCache              = make(map[keePassXCAttributeCacheKey]string)
+	keePassXCVersionMutex                sync.Mutex
+	keePassXCCache                       lockingCache
+	keePassXCAttributeCache              lockingCache
 	keePassXCPairRegexp                  = regexp.MustCompile(`^([^:]+): (.*)$`)
 	keePassXCPassword                    string
+	keePassXCPasswordMutex               sync.Mutex
 	keePassXCNeedShowProtectedArgVersion = semver.Version{Major: 2, Minor: 5, Patch: 1}
 )
 
@@ -58,6 +61,8 @@ func (c *Config) runKeePassXCCmd(cmd *cobra.Command, args []string) error {
 }
 
 func (c *Config) getKeePassXCVersion() *semver.Version {
+	keePassXCVersionMutex.Lock()
+	defer keePassXCVersionMutex.Unlock()
 	if keePassXCVersion != nil {
 		return keePassXCVersion
 	}
@@ -76,8 +81,9 @@ func (c *Config) getKeePassXCVersion() *semver.Version {
 }
 
 func (c *Config) keePassXCFunc(entry string) map[string]string {
-	if data, ok := keePassXCCache[entry]; ok {
-		return data
+	defer keePassXCCache.lock(entry)()
+	if data, ok := keePassXCCache.Load(entry); ok {
+		return data.(map[string]string)
 	}
 	if c.KeePassXC.Database == "" {
 		panic(errors.New("keepassxc.database not set"))
@@ -97,7 +103,7 @@ func (c *Config) keePassXCFunc(entry string) map[string]string {
 	if err != nil {
 		panic(fmt.Errorf("%s %s: %w", name, chezmoi.ShellQuoteArgs(args), err))
 	}
-	keePassXCCache[entry] = data
+	keePassXCCache.Store(entry, data)
 	return data
 }
 
@@ -106,8 +112,9 @@ func (c *Config) keePassXCAttributeFunc(entry, attribute string) string {
 		entry:     entry,
 		attribute: attribute,
 	}
-	if data, ok := keePassXCAttributeCache[key]; ok {
-		return data
+	defer keePassXCAttributeCache.lock(key)()
+	if data, ok := keePassXCAttributeCache.Load(key); ok {
+		return data.(string)
 	}
 	if c.KeePassXC.Database == "" {
 		panic(errors.New("keepassxc.database not set"))
@@ -124,7 +131,7 @@ func (c *Config) keePassXCAttributeFunc(entry, attribute string) string {
 		panic(fmt.Errorf("%s %s: %w", name, chezmoi.ShellQuoteArgs(args), err))
 	}
 	outputStr := strings.TrimSpace(string(output))
-	keePassXCAttributeCache[key] = outputStr
+	keePassXCAttributeCache.Store(key, outputStr)
 	return outputStr
 }
 
@@ -163,18 +170,30 @@ func readPassword(prompt string) (pw []byte, err error) {
 }
 
 func (c *Config) runKeePassXCCLICommand(name string, args []string) ([]byte, error) {
+	password, err := c.getKeePassXCPassword()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = bytes.NewBufferString(password + "\n")
+	cmd.Stderr = c.Stderr
+	return c.mutator.IdempotentCmdOutput(cmd)
+}
+
+// getKeePassXCPassword returns the password to unlock the KeePassXC database,
+// prompting for it only once, even when called concurrently.
+func (c *Config) getKeePassXCPassword() (string, error) {
+	keePassXCPasswordMutex.Lock()
+	defer keePassXCPasswordMutex.Unlock()
 	if keePassXCPassword == "" {
 		password, err := readPassword(fmt.Sprintf("Insert password to unlock %s: ", c.KeePassXC.Database))
 		fmt.Println()
 		if err != nil {
-			return nil, err
+			return "", err
 		}
 		keePassXCPassword = string(password)
 	}
-	cmd := exec.Command(name, args...)
-	cmd.Stdin = bytes.NewBufferString(keePassXCPassword + "\n")
-	cmd.Stderr = c.Stderr
-	return c.mutator.IdempotentCmdOutput(cmd)
+	return keePassXCPassword, nil
 }
 
 func parseKeyPassXCOutput(output []byte) (map[string]string, error) {
@@ -24,7 +24,7 @@ type keyringKey struct {
 	user    string
 }
 
-var keyringCache = make(map[keyringKey]string)
+var keyringCache lockingCache
 
 func init() {
 	secretCmd.AddCommand(keyringCmd)
@@ -45,13 +45,14 @@ func (*Config) keyringFunc(service, user string) string {
 		service: service,
 		user:    user,
 	}
-	if password, ok := keyringCache[key]; ok {
-		return password
+	defer keyringCache.lock(key)()
+	if password, ok := keyringCache.Load(key); ok {
+		return password.(string)
 	}
 	password, err := keyring.Get(service, user)
 	if err != nil {
 		panic(fmt.Errorf("%q %q: %w", service, user, err))
 	}
-	keyringCache[key] = password
+	keyringCache.Store(key, password)
 	return password
 }
@@ -38,7 +38,7 @@ type lastpassCmdConfig struct {
 	versionCheckOnce sync.Once
 }
 
-var lastPassCache = make(map[string][]map[string]interface{})
+var lastPassCache lockingCache
 
 func init() {
 	config.Lastpass.Command = "lpass"
@@ -68,8 +68,9 @@ func (c *Config) lastpassRawFunc(id string) []map[string]interface{} {
 	c.Lastpass.versionCheckOnce.Do(func() {
 		panicOnError(c.lastpassVersionCheck())
 	})
-	if data, ok := lastPassCache[id]; ok {
-		return data
+	defer lastPassCache.lock(id)()
+	if data, ok := lastPassCache.Load(id); ok {
+		return data.([]map[string]interface{})
 	}
 	output, err := c.lastpassOutput("show", "--json", id)
 	panicOnError(err)
@@ -77,7 +78,7 @@ func (c *Config) lastpassRawFunc(id string) []map[string]interface{} {
 	if err := json.Unmarshal(output, &data); err != nil {
 		panic(fmt.Errorf("parse error: %w\n%q", err, output))
 	}
-	lastPassCache[id] = data
+	lastPassCache.Store(id, data)
 	return data
 }
 
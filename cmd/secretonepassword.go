@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
 
 	"github.com/coreos/go-semver/semver"
 	"github.com/spf13/cobra"
@@ -28,7 +29,8 @@ type onepasswordCmdConfig struct {
 
 var (
 	onepasswordVersion         *semver.Version
-	onepasswordOutputCache     = make(map[string][]byte)
+	onepasswordVersionMutex    sync.Mutex
+	onepasswordOutputCache     lockingCache
 	onepasswordCacheArgVersion = semver.Version{Major: 1, Minor: 8, Patch: 0}
 )
 
@@ -43,6 +45,8 @@ func init() {
 }
 
 func (c *Config) getOnepasswordVersion() *semver.Version {
+	onepasswordVersionMutex.Lock()
+	defer onepasswordVersionMutex.Unlock()
 	if onepasswordVersion != nil {
 		return onepasswordVersion
 	}
@@ -70,8 +74,9 @@ func (c *Config) onepasswordOutput(args []string) []byte {
 	}
 
 	key := strings.Join(args, "\x00")
-	if output, ok := onepasswordOutputCache[key]; ok {
-		return output
+	defer onepasswordOutputCache.lock(key)()
+	if output, ok := onepasswordOutputCache.Load(key); ok {
+		return output.([]byte)
 	}
 
 	name := c.Onepassword.Command
@@ -83,7 +88,7 @@ func (c *Config) onepasswordOutput(args []string) []byte {
 		panic(fmt.Errorf("%s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
 	}
 
-	onepasswordOutputCache[key] = output
+	onepasswordOutputCache.Store(key, output)
 	return output
 }
 
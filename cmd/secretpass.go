@@ -22,7 +22,7 @@ type passCmdConfig struct {
 	Command string
 }
 
-var passCache = make(map[string]string)
+var passCache lockingCache
 
 func init() {
 	secretCmd.AddCommand(passCmd)
@@ -36,8 +36,9 @@ func (c *Config) runSecretPassCmd(cmd *cobra.Command, args []string) error {
 }
 
 func (c *Config) passFunc(id string) string {
-	if s, ok := passCache[id]; ok {
-		return s
+	defer passCache.lock(id)()
+	if s, ok := passCache.Load(id); ok {
+		return s.(string)
 	}
 	name := c.Pass.Command
 	args := []string{"show", id}
@@ -54,6 +55,6 @@ func (c *Config) passFunc(id string) string {
 	} else {
 		password = string(output)
 	}
-	passCache[id] = password
-	return passCache[id]
+	passCache.Store(id, password)
+	return password
 }
@@ -22,7 +22,7 @@ type vaultCmdConfig struct {
 	Command string
 }
 
-var vaultCache = make(map[string]interface{})
+var vaultCache lockingCache
 
 func init() {
 	config.Vault.Command = "vault"
@@ -36,7 +36,8 @@ func (c *Config) runVaultCmd(cmd *cobra.Command, args []string) error {
 }
 
 func (c *Config) vaultFunc(key string) interface{} {
-	if data, ok := vaultCache[key]; ok {
+	defer vaultCache.lock(key)()
+	if data, ok := vaultCache.Load(key); ok {
 		return data
 	}
 	name := c.Vault.Command
@@ -52,6 +53,6 @@ func (c *Config) vaultFunc(key string) interface{} {
 	if err := json.Unmarshal(output, &data); err != nil {
 		panic(fmt.Errorf("%s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
 	}
-	vaultCache[key] = data
+	vaultCache.Store(key, data)
 	return data
 }
@@ -18,5 +18,10 @@ func init() {
 }
 
 func (c *Config) runSourceCmd(cmd *cobra.Command, args []string) error {
-	return c.run(c.SourceDir, c.SourceVCS.Command, args...)
+	vcs, err := c.getVCS()
+	if err != nil {
+		// Commands can still be run for unsupported source VCSs.
+		return c.run(c.SourceDir, c.SourceVCS.Command, args...)
+	}
+	return vcs.Run(c.SourceDir, args)
 }
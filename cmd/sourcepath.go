@@ -31,7 +31,7 @@ func (c *Config) runSourcePathCmd(cmd *cobra.Command, args []string) error {
 		_, err := fmt.Println(ts.SourceDir)
 		return err
 	}
-	entries, err := c.getEntries(ts, args)
+	entries, err := c.getSourceEntries(ts, args)
 	if err != nil {
 		return err
 	}
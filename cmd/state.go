@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var stateCmd = &cobra.Command{
+	Use:     "state",
+	Args:    cobra.NoArgs,
+	Short:   "Manipulate the persistent state",
+	Long:    mustGetLongHelp("state"),
+	Example: getExample("state"),
+}
+
+type stateCmdConfig struct {
+	bucket string
+	force  bool
+	format string
+	key    string
+}
+
+func init() {
+	rootCmd.AddCommand(stateCmd)
+}
+
+// getStateBucket returns the bucket named name.
+func (c *Config) getStateBucket(name string) ([]byte, error) {
+	for _, bucket := range c.getStateBuckets() {
+		if string(bucket) == name {
+			return bucket, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: unknown bucket", name)
+}
+
+// getStateBuckets returns all buckets in the persistent state.
+func (c *Config) getStateBuckets() [][]byte {
+	return [][]byte{
+		c.scriptStateBucket,
+	}
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+func TestStateCmd(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.config/chezmoi": &vfst.Dir{Perm: 0o755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	persistentState, err := chezmoi.NewBoltPersistentState(fs, "/home/user/.config/chezmoi/chezmoistate.boltdb", nil)
+	require.NoError(t, err)
+	require.NoError(t, persistentState.Set([]byte("script"), []byte("install.sh"), []byte(`{"name":"run_onchange_install.sh"}`)))
+	require.NoError(t, persistentState.Set([]byte("script"), []byte("update.sh"), []byte(`{"name":"run_onchange_update.sh"}`)))
+	require.NoError(t, persistentState.Close())
+
+	stdout := &bytes.Buffer{}
+	c := newTestConfig(
+		fs,
+		withStateCmdConfig(stateCmdConfig{
+			format: "json",
+		}),
+		withStdout(stdout),
+	)
+	require.NoError(t, c.runStateDumpCmd(nil, nil))
+	var actual interface{}
+	require.NoError(t, json.NewDecoder(stdout).Decode(&actual))
+	assert.Equal(t, map[string]interface{}{
+		"script": map[string]interface{}{
+			"install.sh": map[string]interface{}{
+				"name": "run_onchange_install.sh",
+			},
+			"update.sh": map[string]interface{}{
+				"name": "run_onchange_update.sh",
+			},
+		},
+	}, actual)
+
+	stdout.Reset()
+	c.state = stateCmdConfig{
+		bucket: "script",
+		key:    "install.sh",
+	}
+	require.NoError(t, c.runStateGetCmd(nil, nil))
+	assert.Equal(t, "{\"name\":\"run_onchange_install.sh\"}\n", stdout.String())
+
+	require.NoError(t, c.runStateDeleteCmd(nil, nil))
+	stdout.Reset()
+	require.NoError(t, c.runStateGetCmd(nil, nil))
+	assert.Equal(t, "", stdout.String())
+
+	c.state = stateCmdConfig{
+		bucket: "unknown",
+		key:    "update.sh",
+	}
+	assert.Error(t, c.runStateGetCmd(nil, nil))
+	assert.Error(t, c.runStateResetCmd(nil, nil))
+
+	c.state = stateCmdConfig{
+		bucket: "script",
+		key:    "update.sh",
+	}
+	c.Stdin = bytes.NewBufferString("n\n")
+	require.NoError(t, c.runStateResetCmd(nil, nil))
+	stdout.Reset()
+	require.NoError(t, c.runStateGetCmd(nil, nil))
+	assert.Equal(t, "{\"name\":\"run_onchange_update.sh\"}\n", stdout.String())
+
+	c.state.force = true
+	require.NoError(t, c.runStateResetCmd(nil, nil))
+	stdout.Reset()
+	require.NoError(t, c.runStateGetCmd(nil, nil))
+	assert.Equal(t, "", stdout.String())
+}
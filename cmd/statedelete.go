@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var stateDeleteCmd = &cobra.Command{
+	Use:     "delete",
+	Args:    cobra.NoArgs,
+	Short:   "Delete a value from the persistent state",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runStateDeleteCmd,
+}
+
+func init() {
+	stateCmd.AddCommand(stateDeleteCmd)
+
+	persistentFlags := stateDeleteCmd.PersistentFlags()
+
+	persistentFlags.StringVar(&config.state.bucket, "bucket", "", "bucket")
+	panicOnError(stateDeleteCmd.MarkPersistentFlagRequired("bucket"))
+
+	persistentFlags.StringVar(&config.state.key, "key", "", "key")
+	panicOnError(stateDeleteCmd.MarkPersistentFlagRequired("key"))
+}
+
+func (c *Config) runStateDeleteCmd(cmd *cobra.Command, args []string) error {
+	bucket, err := c.getStateBucket(c.state.bucket)
+	if err != nil {
+		return err
+	}
+
+	persistentState, err := c.getPersistentState(nil)
+	if err != nil {
+		return err
+	}
+	defer persistentState.Close()
+
+	return persistentState.Delete(bucket, []byte(c.state.key))
+}
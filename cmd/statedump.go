@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+var stateDumpCmd = &cobra.Command{
+	Use:     "dump",
+	Args:    cobra.NoArgs,
+	Short:   "Write a dump of the persistent state to stdout",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runStateDumpCmd,
+}
+
+func init() {
+	stateCmd.AddCommand(stateDumpCmd)
+
+	persistentFlags := stateDumpCmd.PersistentFlags()
+	persistentFlags.StringVarP(&config.state.format, "format", "f", "json", "format (JSON, TOML, or YAML)")
+}
+
+func (c *Config) runStateDumpCmd(cmd *cobra.Command, args []string) error {
+	format, ok := formatMap[strings.ToLower(c.state.format)]
+	if !ok {
+		return fmt.Errorf("%s: unknown format", c.state.format)
+	}
+
+	persistentState, err := c.getPersistentState(&bolt.Options{
+		ReadOnly: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer persistentState.Close()
+
+	data := make(map[string]map[string]interface{})
+	for _, bucket := range c.getStateBuckets() {
+		bucketData := make(map[string]interface{})
+		if err := persistentState.ForEach(bucket, func(k, v []byte) error {
+			// Values are usually JSON, but fall back to strings for anything
+			// else.
+			var value interface{}
+			if err := json.Unmarshal(v, &value); err != nil {
+				value = string(v)
+			}
+			bucketData[string(k)] = value
+			return nil
+		}); err != nil {
+			return err
+		}
+		data[string(bucket)] = bucketData
+	}
+	return format(c.Stdout, data)
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+var stateGetCmd = &cobra.Command{
+	Use:     "get",
+	Args:    cobra.NoArgs,
+	Short:   "Get a value from the persistent state",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runStateGetCmd,
+}
+
+func init() {
+	stateCmd.AddCommand(stateGetCmd)
+
+	persistentFlags := stateGetCmd.PersistentFlags()
+
+	persistentFlags.StringVar(&config.state.bucket, "bucket", "", "bucket")
+	panicOnError(stateGetCmd.MarkPersistentFlagRequired("bucket"))
+
+	persistentFlags.StringVar(&config.state.key, "key", "", "key")
+	panicOnError(stateGetCmd.MarkPersistentFlagRequired("key"))
+}
+
+func (c *Config) runStateGetCmd(cmd *cobra.Command, args []string) error {
+	bucket, err := c.getStateBucket(c.state.bucket)
+	if err != nil {
+		return err
+	}
+
+	persistentState, err := c.getPersistentState(&bolt.Options{
+		ReadOnly: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer persistentState.Close()
+
+	value, err := persistentState.Get(bucket, []byte(c.state.key))
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		return nil
+	}
+	_, err = c.Stdout.Write(append(value, '\n'))
+	return err
+}
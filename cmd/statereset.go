@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var stateResetCmd = &cobra.Command{
+	Use:     "reset",
+	Args:    cobra.NoArgs,
+	Short:   "Reset the persistent state",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runStateResetCmd,
+}
+
+func init() {
+	stateCmd.AddCommand(stateResetCmd)
+
+	persistentFlags := stateResetCmd.PersistentFlags()
+	persistentFlags.StringVar(&config.state.bucket, "bucket", "", "bucket")
+	persistentFlags.BoolVarP(&config.state.force, "force", "f", false, "reset without prompting")
+}
+
+func (c *Config) runStateResetCmd(cmd *cobra.Command, args []string) error {
+	buckets := c.getStateBuckets()
+	if c.state.bucket != "" {
+		bucket, err := c.getStateBucket(c.state.bucket)
+		if err != nil {
+			return err
+		}
+		buckets = [][]byte{bucket}
+	}
+
+	persistentState, err := c.getPersistentState(nil)
+	if err != nil {
+		return err
+	}
+	defer persistentState.Close()
+
+BUCKET:
+	for _, bucket := range buckets {
+		if !c.state.force {
+			choice, err := c.prompt(fmt.Sprintf("Reset bucket %s", bucket), "ynqa")
+			if err != nil {
+				return err
+			}
+			switch choice {
+			case 'a':
+				c.state.force = true
+			case 'n':
+				continue BUCKET
+			case 'q':
+				return nil
+			}
+		}
+		if err := persistentState.DeleteBucket(bucket); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,17 +1,85 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/itchyny/gojq"
+	"github.com/pelletier/go-toml"
+	"gopkg.in/ini.v1"
+	"gopkg.in/yaml.v2"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 func init() {
+	config.addTemplateFunc("fromIni", config.fromIniFunc)
+	config.addTemplateFunc("fromJson", config.fromJSONFunc)
+	config.addTemplateFunc("fromToml", config.fromTOMLFunc)
+	config.addTemplateFunc("fromYaml", config.fromYAMLFunc)
 	config.addTemplateFunc("include", config.includeFunc)
 	config.addTemplateFunc("joinPath", config.joinPathFunc)
+	config.addTemplateFunc("jq", config.jqFunc)
 	config.addTemplateFunc("lookPath", config.lookPathFunc)
+	config.addTemplateFunc("readDestFile", config.readDestFileFunc)
+	config.addTemplateFunc("setValueAtPath", config.setValueAtPathFunc)
 	config.addTemplateFunc("stat", config.statFunc)
+	config.addTemplateFunc("toIni", config.toIniFunc)
+	config.addTemplateFunc("toToml", config.toTOMLFunc)
+	config.addTemplateFunc("toYaml", config.toYAMLFunc)
+}
+
+func (c *Config) fromIniFunc(text string) map[string]interface{} {
+	file, err := ini.Load([]byte(text))
+	if err != nil {
+		panic(err)
+	}
+	result := make(map[string]interface{})
+	for _, section := range file.Sections() {
+		values := make(map[string]interface{})
+		for _, key := range section.Keys() {
+			values[key.Name()] = key.Value()
+		}
+		if section.Name() == ini.DefaultSection {
+			for key, value := range values {
+				result[key] = value
+			}
+			continue
+		}
+		result[section.Name()] = values
+	}
+	return result
+}
+
+func (c *Config) fromJSONFunc(text string) interface{} {
+	var result interface{}
+	if err := json.Unmarshal([]byte(text), &result); err != nil {
+		panic(err)
+	}
+	return result
+}
+
+func (c *Config) fromTOMLFunc(text string) map[string]interface{} {
+	tree, err := toml.Load(text)
+	if err != nil {
+		panic(err)
+	}
+	return tree.ToMap()
+}
+
+func (c *Config) fromYAMLFunc(text string) interface{} {
+	var result interface{}
+	if err := yaml.Unmarshal([]byte(text), &result); err != nil {
+		panic(err)
+	}
+	return chezmoi.NormalizeTemplateData(result)
 }
 
 func (c *Config) includeFunc(filename string) string {
@@ -26,6 +94,36 @@ func (c *Config) joinPathFunc(elem ...string) string {
 	return filepath.Join(elem...)
 }
 
+func (c *Config) jqFunc(query string, input interface{}) []interface{} {
+	q, err := gojq.Parse(query)
+	if err != nil {
+		panic(err)
+	}
+	// gojq only accepts the types produced by encoding/json, so round trip
+	// input through JSON.
+	data, err := json.Marshal(input)
+	if err != nil {
+		panic(err)
+	}
+	var jsonInput interface{}
+	if err := json.Unmarshal(data, &jsonInput); err != nil {
+		panic(err)
+	}
+	var results []interface{}
+	iter := q.Run(jsonInput)
+	for {
+		result, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, ok := result.(error); ok {
+			panic(err)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
 func (c *Config) lookPathFunc(file string) string {
 	path, err := exec.LookPath(file)
 	switch {
@@ -38,6 +136,36 @@ func (c *Config) lookPathFunc(file string) string {
 	}
 }
 
+func (c *Config) readDestFileFunc(filename string) string {
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(c.DestDir, filename)
+	}
+	contents, err := c.fs.ReadFile(filename)
+	switch {
+	case err == nil:
+		return string(contents)
+	case os.IsNotExist(err):
+		return ""
+	default:
+		panic(err)
+	}
+}
+
+func (c *Config) setValueAtPathFunc(path string, value interface{}, dict map[string]interface{}) map[string]interface{} {
+	keys := strings.Split(path, ".")
+	m := dict
+	for _, key := range keys[:len(keys)-1] {
+		child, ok := m[key].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			m[key] = child
+		}
+		m = child
+	}
+	m[keys[len(keys)-1]] = value
+	return dict
+}
+
 func (c *Config) statFunc(name string) interface{} {
 	info, err := c.fs.Stat(name)
 	switch {
@@ -56,3 +184,61 @@ func (c *Config) statFunc(name string) interface{} {
 		panic(err)
 	}
 }
+
+func (c *Config) toIniFunc(data map[string]interface{}) string {
+	file := ini.Empty()
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, ok := data[key].(map[string]interface{}); ok {
+			continue
+		}
+		if _, err := file.Section(ini.DefaultSection).NewKey(key, fmt.Sprintf("%v", data[key])); err != nil {
+			panic(err)
+		}
+	}
+	for _, sectionName := range keys {
+		values, ok := data[sectionName].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		section, err := file.NewSection(sectionName)
+		if err != nil {
+			panic(err)
+		}
+		sectionKeys := make([]string, 0, len(values))
+		for key := range values {
+			sectionKeys = append(sectionKeys, key)
+		}
+		sort.Strings(sectionKeys)
+		for _, key := range sectionKeys {
+			if _, err := section.NewKey(key, fmt.Sprintf("%v", values[key])); err != nil {
+				panic(err)
+			}
+		}
+	}
+	b := &bytes.Buffer{}
+	if _, err := file.WriteTo(b); err != nil {
+		panic(err)
+	}
+	return b.String()
+}
+
+func (c *Config) toTOMLFunc(data map[string]interface{}) string {
+	tree, err := toml.TreeFromMap(data)
+	if err != nil {
+		panic(err)
+	}
+	return tree.String()
+}
+
+func (c *Config) toYAMLFunc(data interface{}) string {
+	yamlData, err := yaml.Marshal(data)
+	if err != nil {
+		panic(err)
+	}
+	return string(yamlData)
+}
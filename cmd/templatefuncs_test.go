@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestFromIniFunc(t *testing.T) {
+	c := newTestConfig(nil)
+	assert.Equal(t, map[string]interface{}{
+		"key": "value",
+		"section": map[string]interface{}{
+			"sectionKey": "sectionValue",
+		},
+	}, c.fromIniFunc(strings.Join([]string{
+		"key = value",
+		"",
+		"[section]",
+		"sectionKey = sectionValue",
+	}, "\n")))
+}
+
+func TestFromTOMLFunc(t *testing.T) {
+	c := newTestConfig(nil)
+	assert.Equal(t, map[string]interface{}{
+		"key": "value",
+		"section": map[string]interface{}{
+			"sectionKey": int64(1),
+		},
+	}, c.fromTOMLFunc(strings.Join([]string{
+		`key = "value"`,
+		"",
+		"[section]",
+		"sectionKey = 1",
+	}, "\n")))
+}
+
+func TestFromYAMLFunc(t *testing.T) {
+	c := newTestConfig(nil)
+	assert.Equal(t, map[string]interface{}{
+		"key": "value",
+		"section": map[string]interface{}{
+			"sectionKey": 1,
+		},
+	}, c.fromYAMLFunc(strings.Join([]string{
+		"key: value",
+		"section:",
+		"  sectionKey: 1",
+	}, "\n")))
+}
+
+func TestJqFunc(t *testing.T) {
+	c := newTestConfig(nil)
+	assert.Equal(t, []interface{}{"a", "b"}, c.jqFunc(".users[].name", map[string]interface{}{
+		"users": []interface{}{
+			map[string]interface{}{"name": "a", "uid": int64(1000)},
+			map[string]interface{}{"name": "b", "uid": int64(1001)},
+		},
+	}))
+	assert.Panics(t, func() {
+		c.jqFunc(".[", nil)
+	})
+}
+
+func TestSetValueAtPathFunc(t *testing.T) {
+	c := newTestConfig(nil)
+	assert.Equal(t, map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": map[string]interface{}{
+				"c": 1,
+			},
+			"d": 2,
+		},
+	}, c.setValueAtPathFunc("a.b.c", 1, map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": "replaced",
+			"d": 2,
+		},
+	}))
+}
+
+func TestToIniFunc(t *testing.T) {
+	c := newTestConfig(nil)
+	data := map[string]interface{}{
+		"key": "value",
+		"section": map[string]interface{}{
+			"sectionKey": "sectionValue",
+		},
+	}
+	assert.Equal(t, data, c.fromIniFunc(c.toIniFunc(data)))
+}
+
+func TestToTOMLFunc(t *testing.T) {
+	c := newTestConfig(nil)
+	data := map[string]interface{}{
+		"key": "value",
+		"section": map[string]interface{}{
+			"sectionKey": int64(1),
+		},
+	}
+	assert.Equal(t, data, c.fromTOMLFunc(c.toTOMLFunc(data)))
+}
+
+func TestToYAMLFunc(t *testing.T) {
+	c := newTestConfig(nil)
+	data := map[string]interface{}{
+		"key": "value",
+		"section": map[string]interface{}{
+			"sectionKey": 1,
+		},
+	}
+	assert.Equal(t, data, c.fromYAMLFunc(c.toYAMLFunc(data)))
+}
+
+func TestMergeDestFile(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.config/Code/User/settings.json": `{"editor.fontSize":12,"window.zoomLevel":1}`,
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	c := newTestConfig(fs)
+	for _, tc := range []struct {
+		name     string
+		filename string
+		expected string
+	}{
+		{
+			name:     "exists",
+			filename: ".config/Code/User/settings.json",
+			expected: strings.Join([]string{
+				"{",
+				`  "editor.fontSize": 14,`,
+				`  "window.zoomLevel": 1`,
+				"}",
+			}, "\n"),
+		},
+		{
+			name:     "not_exist",
+			filename: ".config/Code/User/keybindings.json",
+			expected: strings.Join([]string{
+				"{",
+				`  "editor.fontSize": 14`,
+				"}",
+			}, "\n"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl, err := template.New(tc.name).Funcs(sprig.TxtFuncMap()).Funcs(template.FuncMap{
+				"fromJson":     c.fromJSONFunc,
+				"readDestFile": c.readDestFileFunc,
+			}).Parse(`` +
+				`{{ $settings := readDestFile "` + tc.filename + `" | default "{}" | fromJson }}` +
+				`{{ mergeOverwrite $settings (dict "editor.fontSize" 14) | toPrettyJson }}`,
+			)
+			require.NoError(t, err)
+			sb := &strings.Builder{}
+			require.NoError(t, tmpl.Execute(sb, nil))
+			assert.Equal(t, tc.expected, sb.String())
+		})
+	}
+}
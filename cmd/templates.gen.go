@@ -6,17 +6,11 @@ func init() {
 	assets["assets/templates/COMMIT_MESSAGE.tmpl"] = []byte("" +
 		"{{- /* FIXME generate commit summary */ -}}\n" +
 		"\n" +
-		"{{- range .Ordinary -}}\n" +
-		"{{ if and (eq .X 'A') (eq .Y '.') -}}Add {{ .Path }}\n" +
-		"{{ else if and (eq .X 'D') (eq .Y '.') -}}Remove {{ .Path }}\n" +
-		"{{ else if and (eq .X 'M') (eq .Y '.') -}}Update {{ .Path }}\n" +
-		"{{ else }}{{with (printf \"unsupported XY: %q\" (printf \"%c%c\" .X .Y)) }}{{ fail . }}{{ end }}\n" +
-		"{{ end }}\n" +
-		"{{- end -}}\n" +
-		"\n" +
-		"{{- range .RenamedOrCopied -}}\n" +
-		"{{ if and (eq .X 'R') (eq .Y '.') }}Rename {{ .OrigPath }} to {{ .Path }}\n" +
-		"{{ else }}{{with (printf \"unsupported XY: %q\" (printf \"%c%c\" .X .Y)) }}{{ fail . }}{{ end }}\n" +
+		"{{- range .Changes -}}\n" +
+		"{{ if eq .Kind \"added\" -}}Add {{ .Name }}\n" +
+		"{{ else if eq .Kind \"deleted\" -}}Remove {{ .Name }}\n" +
+		"{{ else if eq .Kind \"modified\" -}}Update {{ .Name }}\n" +
+		"{{ else if eq .Kind \"renamed\" -}}Rename {{ .OrigName }} to {{ .Name }}\n" +
 		"{{ end }}\n" +
 		"{{- end -}}\n" +
 		"\n" +
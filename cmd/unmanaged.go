@@ -38,9 +38,9 @@ func (c *Config) runUnmanagedCmd(cmd *cobra.Command, args []string) error {
 		}
 		entry, _ := ts.Get(c.fs, path)
 		managed := entry != nil
-		ignored := ts.TargetIgnore.Match(strings.TrimPrefix(path, c.DestDir+"/"))
+		ignored := ts.TargetIgnore.Match(strings.TrimPrefix(path, c.DestDir+"/"), info.IsDir())
 		if !managed && !ignored {
-			fmt.Println(path)
+			fmt.Fprintln(c.Stdout, path)
 		}
 		if info.IsDir() && (!managed || ignored) {
 			return filepath.SkipDir
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmanagedCmdNestedIgnore(t *testing.T) {
+	fs, cleanup, err := newNestedIgnoreTestFS()
+	require.NoError(t, err)
+	defer cleanup()
+	stdout := &bytes.Buffer{}
+	c := newTestConfig(
+		fs,
+		withStdout(stdout),
+	)
+	require.NoError(t, c.runUnmanagedCmd(nil, nil))
+	posixTargetNames, err := extractPOSIXTargetNames(stdout.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"/home/user/.config/other",
+		"/home/user/.local",
+		"/home/user/.ssh/id_rsa",
+	}, posixTargetNames)
+}
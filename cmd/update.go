@@ -1,14 +1,21 @@
 package cmd
 
 import (
-	"fmt"
-	"strings"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
+	vfs "github.com/twpayne/go-vfs"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 type updateCmdConfig struct {
-	apply bool
+	apply         bool
+	failIfChanges bool
+	interactive   bool
 }
 
 var updateCmd = &cobra.Command{
@@ -26,31 +33,83 @@ func init() {
 
 	persistentFlags := updateCmd.PersistentFlags()
 	persistentFlags.BoolVarP(&config.update.apply, "apply", "a", true, "apply after pulling")
+	persistentFlags.BoolVar(&config.update.failIfChanges, "fail-if-changes", false, "show incoming changes and fail if there are any, without pulling")
+	persistentFlags.BoolVar(&config.update.interactive, "interactive", false, "show incoming changes and prompt before pulling")
 }
 
 func (c *Config) runUpdateCmd(cmd *cobra.Command, args []string) error {
+	if c.update.failIfChanges || c.update.interactive {
+		return c.runUpdatePreview()
+	}
+
 	vcs, err := c.getVCS()
 	if err != nil {
 		return err
 	}
-	var pullArgs []string
-	if c.SourceVCS.Pull != nil {
-		switch v := c.SourceVCS.Pull.(type) {
-		case string:
-			pullArgs = strings.Split(v, " ")
-		case []string:
-			pullArgs = v
-		default:
-			return fmt.Errorf("sourceVCS.pull: cannot parse value")
+	if err := vcs.Pull(c.SourceDir); err != nil {
+		return err
+	}
+
+	if c.update.apply {
+		persistentState, err := c.getPersistentState(nil)
+		if err != nil {
+			return err
+		}
+		defer persistentState.Close()
+		if err := c.applyArgs(nil, persistentState); err != nil {
+			return err
 		}
-	} else {
-		pullArgs = vcs.PullArgs()
 	}
-	if pullArgs == nil {
-		return fmt.Errorf("%s: pull not supported", c.SourceVCS.Command)
+
+	return nil
+}
+
+// runUpdatePreview fetches changes from the source VCS, prints the incoming
+// log and the diff that applying them would make, and then either fails if
+// there are any changes or prompts before merging and applying them.
+func (c *Config) runUpdatePreview() error {
+	vcs, err := c.getVCS()
+	if err != nil {
+		return err
+	}
+
+	if err := vcs.Fetch(c.SourceDir); err != nil {
+		return err
 	}
 
-	if err := c.run(c.SourceDir, c.SourceVCS.Command, pullArgs...); err != nil {
+	incomingLog, err := vcs.IncomingLog(c.SourceDir)
+	if err != nil {
+		return err
+	}
+	if _, err := c.Stdout.Write(incomingLog); err != nil {
+		return err
+	}
+
+	changed, err := c.diffUpstream(vcs)
+	if err != nil {
+		return err
+	}
+	changed = changed || len(incomingLog) != 0
+
+	if c.update.failIfChanges {
+		if changed {
+			return errExitFailure
+		}
+		return nil
+	}
+	if !changed {
+		return nil
+	}
+
+	choice, err := c.prompt("Pull and apply these changes", "yn")
+	if err != nil {
+		return err
+	}
+	if choice != 'y' {
+		return nil
+	}
+
+	if err := vcs.Merge(c.SourceDir); err != nil {
 		return err
 	}
 
@@ -67,3 +126,63 @@ func (c *Config) runUpdateCmd(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// diffUpstream checks out the fetched upstream into a temporary source
+// directory, writes the diff between its target state and the destination
+// directory to c.Stdout, and returns whether there were any differences. No
+// scripts or modify scripts from the upstream are run and no externals are
+// downloaded.
+func (c *Config) diffUpstream(vcs vcsDriver) (bool, error) {
+	// Create the temporary source directory next to the source directory so
+	// that it is accessible through c.fs.
+	rawSourceDir, err := c.fs.RawPath(c.SourceDir)
+	if err != nil {
+		return false, err
+	}
+	rawTempDir, err := ioutil.TempDir(filepath.Dir(rawSourceDir), "chezmoi-update-")
+	if err != nil {
+		return false, err
+	}
+	defer os.RemoveAll(rawTempDir)
+	tempDir := filepath.Join(filepath.Dir(c.SourceDir), filepath.Base(rawTempDir))
+
+	if err := vcs.CheckoutUpstream(c.SourceDir, tempDir); err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = vcs.RemoveUpstreamCheckout(c.SourceDir, tempDir)
+	}()
+
+	// Compute the diff as if the upstream was the source directory, without
+	// running any scripts or changing any state.
+	prevSourceDir, prevDryRun, prevMutator := c.SourceDir, c.DryRun, c.mutator
+	defer func() {
+		c.SourceDir, c.DryRun, c.mutator = prevSourceDir, prevDryRun, prevMutator
+	}()
+	c.SourceDir = tempDir
+	c.DryRun = true
+	var baseMutator chezmoi.Mutator = chezmoi.NullMutator{}
+	if c.Diff.Format == "git" {
+		baseMutator = chezmoi.NewFSMutator(vfs.NewReadOnlyFS(c.fs))
+	}
+	mutator := chezmoi.NewAnyMutator(baseMutator)
+	c.mutator = c.newDiffMutator(c.Stdout, mutator)
+
+	persistentState, err := c.getPersistentState(&bolt.Options{
+		ReadOnly: true,
+	})
+	if err != nil {
+		return false, err
+	}
+	defer persistentState.Close()
+	// The upstream has not been approved yet, so do not run its modify
+	// scripts or download its externals.
+	if err := c.applyArgsWithPopulateOptions(nil, persistentState, &chezmoi.PopulateOptions{
+		ExecuteTemplates:    true,
+		CachedExternalsOnly: true,
+		SkipModifyScripts:   true,
+	}); err != nil {
+		return false, err
+	}
+	return mutator.Mutated(), nil
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	gogit "github.com/go-git/go-git/v5"
+	gogitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestUpdatePreview(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".bashrc": "# contents of .bashrc\n",
+			"upstream": map[string]interface{}{
+				"dot_bashrc": "# contents of .bashrc\n",
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	rawBareDir, err := fs.RawPath("/home/user/repo.git")
+	require.NoError(t, err)
+	rawUpstreamDir, err := fs.RawPath("/home/user/upstream")
+	require.NoError(t, err)
+
+	vcs := builtinGitVCS{
+		fs: fs,
+	}
+	sourceVCS := sourceVCSConfig{
+		Command: builtinVCSCommand,
+	}
+
+	// Create an upstream repo, push it to a local bare repo, and clone the
+	// bare repo into the source directory.
+	_, err = gogit.PlainInit(rawBareDir, true)
+	require.NoError(t, err)
+	require.NoError(t, vcs.Init("/home/user/upstream"))
+	upstreamRepo, err := gogit.PlainOpen(rawUpstreamDir)
+	require.NoError(t, err)
+	setBuiltinGitTestUser(t, upstreamRepo)
+	_, err = upstreamRepo.CreateRemote(&gogitconfig.RemoteConfig{
+		Name: gogit.DefaultRemoteName,
+		URLs: []string{rawBareDir},
+	})
+	require.NoError(t, err)
+	require.NoError(t, vcs.Add("/home/user/upstream"))
+	require.NoError(t, vcs.Commit("/home/user/upstream", "Initial commit\n"))
+	require.NoError(t, vcs.Push("/home/user/upstream"))
+	require.NoError(t, newTestConfig(fs, withSourceVCS(sourceVCS)).runInitCmd(nil, []string{rawBareDir}))
+
+	// With no incoming changes, --fail-if-changes succeeds.
+	stdout := &bytes.Buffer{}
+	require.NoError(t, newTestConfig(
+		fs,
+		withSourceVCS(sourceVCS),
+		withStdout(stdout),
+		withUpdateCmdConfig(updateCmdConfig{
+			failIfChanges: true,
+		}),
+	).runUpdateCmd(nil, nil))
+	assert.Equal(t, "", stdout.String())
+
+	// Push a change upstream.
+	require.NoError(t, fs.WriteFile("/home/user/upstream/dot_bashrc", []byte("# new contents of .bashrc\n"), 0o666))
+	require.NoError(t, vcs.Add("/home/user/upstream"))
+	require.NoError(t, vcs.Commit("/home/user/upstream", "Update .bashrc\n"))
+	require.NoError(t, vcs.Push("/home/user/upstream"))
+
+	// With incoming changes, --fail-if-changes prints the log and the diff,
+	// fails, and does not change anything.
+	stdout = &bytes.Buffer{}
+	assert.Equal(t, errExitFailure, newTestConfig(
+		fs,
+		withSourceVCS(sourceVCS),
+		withStdout(stdout),
+		withUpdateCmdConfig(updateCmdConfig{
+			failIfChanges: true,
+		}),
+	).runUpdateCmd(nil, nil))
+	assert.Regexp(t, `^[0-9a-f]{7} Update \.bashrc\n`, stdout.String())
+	assert.Contains(t, stdout.String(), "+# new contents of .bashrc\n")
+	assert.Contains(t, stdout.String(), "-# contents of .bashrc\n")
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/share/chezmoi/dot_bashrc",
+			vfst.TestContentsString("# contents of .bashrc\n"),
+		),
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestContentsString("# contents of .bashrc\n"),
+		),
+	)
+	infos, err := fs.ReadDir("/home/user/.local/share")
+	require.NoError(t, err)
+	for _, info := range infos {
+		assert.False(t, strings.HasPrefix(info.Name(), "chezmoi-update-"), info.Name())
+	}
+
+	// Declining the prompt does not change anything.
+	require.NoError(t, newTestConfig(
+		fs,
+		withSourceVCS(sourceVCS),
+		withStdin(bytes.NewBufferString("n\n")),
+		withStdout(&bytes.Buffer{}),
+		withUpdateCmdConfig(updateCmdConfig{
+			apply:       true,
+			interactive: true,
+		}),
+	).runUpdateCmd(nil, nil))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/share/chezmoi/dot_bashrc",
+			vfst.TestContentsString("# contents of .bashrc\n"),
+		),
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestContentsString("# contents of .bashrc\n"),
+		),
+	)
+
+	// Accepting the prompt pulls and applies the changes.
+	require.NoError(t, newTestConfig(
+		fs,
+		withSourceVCS(sourceVCS),
+		withStdin(bytes.NewBufferString("y\n")),
+		withStdout(&bytes.Buffer{}),
+		withUpdateCmdConfig(updateCmdConfig{
+			apply:       true,
+			interactive: true,
+		}),
+	).runUpdateCmd(nil, nil))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/share/chezmoi/dot_bashrc",
+			vfst.TestContentsString("# new contents of .bashrc\n"),
+		),
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestContentsString("# new contents of .bashrc\n"),
+		),
+	)
+}
@@ -1,23 +1,61 @@
 package cmd
 
-import "regexp"
+import (
+	"regexp"
+
+	vfs "github.com/twpayne/go-vfs"
+)
 
 // A VCS is a version control system.
 type VCS interface {
 	AddArgs(string) []string
-	CloneArgs(string, string) []string
+	CloneArgs(string, string, vcsCloneOptions) ([]string, error)
 	CommitArgs(string) []string
+	FetchArgs() []string
+	IncomingLogArgs() []string
 	InitArgs() []string
 	Initialized(string) (bool, error)
+	MergeArgs() []string
 	ParseStatusOutput([]byte) (interface{}, error)
+	PostCloneArgs(vfs.FS, string, vcsCloneOptions) ([][]string, error)
+	PostPullArgs(vfs.FS, string) ([][]string, error)
 	PullArgs() []string
 	PushArgs() []string
 	StatusArgs() []string
+	UpstreamCheckoutArgs(string) []string
+	UpstreamCheckoutCleanupArgs(string) []string
 	VersionArgs() []string
 	VersionRegexp() *regexp.Regexp
 }
 
+// vcsCloneOptions are options for cloning a repo.
+type vcsCloneOptions struct {
+	Branch string
+	Depth  int
+}
+
 var vcses = map[string]VCS{
 	"git": gitVCS{},
 	"hg":  hgVCS{},
 }
+
+// A vcsDriver performs version control operations on a repository. All dirs
+// are paths in the config's filesystem.
+type vcsDriver interface {
+	Add(dir string) error
+	CheckoutUpstream(dir, dst string) error
+	Clone(repo, dir string, options vcsCloneOptions) error
+	Commit(dir, message string) error
+	Fetch(dir string) error
+	Git() bool
+	IncomingLog(dir string) ([]byte, error)
+	Init(dir string) error
+	Initialized(dir string) (bool, error)
+	Merge(dir string) error
+	Pull(dir string) error
+	Push(dir string) error
+	RemoveUpstreamCheckout(dir, dst string) error
+	Run(dir string, args []string) error
+	Status(dir string) (interface{}, error)
+	doctorCheck() *doctorBinaryCheck
+}
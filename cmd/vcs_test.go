@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestVCSCloneArgs(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		vcs          VCS
+		options      vcsCloneOptions
+		expectedArgs []string
+		expectedErr  bool
+	}{
+		{
+			name:         "git",
+			vcs:          gitVCS{},
+			expectedArgs: []string{"clone", "repo", "dir"},
+		},
+		{
+			name: "git_branch_and_depth",
+			vcs:  gitVCS{},
+			options: vcsCloneOptions{
+				Branch: "main",
+				Depth:  1,
+			},
+			expectedArgs: []string{"clone", "--branch", "main", "--depth", "1", "repo", "dir"},
+		},
+		{
+			name: "hg_branch",
+			vcs:  hgVCS{},
+			options: vcsCloneOptions{
+				Branch: "default",
+			},
+			expectedArgs: []string{"clone", "--branch", "default", "repo", "dir"},
+		},
+		{
+			name: "hg_depth",
+			vcs:  hgVCS{},
+			options: vcsCloneOptions{
+				Depth: 1,
+			},
+			expectedErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actualArgs, err := tc.vcs.CloneArgs("repo", "dir", tc.options)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedArgs, actualArgs)
+		})
+	}
+}
+
+func TestVCSPostCloneAndPostPullArgs(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/nosubmodules/dot_bashrc": "# contents of .bashrc\n",
+		"/home/user/submodules/.gitmodules":  "[submodule \"dot_vim\"]\n",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	for _, tc := range []struct {
+		name                  string
+		vcs                   VCS
+		dir                   string
+		options               vcsCloneOptions
+		expectedPostCloneArgs [][]string
+		expectedPostPullArgs  [][]string
+	}{
+		{
+			name: "git_no_submodules",
+			vcs:  gitVCS{},
+			dir:  "/home/user/nosubmodules",
+		},
+		{
+			name: "git_submodules",
+			vcs:  gitVCS{},
+			dir:  "/home/user/submodules",
+			expectedPostCloneArgs: [][]string{
+				{"submodule", "update", "--init", "--recursive"},
+			},
+			expectedPostPullArgs: [][]string{
+				{"submodule", "sync", "--recursive"},
+				{"submodule", "update", "--init", "--recursive"},
+			},
+		},
+		{
+			name: "git_submodules_depth",
+			vcs:  gitVCS{},
+			dir:  "/home/user/submodules",
+			options: vcsCloneOptions{
+				Depth: 1,
+			},
+			expectedPostCloneArgs: [][]string{
+				{"submodule", "update", "--init", "--recursive", "--depth", "1"},
+			},
+			expectedPostPullArgs: [][]string{
+				{"submodule", "sync", "--recursive"},
+				{"submodule", "update", "--init", "--recursive"},
+			},
+		},
+		{
+			name: "hg",
+			vcs:  hgVCS{},
+			dir:  "/home/user/nosubmodules",
+			expectedPostPullArgs: [][]string{
+				{"update"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actualPostCloneArgs, err := tc.vcs.PostCloneArgs(fs, tc.dir, tc.options)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedPostCloneArgs, actualPostCloneArgs)
+			actualPostPullArgs, err := tc.vcs.PostPullArgs(fs, tc.dir)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedPostPullArgs, actualPostPullArgs)
+		})
+	}
+}
@@ -23,6 +23,9 @@ func init() {
 }
 
 func (c *Config) runVerifyCmd(cmd *cobra.Command, args []string) error {
+	c.DryRun = true           // Prevent script state from being recorded.
+	c.RunModifyScripts = true // Verify the output of modify scripts.
+
 	mutator := chezmoi.NewAnyMutator(chezmoi.NullMutator{})
 	c.mutator = mutator
 
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestVerifyRemove(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".oldrc": "# contents of .oldrc\n",
+			".local/share/chezmoi": map[string]interface{}{
+				"remove_dot_oldrc": "",
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	assert.Equal(t, errExitFailure, newTestConfig(fs).runVerifyCmd(nil, nil))
+	require.NoError(t, newTestConfig(fs).runApplyCmd(nil, nil))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.oldrc",
+			vfst.TestDoesNotExist,
+		),
+	)
+	assert.NoError(t, newTestConfig(fs).runVerifyCmd(nil, nil))
+}
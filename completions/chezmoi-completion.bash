@@ -369,6 +369,7 @@ _chezmoi_add()
 
     flags+=("--autotemplate")
     flags+=("-a")
+    flags+=("--create")
     flags+=("--empty")
     flags+=("-e")
     flags+=("--encrypt")
@@ -403,6 +404,7 @@ _chezmoi_add()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -432,6 +434,12 @@ _chezmoi_apply()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--format=")
+    two_word_flags+=("--format")
+    two_word_flags+=("-f")
+    flags+=("--plan=")
+    two_word_flags+=("--plan")
+    flags+=("--rollback-on-error")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -453,6 +461,7 @@ _chezmoi_apply()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -482,6 +491,9 @@ _chezmoi_archive()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--format=")
+    two_word_flags+=("--format")
+    two_word_flags+=("-f")
     flags+=("--output=")
     two_word_flags+=("--output")
     flags_with_completion+=("--output")
@@ -510,6 +522,7 @@ _chezmoi_archive()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -560,6 +573,7 @@ _chezmoi_cat()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -610,6 +624,7 @@ _chezmoi_cd()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -660,6 +675,7 @@ _chezmoi_chattr()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -721,6 +737,7 @@ _chezmoi_completion()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -757,6 +774,8 @@ _chezmoi_data()
     flags+=("--format=")
     two_word_flags+=("--format")
     two_word_flags+=("-f")
+    flags+=("--sources")
+    flags+=("-s")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -778,6 +797,7 @@ _chezmoi_data()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -832,6 +852,7 @@ _chezmoi_diff()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -882,6 +903,7 @@ _chezmoi_docs()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -932,6 +954,7 @@ _chezmoi_doctor()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -987,6 +1010,7 @@ _chezmoi_dump()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -1043,6 +1067,7 @@ _chezmoi_edit()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -1093,6 +1118,7 @@ _chezmoi_edit-config()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -1155,6 +1181,7 @@ _chezmoi_execute-template()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -1205,6 +1232,7 @@ _chezmoi_forget()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -1255,6 +1283,7 @@ _chezmoi_git()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -1305,6 +1334,7 @@ _chezmoi_help()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -1355,6 +1385,58 @@ _chezmoi_hg()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    flags_with_completion+=("--source")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-S")
+    flags_with_completion+=("-S")
+    flags_completion+=("_filedir -d")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_ignored()
+{
+    last_command="chezmoi_ignored"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    flags_with_completion+=("--destination")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-D")
+    flags_with_completion+=("-D")
+    flags_completion+=("_filedir -d")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -1386,6 +1468,9 @@ _chezmoi_import()
 
     flags+=("--exact")
     flags+=("-x")
+    flags+=("--format=")
+    two_word_flags+=("--format")
+    two_word_flags+=("-f")
     flags+=("--remove-destination")
     flags+=("-r")
     flags+=("--strip-components=")
@@ -1411,6 +1496,7 @@ _chezmoi_import()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -1441,6 +1527,10 @@ _chezmoi_init()
     flags_completion=()
 
     flags+=("--apply")
+    flags+=("--branch=")
+    two_word_flags+=("--branch")
+    flags+=("--depth=")
+    two_word_flags+=("--depth")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -1462,6 +1552,7 @@ _chezmoi_init()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -1515,6 +1606,7 @@ _chezmoi_managed()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -1565,6 +1657,61 @@ _chezmoi_merge()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    flags_with_completion+=("--source")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-S")
+    flags_with_completion+=("-S")
+    flags_completion+=("_filedir -d")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_plan()
+{
+    last_command="chezmoi_plan"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--output=")
+    two_word_flags+=("--output")
+    two_word_flags+=("-o")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    flags_with_completion+=("--destination")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-D")
+    flags_with_completion+=("-D")
+    flags_completion+=("_filedir -d")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -1617,6 +1764,7 @@ _chezmoi_purge()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -1669,6 +1817,60 @@ _chezmoi_remove()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    flags_with_completion+=("--source")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-S")
+    flags_with_completion+=("-S")
+    flags_completion+=("_filedir -d")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_rollback()
+{
+    last_command="chezmoi_rollback"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--list")
+    flags+=("-l")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    flags_with_completion+=("--destination")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-D")
+    flags_with_completion+=("-D")
+    flags_completion+=("_filedir -d")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -1719,6 +1921,7 @@ _chezmoi_secret_bitwarden()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -1769,6 +1972,7 @@ _chezmoi_secret_generic()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -1819,6 +2023,7 @@ _chezmoi_secret_gopass()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -1869,6 +2074,7 @@ _chezmoi_secret_keepassxc()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -1919,6 +2125,7 @@ _chezmoi_secret_keyring_get()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--service=")
     two_word_flags+=("--service")
     flags+=("--source=")
@@ -1975,6 +2182,7 @@ _chezmoi_secret_keyring_set()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--service=")
     two_word_flags+=("--service")
     flags+=("--source=")
@@ -2035,6 +2243,7 @@ _chezmoi_secret_keyring()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -2087,6 +2296,7 @@ _chezmoi_secret_lastpass()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -2137,6 +2347,7 @@ _chezmoi_secret_onepassword()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -2187,6 +2398,7 @@ _chezmoi_secret_pass()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -2237,6 +2449,7 @@ _chezmoi_secret_vault()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -2296,6 +2509,7 @@ _chezmoi_secret()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -2346,6 +2560,7 @@ _chezmoi_source()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -2396,6 +2611,285 @@ _chezmoi_source-path()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    flags_with_completion+=("--source")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-S")
+    flags_with_completion+=("-S")
+    flags_completion+=("_filedir -d")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_state_delete()
+{
+    last_command="chezmoi_state_delete"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--bucket=")
+    two_word_flags+=("--bucket")
+    flags+=("--key=")
+    two_word_flags+=("--key")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    flags_with_completion+=("--destination")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-D")
+    flags_with_completion+=("-D")
+    flags_completion+=("_filedir -d")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--run-modify-scripts")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    flags_with_completion+=("--source")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-S")
+    flags_with_completion+=("-S")
+    flags_completion+=("_filedir -d")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_flag+=("--bucket=")
+    must_have_one_flag+=("--key=")
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_state_dump()
+{
+    last_command="chezmoi_state_dump"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--format=")
+    two_word_flags+=("--format")
+    two_word_flags+=("-f")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    flags_with_completion+=("--destination")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-D")
+    flags_with_completion+=("-D")
+    flags_completion+=("_filedir -d")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--run-modify-scripts")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    flags_with_completion+=("--source")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-S")
+    flags_with_completion+=("-S")
+    flags_completion+=("_filedir -d")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_state_get()
+{
+    last_command="chezmoi_state_get"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--bucket=")
+    two_word_flags+=("--bucket")
+    flags+=("--key=")
+    two_word_flags+=("--key")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    flags_with_completion+=("--destination")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-D")
+    flags_with_completion+=("-D")
+    flags_completion+=("_filedir -d")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--run-modify-scripts")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    flags_with_completion+=("--source")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-S")
+    flags_with_completion+=("-S")
+    flags_completion+=("_filedir -d")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_flag+=("--bucket=")
+    must_have_one_flag+=("--key=")
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_state_reset()
+{
+    last_command="chezmoi_state_reset"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--bucket=")
+    two_word_flags+=("--bucket")
+    flags+=("--force")
+    flags+=("-f")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    flags_with_completion+=("--destination")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-D")
+    flags_with_completion+=("-D")
+    flags_completion+=("_filedir -d")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--run-modify-scripts")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    flags_with_completion+=("--source")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-S")
+    flags_with_completion+=("-S")
+    flags_completion+=("_filedir -d")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_state()
+{
+    last_command="chezmoi_state"
+
+    command_aliases=()
+
+    commands=()
+    commands+=("delete")
+    commands+=("dump")
+    commands+=("get")
+    commands+=("reset")
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    flags_with_completion+=("--destination")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-D")
+    flags_with_completion+=("-D")
+    flags_completion+=("_filedir -d")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -2446,6 +2940,7 @@ _chezmoi_unmanaged()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -2477,6 +2972,8 @@ _chezmoi_update()
 
     flags+=("--apply")
     flags+=("-a")
+    flags+=("--fail-if-changes")
+    flags+=("--interactive")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -2498,6 +2995,7 @@ _chezmoi_update()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -2559,6 +3057,7 @@ _chezmoi_upgrade()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -2609,6 +3108,7 @@ _chezmoi_verify()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -2658,19 +3158,23 @@ _chezmoi_root_command()
     commands+=("git")
     commands+=("help")
     commands+=("hg")
+    commands+=("ignored")
     commands+=("import")
     commands+=("init")
     commands+=("managed")
     commands+=("merge")
+    commands+=("plan")
     commands+=("purge")
     commands+=("remove")
     if [[ -z "${BASH_VERSION}" || "${BASH_VERSINFO[0]}" -gt 3 ]]; then
         command_aliases+=("rm")
         aliashash["rm"]="remove"
     fi
+    commands+=("rollback")
     commands+=("secret")
     commands+=("source")
     commands+=("source-path")
+    commands+=("state")
     commands+=("unmanaged")
     commands+=("update")
     commands+=("upgrade")
@@ -2703,6 +3207,7 @@ _chezmoi_root_command()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--run-modify-scripts")
     flags+=("--source=")
     two_word_flags+=("--source")
     flags_with_completion+=("--source")
@@ -27,13 +27,14 @@ Register-ArgumentCompleter -Native -CommandName 'chezmoi' -ScriptBlock {
             [CompletionResult]::new('--dry-run', 'dry-run', [CompletionResultType]::ParameterName, 'dry run')
             [CompletionResult]::new('--follow', 'follow', [CompletionResultType]::ParameterName, 'follow symlinks')
             [CompletionResult]::new('--remove', 'remove', [CompletionResultType]::ParameterName, 'remove targets')
+            [CompletionResult]::new('--run-modify-scripts', 'run-modify-scripts', [CompletionResultType]::ParameterName, 'run modify scripts in dry runs and diffs')
             [CompletionResult]::new('-S', 'S', [CompletionResultType]::ParameterName, 'source directory')
             [CompletionResult]::new('--source', 'source', [CompletionResultType]::ParameterName, 'source directory')
             [CompletionResult]::new('-v', 'v', [CompletionResultType]::ParameterName, 'verbose')
             [CompletionResult]::new('--verbose', 'verbose', [CompletionResultType]::ParameterName, 'verbose')
             [CompletionResult]::new('add', 'add', [CompletionResultType]::ParameterValue, 'Add an existing file, directory, or symlink to the source state')
             [CompletionResult]::new('apply', 'apply', [CompletionResultType]::ParameterValue, 'Update the destination directory to match the target state')
-            [CompletionResult]::new('archive', 'archive', [CompletionResultType]::ParameterValue, 'Write a tar archive of the target state to stdout')
+            [CompletionResult]::new('archive', 'archive', [CompletionResultType]::ParameterValue, 'Write an archive of the target state to stdout')
             [CompletionResult]::new('cat', 'cat', [CompletionResultType]::ParameterValue, 'Print the target contents of a file or symlink')
             [CompletionResult]::new('cd', 'cd', [CompletionResultType]::ParameterValue, 'Launch a shell in the source directory')
             [CompletionResult]::new('chattr', 'chattr', [CompletionResultType]::ParameterValue, 'Change the attributes of a target in the source state')
@@ -50,15 +51,19 @@ Register-ArgumentCompleter -Native -CommandName 'chezmoi' -ScriptBlock {
             [CompletionResult]::new('git', 'git', [CompletionResultType]::ParameterValue, 'Run git in the source directory')
             [CompletionResult]::new('help', 'help', [CompletionResultType]::ParameterValue, 'Print help about a command')
             [CompletionResult]::new('hg', 'hg', [CompletionResultType]::ParameterValue, 'Run mercurial in the source directory')
-            [CompletionResult]::new('import', 'import', [CompletionResultType]::ParameterValue, 'Import a tar archive into the source state')
+            [CompletionResult]::new('ignored', 'ignored', [CompletionResultType]::ParameterValue, 'Explain why targets are ignored')
+            [CompletionResult]::new('import', 'import', [CompletionResultType]::ParameterValue, 'Import an archive into the source state')
             [CompletionResult]::new('init', 'init', [CompletionResultType]::ParameterValue, 'Setup the source directory and update the destination directory to match the target state')
             [CompletionResult]::new('managed', 'managed', [CompletionResultType]::ParameterValue, 'List the managed files in the destination directory')
             [CompletionResult]::new('merge', 'merge', [CompletionResultType]::ParameterValue, 'Perform a three-way merge between the destination state, the source state, and the target state')
+            [CompletionResult]::new('plan', 'plan', [CompletionResultType]::ParameterValue, 'Write a plan of the changes that apply would make')
             [CompletionResult]::new('purge', 'purge', [CompletionResultType]::ParameterValue, 'Purge all of chezmoi''s configuration and data')
             [CompletionResult]::new('remove', 'remove', [CompletionResultType]::ParameterValue, 'Remove a target from the source state and the destination directory')
+            [CompletionResult]::new('rollback', 'rollback', [CompletionResultType]::ParameterValue, 'Restore destination files from a backup generation')
             [CompletionResult]::new('secret', 'secret', [CompletionResultType]::ParameterValue, 'Interact with a secret manager')
             [CompletionResult]::new('source', 'source', [CompletionResultType]::ParameterValue, 'Run the source version control system command in the source directory')
             [CompletionResult]::new('source-path', 'source-path', [CompletionResultType]::ParameterValue, 'Print the path of a target in the source state')
+            [CompletionResult]::new('state', 'state', [CompletionResultType]::ParameterValue, 'Manipulate the persistent state')
             [CompletionResult]::new('unmanaged', 'unmanaged', [CompletionResultType]::ParameterValue, 'List the unmanaged files in the destination directory')
             [CompletionResult]::new('update', 'update', [CompletionResultType]::ParameterValue, 'Pull changes from the source VCS and apply any changes')
             [CompletionResult]::new('upgrade', 'upgrade', [CompletionResultType]::ParameterValue, 'Upgrade chezmoi to the latest released version')
@@ -98,6 +103,7 @@ Register-ArgumentCompleter -Native -CommandName 'chezmoi' -ScriptBlock {
             [CompletionResult]::new('-o', 'o', [CompletionResultType]::ParameterName, 'output filename')
             [CompletionResult]::new('--output', 'output', [CompletionResultType]::ParameterName, 'output filename')
             [CompletionResult]::new('--remove', 'remove', [CompletionResultType]::ParameterName, 'remove targets')
+            [CompletionResult]::new('--run-modify-scripts', 'run-modify-scripts', [CompletionResultType]::ParameterName, 'run modify scripts in dry runs and diffs')
             [CompletionResult]::new('-S', 'S', [CompletionResultType]::ParameterName, 'source directory')
             [CompletionResult]::new('--source', 'source', [CompletionResultType]::ParameterName, 'source directory')
             [CompletionResult]::new('-v', 'v', [CompletionResultType]::ParameterName, 'verbose')
@@ -140,6 +146,9 @@ Register-ArgumentCompleter -Native -CommandName 'chezmoi' -ScriptBlock {
         'chezmoi;hg' {
             break
         }
+        'chezmoi;ignored' {
+            break
+        }
         'chezmoi;import' {
             break
         }
@@ -152,12 +161,18 @@ Register-ArgumentCompleter -Native -CommandName 'chezmoi' -ScriptBlock {
         'chezmoi;merge' {
             break
         }
+        'chezmoi;plan' {
+            break
+        }
         'chezmoi;purge' {
             break
         }
         'chezmoi;remove' {
             break
         }
+        'chezmoi;rollback' {
+            break
+        }
         'chezmoi;secret' {
             [CompletionResult]::new('bitwarden', 'bitwarden', [CompletionResultType]::ParameterValue, 'Execute the Bitwarden CLI (bw)')
             [CompletionResult]::new('generic', 'generic', [CompletionResultType]::ParameterValue, 'Execute a generic secret command')
@@ -211,6 +226,25 @@ Register-ArgumentCompleter -Native -CommandName 'chezmoi' -ScriptBlock {
         'chezmoi;source-path' {
             break
         }
+        'chezmoi;state' {
+            [CompletionResult]::new('delete', 'delete', [CompletionResultType]::ParameterValue, 'Delete a value from the persistent state')
+            [CompletionResult]::new('dump', 'dump', [CompletionResultType]::ParameterValue, 'Write a dump of the persistent state to stdout')
+            [CompletionResult]::new('get', 'get', [CompletionResultType]::ParameterValue, 'Get a value from the persistent state')
+            [CompletionResult]::new('reset', 'reset', [CompletionResultType]::ParameterValue, 'Reset the persistent state')
+            break
+        }
+        'chezmoi;state;delete' {
+            break
+        }
+        'chezmoi;state;dump' {
+            break
+        }
+        'chezmoi;state;get' {
+            break
+        }
+        'chezmoi;state;reset' {
+            break
+        }
         'chezmoi;unmanaged' {
             break
         }
@@ -0,0 +1,180 @@
+package chezmoi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+const ageArmorHeader = "-----BEGIN AGE ENCRYPTED FILE-----"
+
+// An AGE encrypts and decrypts files using age, without requiring an external
+// binary.
+type AGE struct {
+	Identity       string
+	Identities     []string
+	Passphrase     bool
+	Recipient      string
+	Recipients     []string
+	RecipientsFile string
+
+	// PassphraseFunc is called to obtain the passphrase when Passphrase is
+	// true.
+	PassphraseFunc func() (string, error) `mapstructure:"-"`
+}
+
+// Decrypt implements Encryption.Decrypt.
+func (a *AGE) Decrypt(filename string, ciphertext []byte) ([]byte, error) {
+	identities, err := a.identities()
+	if err != nil {
+		return nil, err
+	}
+	var r io.Reader = bytes.NewReader(ciphertext)
+	if bytes.HasPrefix(bytes.TrimSpace(ciphertext), []byte(ageArmorHeader)) {
+		r = armor.NewReader(bytes.NewReader(bytes.TrimSpace(ciphertext)))
+	}
+	plaintextR, err := age.Decrypt(r, identities...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return ioutil.ReadAll(plaintextR)
+}
+
+// Encrypt implements Encryption.Encrypt.
+func (a *AGE) Encrypt(filename string, plaintext []byte) ([]byte, error) {
+	recipients, err := a.recipients()
+	if err != nil {
+		return nil, err
+	}
+	b := &bytes.Buffer{}
+	armorW := armor.NewWriter(b)
+	w, err := age.Encrypt(armorW, recipients...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	if err := armorW.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// EncryptedSuffix implements Encryption.EncryptedSuffix.
+func (a *AGE) EncryptedSuffix() string {
+	return ".age"
+}
+
+// identities returns a's identities.
+func (a *AGE) identities() ([]age.Identity, error) {
+	if a.Passphrase {
+		passphrase, err := a.passphrase()
+		if err != nil {
+			return nil, err
+		}
+		identity, err := age.NewScryptIdentity(passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return []age.Identity{identity}, nil
+	}
+
+	var identities []age.Identity
+	for _, identityFile := range a.identityFiles() {
+		data, err := ioutil.ReadFile(identityFile)
+		if err != nil {
+			return nil, err
+		}
+		fileIdentities, err := age.ParseIdentities(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", identityFile, err)
+		}
+		identities = append(identities, fileIdentities...)
+	}
+	if len(identities) == 0 {
+		return nil, errors.New("age: no identities")
+	}
+	return identities, nil
+}
+
+// identityFiles returns all of a's identity files.
+func (a *AGE) identityFiles() []string {
+	var identityFiles []string
+	if a.Identity != "" {
+		identityFiles = append(identityFiles, a.Identity)
+	}
+	return append(identityFiles, a.Identities...)
+}
+
+// passphrase returns the passphrase.
+func (a *AGE) passphrase() (string, error) {
+	if a.PassphraseFunc == nil {
+		return "", errors.New("age: passphrase not available")
+	}
+	return a.PassphraseFunc()
+}
+
+// recipients returns a's recipients. If no recipients are configured then the
+// recipients corresponding to a's X25519 identities are used.
+func (a *AGE) recipients() ([]age.Recipient, error) {
+	if a.Passphrase {
+		passphrase, err := a.passphrase()
+		if err != nil {
+			return nil, err
+		}
+		recipient, err := age.NewScryptRecipient(passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return []age.Recipient{recipient}, nil
+	}
+
+	var recipients []age.Recipient
+	for _, recipientStr := range append([]string{a.Recipient}, a.Recipients...) {
+		if recipientStr == "" {
+			continue
+		}
+		recipient, err := age.ParseX25519Recipient(recipientStr)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, recipient)
+	}
+	if a.RecipientsFile != "" {
+		data, err := ioutil.ReadFile(a.RecipientsFile)
+		if err != nil {
+			return nil, err
+		}
+		fileRecipients, err := age.ParseRecipients(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", a.RecipientsFile, err)
+		}
+		recipients = append(recipients, fileRecipients...)
+	}
+	if len(recipients) != 0 {
+		return recipients, nil
+	}
+
+	identities, err := a.identities()
+	if err != nil {
+		return nil, err
+	}
+	for _, identity := range identities {
+		if x25519Identity, ok := identity.(*age.X25519Identity); ok {
+			recipients = append(recipients, x25519Identity.Recipient())
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, errors.New("age: no recipients")
+	}
+	return recipients, nil
+}
@@ -0,0 +1,89 @@
+package chezmoi
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAGE(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "chezmoi-test-age")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	identityFile := filepath.Join(tempDir, "key.txt")
+	require.NoError(t, ioutil.WriteFile(identityFile, []byte(identity.String()+"\n"), 0o600))
+	otherIdentity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name string
+		age  *AGE
+	}{
+		{
+			name: "identity",
+			age: &AGE{
+				Identity: identityFile,
+			},
+		},
+		{
+			name: "identity_and_recipients",
+			age: &AGE{
+				Identity: identityFile,
+				Recipients: []string{
+					identity.Recipient().String(),
+					otherIdentity.Recipient().String(),
+				},
+			},
+		},
+		{
+			name: "passphrase",
+			age: &AGE{
+				Passphrase: true,
+				PassphraseFunc: func() (string, error) {
+					return "correct horse battery staple", nil
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			plaintext := []byte("# contents of .netrc\n")
+			ciphertext, err := tc.age.Encrypt(".netrc", plaintext)
+			require.NoError(t, err)
+			assert.Contains(t, string(ciphertext), ageArmorHeader)
+			assert.NotContains(t, string(ciphertext), string(plaintext))
+			actualPlaintext, err := tc.age.Decrypt(".netrc", ciphertext)
+			require.NoError(t, err)
+			assert.Equal(t, plaintext, actualPlaintext)
+		})
+	}
+}
+
+func TestAGEWrongIdentity(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "chezmoi-test-age")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	identityFile := filepath.Join(tempDir, "key.txt")
+	require.NoError(t, ioutil.WriteFile(identityFile, []byte(identity.String()+"\n"), 0o600))
+	otherIdentity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	a := &AGE{
+		Identity:  identityFile,
+		Recipient: otherIdentity.Recipient().String(),
+	}
+	ciphertext, err := a.Encrypt(".netrc", []byte("# contents of .netrc\n"))
+	require.NoError(t, err)
+	_, err = a.Decrypt(".netrc", ciphertext)
+	assert.Error(t, err)
+}
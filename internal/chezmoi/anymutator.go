@@ -42,6 +42,11 @@ func (m *AnyMutator) Mutated() bool {
 	return m.mutated
 }
 
+// RecordScript implements ScriptRecorder.RecordScript.
+func (m *AnyMutator) RecordScript(scriptname, dir string, data []byte, stateUpdate *ScriptStateUpdate) error {
+	return recordScript(m.m, scriptname, dir, data, stateUpdate)
+}
+
 // RemoveAll implements Mutator.RemoveAll.
 func (m *AnyMutator) RemoveAll(name string) error {
 	m.mutated = true
@@ -60,6 +65,12 @@ func (m *AnyMutator) RunCmd(cmd *exec.Cmd) error {
 	return m.m.RunCmd(cmd)
 }
 
+// RunScript implements Mutator.RunScript. Running a script is not considered a
+// mutation, as scripts are not part of the target state.
+func (m *AnyMutator) RunScript(scriptname, dir string, data []byte) error {
+	return m.m.RunScript(scriptname, dir, data)
+}
+
 // Stat implements Mutator.Stat.
 func (m *AnyMutator) Stat(path string) (os.FileInfo, error) {
 	return m.m.Stat(path)
@@ -0,0 +1,395 @@
+package chezmoi
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// Archive formats.
+const (
+	ArchiveFormatTar    = "tar"
+	ArchiveFormatTarBz2 = "tar.bz2"
+	ArchiveFormatTarGz  = "tar.gz"
+	ArchiveFormatTarXz  = "tar.xz"
+	ArchiveFormatTarZst = "tar.zst"
+	ArchiveFormatZip    = "zip"
+)
+
+// ArchiveFormats is the list of all archive formats.
+var ArchiveFormats = []string{
+	ArchiveFormatTar,
+	ArchiveFormatTarBz2,
+	ArchiveFormatTarGz,
+	ArchiveFormatTarXz,
+	ArchiveFormatTarZst,
+	ArchiveFormatZip,
+}
+
+// archiveMagics maps magic bytes at the start of data to archive formats.
+var archiveMagics = []struct {
+	magic  []byte
+	format string
+}{
+	{magic: []byte{0x1f, 0x8b}, format: ArchiveFormatTarGz},
+	{magic: []byte("BZh"), format: ArchiveFormatTarBz2},
+	{magic: []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}, format: ArchiveFormatTarXz},
+	{magic: []byte{0x28, 0xb5, 0x2f, 0xfd}, format: ArchiveFormatTarZst},
+	{magic: []byte("PK\x03\x04"), format: ArchiveFormatZip},
+	{magic: []byte("PK\x05\x06"), format: ArchiveFormatZip},
+}
+
+// tarMagicOffset is the offset of the magic bytes in a tar header.
+const tarMagicOffset = 257
+
+// An ArchiveWriter writes entries to an archive.
+type ArchiveWriter interface {
+	Close() error
+	WriteDir(name string, perm os.FileMode) error
+	WriteFile(name string, contents []byte, perm os.FileMode) error
+	WriteSymlink(name, linkname string) error
+}
+
+// A tarArchiveWriter is an ArchiveWriter that writes a tar archive, optionally
+// compressed.
+type tarArchiveWriter struct {
+	w              *tar.Writer
+	compressor     io.WriteCloser
+	headerTemplate *tar.Header
+}
+
+// A zipArchiveWriter is an ArchiveWriter that writes a zip archive.
+type zipArchiveWriter struct {
+	w              *zip.Writer
+	headerTemplate *tar.Header
+}
+
+// DetectArchiveFormat returns the archive format of data based on its magic
+// bytes, or the empty string if the format cannot be detected.
+func DetectArchiveFormat(data []byte) string {
+	for _, archiveMagic := range archiveMagics {
+		if bytes.HasPrefix(data, archiveMagic.magic) {
+			return archiveMagic.format
+		}
+	}
+	if len(data) >= tarMagicOffset+5 && string(data[tarMagicOffset:tarMagicOffset+5]) == "ustar" {
+		return ArchiveFormatTar
+	}
+	return ""
+}
+
+// PeekArchiveFormat returns the archive format of the data that br will read
+// based on its magic bytes, without consuming any data, or the empty string if
+// the format cannot be detected.
+func PeekArchiveFormat(br *bufio.Reader) (string, error) {
+	data, err := br.Peek(tarMagicOffset + 5)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return DetectArchiveFormat(data), nil
+}
+
+// GuessArchiveFormat returns the archive format of name based on its
+// extension, or the empty string if the format cannot be guessed.
+func GuessArchiveFormat(name string) string {
+	switch name = strings.ToLower(name); {
+	case strings.HasSuffix(name, ".tar"):
+		return ArchiveFormatTar
+	case strings.HasSuffix(name, ".tar.bz2") || strings.HasSuffix(name, ".tbz2"):
+		return ArchiveFormatTarBz2
+	case strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz"):
+		return ArchiveFormatTarGz
+	case strings.HasSuffix(name, ".tar.xz") || strings.HasSuffix(name, ".txz"):
+		return ArchiveFormatTarXz
+	case strings.HasSuffix(name, ".tar.zst"):
+		return ArchiveFormatTarZst
+	case strings.HasSuffix(name, ".zip"):
+		return ArchiveFormatZip
+	default:
+		return ""
+	}
+}
+
+// IsArchiveFormat returns true if format is a valid archive format.
+func IsArchiveFormat(format string) bool {
+	for _, archiveFormat := range ArchiveFormats {
+		if format == archiveFormat {
+			return true
+		}
+	}
+	return false
+}
+
+// NewArchiveWriter returns a new ArchiveWriter that writes an archive in
+// format to w, taking ownership, modification times, and other metadata from
+// headerTemplate. Closing the ArchiveWriter does not close w.
+func NewArchiveWriter(w io.Writer, format string, headerTemplate *tar.Header) (ArchiveWriter, error) {
+	var compressor io.WriteCloser
+	switch format {
+	case ArchiveFormatTar:
+	case ArchiveFormatTarGz:
+		compressor = gzip.NewWriter(w)
+	case ArchiveFormatTarXz:
+		xzWriter, err := xz.NewWriter(w)
+		if err != nil {
+			return nil, err
+		}
+		compressor = xzWriter
+	case ArchiveFormatTarZst:
+		zstdWriter, err := zstd.NewWriter(w)
+		if err != nil {
+			return nil, err
+		}
+		compressor = zstdWriter
+	case ArchiveFormatZip:
+		return &zipArchiveWriter{
+			w:              zip.NewWriter(w),
+			headerTemplate: headerTemplate,
+		}, nil
+	case ArchiveFormatTarBz2:
+		return nil, fmt.Errorf("%s: writing archive format not supported", format)
+	default:
+		return nil, fmt.Errorf("%s: unknown archive format", format)
+	}
+	if compressor != nil {
+		w = compressor
+	}
+	return &tarArchiveWriter{
+		w:              tar.NewWriter(w),
+		compressor:     compressor,
+		headerTemplate: headerTemplate,
+	}, nil
+}
+
+// NewTarReader returns a new tar.Reader that reads a tar archive in format
+// from r.
+func NewTarReader(r io.Reader, format string) (*tar.Reader, error) {
+	switch format {
+	case ArchiveFormatTar:
+		return tar.NewReader(r), nil
+	case ArchiveFormatTarBz2:
+		return tar.NewReader(bzip2.NewReader(r)), nil
+	case ArchiveFormatTarGz:
+		gzipReader, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return tar.NewReader(gzipReader), nil
+	case ArchiveFormatTarXz:
+		xzReader, err := xz.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return tar.NewReader(xzReader), nil
+	case ArchiveFormatTarZst:
+		zstdReader, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return tar.NewReader(zstdReader), nil
+	default:
+		return nil, fmt.Errorf("%s: unknown tar archive format", format)
+	}
+}
+
+// walkArchive calls f for each entry in the archive in data in format. f is
+// passed the entry's name, with forward slashes, its os.FileInfo, a reader
+// of its contents if it is a regular file, and its link name if it is a
+// symlink. Entries that are not directories, regular files, or symlinks are an
+// error.
+func walkArchive(data []byte, format string, f func(name string, info os.FileInfo, r io.Reader, linkname string) error) error {
+	if format == ArchiveFormatZip {
+		return walkZipArchive(data, f)
+	}
+	return walkTarArchive(bytes.NewReader(data), format, f)
+}
+
+// walkArchiveReader is like walkArchive but reads the archive from r. Tar
+// archives are streamed. Zip archives are read completely into memory, as
+// their central directory is at the end.
+func walkArchiveReader(r io.Reader, format string, f func(name string, info os.FileInfo, r io.Reader, linkname string) error) error {
+	if format == ArchiveFormatZip {
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		return walkZipArchive(data, f)
+	}
+	return walkTarArchive(r, format, f)
+}
+
+// walkTarArchive calls f for each entry in the tar archive in format read from
+// tarFile.
+func walkTarArchive(tarFile io.Reader, format string, f func(name string, info os.FileInfo, r io.Reader, linkname string) error) error {
+	r, err := NewTarReader(tarFile, format)
+	if err != nil {
+		return err
+	}
+	for {
+		header, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir, tar.TypeReg:
+			if err := f(header.Name, header.FileInfo(), r, ""); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := f(header.Name, header.FileInfo(), nil, header.Linkname); err != nil {
+				return err
+			}
+		case tar.TypeXGlobalHeader:
+		default:
+			return fmt.Errorf("%s: unspported typeflag '%c'", header.Name, header.Typeflag)
+		}
+	}
+}
+
+// walkZipArchive calls f for each entry in the zip archive in data.
+func walkZipArchive(data []byte, f func(name string, info os.FileInfo, r io.Reader, linkname string) error) error {
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	for _, zipFile := range zipReader.File {
+		info := zipFile.FileInfo()
+		switch {
+		case info.IsDir():
+			if err := f(zipFile.Name, info, nil, ""); err != nil {
+				return err
+			}
+		case info.Mode().IsRegular():
+			if err := walkZipFile(zipFile, func(r io.Reader) error {
+				return f(zipFile.Name, info, r, "")
+			}); err != nil {
+				return err
+			}
+		case info.Mode()&os.ModeType == os.ModeSymlink:
+			if err := walkZipFile(zipFile, func(r io.Reader) error {
+				linkname, err := ioutil.ReadAll(r)
+				if err != nil {
+					return err
+				}
+				return f(zipFile.Name, info, nil, string(linkname))
+			}); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%s: unsupported file type", zipFile.Name)
+		}
+	}
+	return nil
+}
+
+// walkZipFile calls f with a reader of zipFile's contents.
+func walkZipFile(zipFile *zip.File, f func(io.Reader) error) error {
+	r, err := zipFile.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return f(r)
+}
+
+// Close implements ArchiveWriter.Close.
+func (w *tarArchiveWriter) Close() error {
+	if err := w.w.Close(); err != nil {
+		return err
+	}
+	if w.compressor != nil {
+		return w.compressor.Close()
+	}
+	return nil
+}
+
+// WriteDir implements ArchiveWriter.WriteDir.
+func (w *tarArchiveWriter) WriteDir(name string, perm os.FileMode) error {
+	header := *w.headerTemplate
+	header.Typeflag = tar.TypeDir
+	header.Name = filepath.ToSlash(name) + "/"
+	header.Mode = int64(perm)
+	return w.w.WriteHeader(&header)
+}
+
+// WriteFile implements ArchiveWriter.WriteFile.
+func (w *tarArchiveWriter) WriteFile(name string, contents []byte, perm os.FileMode) error {
+	header := *w.headerTemplate
+	header.Typeflag = tar.TypeReg
+	header.Name = filepath.ToSlash(name)
+	header.Size = int64(len(contents))
+	header.Mode = int64(perm)
+	if err := w.w.WriteHeader(&header); err != nil {
+		return err
+	}
+	_, err := w.w.Write(contents)
+	return err
+}
+
+// WriteSymlink implements ArchiveWriter.WriteSymlink.
+func (w *tarArchiveWriter) WriteSymlink(name, linkname string) error {
+	header := *w.headerTemplate
+	header.Typeflag = tar.TypeSymlink
+	header.Name = filepath.ToSlash(name)
+	header.Linkname = linkname
+	return w.w.WriteHeader(&header)
+}
+
+// Close implements ArchiveWriter.Close.
+func (w *zipArchiveWriter) Close() error {
+	return w.w.Close()
+}
+
+// WriteDir implements ArchiveWriter.WriteDir.
+func (w *zipArchiveWriter) WriteDir(name string, perm os.FileMode) error {
+	_, err := w.w.CreateHeader(w.newFileHeader(name+string(filepath.Separator), os.ModeDir|perm))
+	return err
+}
+
+// WriteFile implements ArchiveWriter.WriteFile.
+func (w *zipArchiveWriter) WriteFile(name string, contents []byte, perm os.FileMode) error {
+	fileHeader := w.newFileHeader(name, perm)
+	fileHeader.Method = zip.Deflate
+	fileHeader.UncompressedSize64 = uint64(len(contents))
+	fw, err := w.w.CreateHeader(fileHeader)
+	if err != nil {
+		return err
+	}
+	_, err = fw.Write(contents)
+	return err
+}
+
+// WriteSymlink implements ArchiveWriter.WriteSymlink.
+func (w *zipArchiveWriter) WriteSymlink(name, linkname string) error {
+	fw, err := w.w.CreateHeader(w.newFileHeader(name, os.ModeSymlink|0o777))
+	if err != nil {
+		return err
+	}
+	_, err = fw.Write([]byte(linkname))
+	return err
+}
+
+// newFileHeader returns a new zip.FileHeader for name with mode.
+func (w *zipArchiveWriter) newFileHeader(name string, mode os.FileMode) *zip.FileHeader {
+	fileHeader := &zip.FileHeader{
+		Name:     filepath.ToSlash(name),
+		Modified: w.headerTemplate.ModTime,
+	}
+	fileHeader.SetMode(mode)
+	return fileHeader
+}
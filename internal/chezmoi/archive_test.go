@@ -0,0 +1,114 @@
+package chezmoi
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveWriterRoundTrip(t *testing.T) {
+	for _, format := range []string{
+		ArchiveFormatTar,
+		ArchiveFormatTarGz,
+		ArchiveFormatTarXz,
+		ArchiveFormatTarZst,
+		ArchiveFormatZip,
+	} {
+		t.Run(format, func(t *testing.T) {
+			b := &bytes.Buffer{}
+			w, err := NewArchiveWriter(b, format, &tar.Header{})
+			require.NoError(t, err)
+			require.NoError(t, w.WriteDir("dir", 0o755))
+			require.NoError(t, w.WriteFile("dir/file", []byte("contents"), 0o644))
+			require.NoError(t, w.WriteSymlink("symlink", "target"))
+			require.NoError(t, w.Close())
+
+			data := b.Bytes()
+			assert.Equal(t, format, DetectArchiveFormat(data))
+
+			type archiveEntry struct {
+				name     string
+				mode     os.FileMode
+				contents string
+				linkname string
+			}
+			br := bufio.NewReader(bytes.NewReader(data))
+			peekedFormat, err := PeekArchiveFormat(br)
+			require.NoError(t, err)
+			assert.Equal(t, format, peekedFormat)
+
+			for _, walk := range []func(func(string, os.FileInfo, io.Reader, string) error) error{
+				func(f func(string, os.FileInfo, io.Reader, string) error) error {
+					return walkArchive(data, format, f)
+				},
+				func(f func(string, os.FileInfo, io.Reader, string) error) error {
+					return walkArchiveReader(br, format, f)
+				},
+			} {
+				var gotEntries []archiveEntry
+				require.NoError(t, walk(func(name string, info os.FileInfo, r io.Reader, linkname string) error {
+					entry := archiveEntry{
+						name:     name,
+						mode:     info.Mode() &^ os.ModePerm,
+						linkname: linkname,
+					}
+					if r != nil {
+						contents, err := ioutil.ReadAll(r)
+						if err != nil {
+							return err
+						}
+						entry.contents = string(contents)
+					}
+					gotEntries = append(gotEntries, entry)
+					return nil
+				}))
+				assert.Equal(t, []archiveEntry{
+					{name: "dir/", mode: os.ModeDir},
+					{name: "dir/file", contents: "contents"},
+					{name: "symlink", mode: os.ModeSymlink, linkname: "target"},
+				}, gotEntries)
+			}
+		})
+	}
+}
+
+func TestDetectArchiveFormat(t *testing.T) {
+	for _, tc := range []struct {
+		data []byte
+		want string
+	}{
+		{data: nil, want: ""},
+		{data: []byte("not an archive"), want: ""},
+		{data: []byte{0x1f, 0x8b, 0x08}, want: ArchiveFormatTarGz},
+		{data: []byte("BZh91AY"), want: ArchiveFormatTarBz2},
+		{data: []byte{0xfd, '7', 'z', 'X', 'Z', 0x00, 0x00}, want: ArchiveFormatTarXz},
+		{data: []byte{0x28, 0xb5, 0x2f, 0xfd, 0x00}, want: ArchiveFormatTarZst},
+		{data: []byte("PK\x03\x04"), want: ArchiveFormatZip},
+	} {
+		assert.Equal(t, tc.want, DetectArchiveFormat(tc.data))
+	}
+}
+
+func TestGuessArchiveFormat(t *testing.T) {
+	for name, want := range map[string]string{
+		"archive":         "",
+		"archive.tar":     ArchiveFormatTar,
+		"archive.tar.bz2": ArchiveFormatTarBz2,
+		"archive.tbz2":    ArchiveFormatTarBz2,
+		"archive.tar.gz":  ArchiveFormatTarGz,
+		"archive.TGZ":     ArchiveFormatTarGz,
+		"archive.tar.xz":  ArchiveFormatTarXz,
+		"archive.txz":     ArchiveFormatTarXz,
+		"archive.tar.zst": ArchiveFormatTarZst,
+		"archive.zip":     ArchiveFormatZip,
+	} {
+		assert.Equal(t, want, GuessArchiveFormat(name), name)
+	}
+}
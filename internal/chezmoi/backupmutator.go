@@ -0,0 +1,503 @@
+package chezmoi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// Backup entry types.
+const (
+	BackupEntryTypeAbsent  = "absent"
+	BackupEntryTypeDir     = "dir"
+	BackupEntryTypeFile    = "file"
+	BackupEntryTypeMode    = "mode"
+	BackupEntryTypeSymlink = "symlink"
+)
+
+// backupManifestName is the name of the manifest in a generation directory.
+// The manifest consists of a header followed by one entry per line, so entries
+// can be appended as paths are backed up.
+const backupManifestName = "manifest.jsonl"
+
+// A backupLevel is how much of a path has been backed up.
+type backupLevel int
+
+// Backup levels.
+const (
+	backupLevelNone backupLevel = iota
+	backupLevelMode
+	backupLevelPath
+	backupLevelTree
+)
+
+// A backupManifestHeader is the first line of a manifest.
+type backupManifestHeader struct {
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// A BackupEntry records the state of a destination path before it was changed.
+type BackupEntry struct {
+	Path     string      `json:"path" yaml:"path"`
+	Type     string      `json:"type" yaml:"type"`
+	Mode     os.FileMode `json:"mode,omitempty" yaml:"mode,omitempty"`
+	Linkname string      `json:"linkname,omitempty" yaml:"linkname,omitempty"`
+	Contents string      `json:"contents,omitempty" yaml:"contents,omitempty"`
+}
+
+// A BackupGeneration records the state of all destination paths changed by a
+// single BackupMutator, in the order in which they were first changed.
+type BackupGeneration struct {
+	CreatedAt time.Time     `json:"createdAt" yaml:"createdAt"`
+	Entries   []BackupEntry `json:"entries" yaml:"entries"`
+}
+
+// A BackupMutator wraps a Mutator and backs up the state of every path in fs
+// before it is changed to a generation directory. The generation directory is
+// only created when the first path is changed.
+type BackupMutator struct {
+	m        Mutator
+	fs       vfs.FS
+	dir      string
+	started  bool
+	entries  int
+	backedUp map[string]backupLevel
+	modes    map[string]os.FileMode
+}
+
+// NewBackupMutator returns a new BackupMutator that backs up paths in fs to
+// dir.
+func NewBackupMutator(m Mutator, fs vfs.FS, dir string) *BackupMutator {
+	return &BackupMutator{
+		m:        m,
+		fs:       fs,
+		dir:      dir,
+		backedUp: make(map[string]backupLevel),
+		modes:    make(map[string]os.FileMode),
+	}
+}
+
+// BackupGenerations returns the sorted generation numbers in backupsDir.
+func BackupGenerations(fs vfs.FS, backupsDir string) ([]int, error) {
+	infos, err := fs.ReadDir(backupsDir)
+	switch {
+	case os.IsNotExist(err):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	var generations []int
+	for _, info := range infos {
+		if !info.IsDir() {
+			continue
+		}
+		generation, err := strconv.Atoi(info.Name())
+		if err != nil || generation <= 0 {
+			continue
+		}
+		generations = append(generations, generation)
+	}
+	sort.Ints(generations)
+	return generations, nil
+}
+
+// BackupGenerationDir returns the directory of generation in backupsDir.
+func BackupGenerationDir(backupsDir string, generation int) string {
+	return filepath.Join(backupsDir, strconv.Itoa(generation))
+}
+
+// NextBackupGenerationDir returns the directory of the next generation in
+// backupsDir.
+func NextBackupGenerationDir(fs vfs.FS, backupsDir string) (string, error) {
+	generations, err := BackupGenerations(fs, backupsDir)
+	if err != nil {
+		return "", err
+	}
+	next := 1
+	if len(generations) > 0 {
+		next = generations[len(generations)-1] + 1
+	}
+	return BackupGenerationDir(backupsDir, next), nil
+}
+
+// PruneBackupGenerations removes all but the newest keep generations in
+// backupsDir.
+func PruneBackupGenerations(fs vfs.FS, backupsDir string, keep int) error {
+	generations, err := BackupGenerations(fs, backupsDir)
+	if err != nil {
+		return err
+	}
+	for len(generations) > keep {
+		if err := fs.RemoveAll(BackupGenerationDir(backupsDir, generations[0])); err != nil {
+			return err
+		}
+		generations = generations[1:]
+	}
+	return nil
+}
+
+// ReadBackupGeneration reads the generation in dir.
+func ReadBackupGeneration(fs vfs.FS, dir string) (*BackupGeneration, error) {
+	data, err := fs.ReadFile(filepath.Join(dir, backupManifestName))
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	var header backupManifestHeader
+	if err := decoder.Decode(&header); err != nil {
+		return nil, err
+	}
+	generation := &BackupGeneration{
+		CreatedAt: header.CreatedAt,
+		Entries:   []BackupEntry{},
+	}
+	for {
+		var entry BackupEntry
+		switch err := decoder.Decode(&entry); {
+		case errors.Is(err, io.EOF):
+			return generation, nil
+		case err != nil:
+			return nil, err
+		}
+		generation.Entries = append(generation.Entries, entry)
+	}
+}
+
+// RestoreBackupGeneration restores the paths backed up in the generation in dir
+// using mutator.
+func RestoreBackupGeneration(fs vfs.FS, mutator Mutator, dir string) error {
+	generation, err := ReadBackupGeneration(fs, dir)
+	if err != nil {
+		return err
+	}
+
+	// Remove paths that did not exist, children before their parents.
+	for i := len(generation.Entries) - 1; i >= 0; i-- {
+		entry := generation.Entries[i]
+		if entry.Type != BackupEntryTypeAbsent {
+			continue
+		}
+		if _, err := fs.Lstat(entry.Path); os.IsNotExist(err) {
+			continue
+		}
+		if err := mutator.RemoveAll(entry.Path); err != nil {
+			return err
+		}
+	}
+
+	// Restore paths that did exist, parents before their children.
+	for _, entry := range generation.Entries {
+		info, err := fs.Lstat(entry.Path)
+		exists := err == nil
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		switch entry.Type {
+		case BackupEntryTypeMode:
+			if exists && info.Mode().Perm() != entry.Mode.Perm() {
+				if err := mutator.Chmod(entry.Path, entry.Mode.Perm()); err != nil {
+					return err
+				}
+			}
+		case BackupEntryTypeDir:
+			if exists && info.IsDir() {
+				if info.Mode().Perm() != entry.Mode.Perm() {
+					if err := mutator.Chmod(entry.Path, entry.Mode.Perm()); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if exists {
+				if err := mutator.RemoveAll(entry.Path); err != nil {
+					return err
+				}
+			}
+			if err := mutator.Mkdir(entry.Path, entry.Mode.Perm()); err != nil {
+				return err
+			}
+		case BackupEntryTypeFile:
+			contents, err := fs.ReadFile(filepath.Join(dir, entry.Contents))
+			if err != nil {
+				return err
+			}
+			var currContents []byte
+			if exists && info.Mode().IsRegular() {
+				currContents, err = fs.ReadFile(entry.Path)
+				if err != nil {
+					return err
+				}
+			} else if exists {
+				if err := mutator.RemoveAll(entry.Path); err != nil {
+					return err
+				}
+			}
+			if err := mutator.WriteFile(entry.Path, contents, entry.Mode.Perm(), currContents); err != nil {
+				return err
+			}
+		case BackupEntryTypeSymlink:
+			if exists && info.IsDir() {
+				if err := mutator.RemoveAll(entry.Path); err != nil {
+					return err
+				}
+			}
+			if err := mutator.WriteSymlink(entry.Linkname, entry.Path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Dir returns the generation directory of m.
+func (m *BackupMutator) Dir() string {
+	return m.dir
+}
+
+// Restore undoes all changes made through m using mutator. If no changes were
+// made then Restore does nothing.
+func (m *BackupMutator) Restore(mutator Mutator) error {
+	if !m.started {
+		return nil
+	}
+	return RestoreBackupGeneration(m.fs, mutator, m.dir)
+}
+
+// Chmod implements Mutator.Chmod.
+func (m *BackupMutator) Chmod(name string, mode os.FileMode) error {
+	if err := m.backupMode(name); err != nil {
+		return err
+	}
+	return m.m.Chmod(name, mode)
+}
+
+// IdempotentCmdOutput implements Mutator.IdempotentCmdOutput.
+func (m *BackupMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
+	return m.m.IdempotentCmdOutput(cmd)
+}
+
+// Mkdir implements Mutator.Mkdir.
+func (m *BackupMutator) Mkdir(name string, perm os.FileMode) error {
+	if err := m.backupPath(name); err != nil {
+		return err
+	}
+	return m.m.Mkdir(name, perm)
+}
+
+// RemoveAll implements Mutator.RemoveAll.
+func (m *BackupMutator) RemoveAll(name string) error {
+	if err := m.backupTree(name); err != nil {
+		return err
+	}
+	return m.m.RemoveAll(name)
+}
+
+// Rename implements Mutator.Rename.
+func (m *BackupMutator) Rename(oldpath, newpath string) error {
+	if err := m.backupTree(oldpath); err != nil {
+		return err
+	}
+	if err := m.backupPath(newpath); err != nil {
+		return err
+	}
+	return m.m.Rename(oldpath, newpath)
+}
+
+// RecordScript implements ScriptRecorder.RecordScript.
+func (m *BackupMutator) RecordScript(scriptname, dir string, data []byte, stateUpdate *ScriptStateUpdate) error {
+	return recordScript(m.m, scriptname, dir, data, stateUpdate)
+}
+
+// RunCmd implements Mutator.RunCmd.
+func (m *BackupMutator) RunCmd(cmd *exec.Cmd) error {
+	return m.m.RunCmd(cmd)
+}
+
+// RunScript implements Mutator.RunScript.
+func (m *BackupMutator) RunScript(scriptname, dir string, data []byte) error {
+	return m.m.RunScript(scriptname, dir, data)
+}
+
+// Stat implements Mutator.Stat.
+func (m *BackupMutator) Stat(name string) (os.FileInfo, error) {
+	return m.m.Stat(name)
+}
+
+// WriteFile implements Mutator.WriteFile.
+func (m *BackupMutator) WriteFile(name string, data []byte, perm os.FileMode, currData []byte) error {
+	if err := m.backupPath(name); err != nil {
+		return err
+	}
+	return m.m.WriteFile(name, data, perm, currData)
+}
+
+// WriteSymlink implements Mutator.WriteSymlink.
+func (m *BackupMutator) WriteSymlink(oldname, newname string) error {
+	if err := m.backupPath(newname); err != nil {
+		return err
+	}
+	return m.m.WriteSymlink(oldname, newname)
+}
+
+// backupMode backs up the mode of name, if it has not already been backed up.
+func (m *BackupMutator) backupMode(name string) error {
+	return m.backup(name, backupLevelMode)
+}
+
+// backupPath backs up name but not its children, if it has not already been
+// backed up.
+func (m *BackupMutator) backupPath(name string) error {
+	return m.backup(name, backupLevelPath)
+}
+
+// backupTree backs up name and, if name is a directory, all of its children,
+// if they have not already been backed up.
+func (m *BackupMutator) backupTree(name string) error {
+	info, err := m.fs.Lstat(name)
+	switch {
+	case os.IsNotExist(err):
+		return m.backup(name, backupLevelTree)
+	case err != nil:
+		return err
+	case !info.IsDir():
+		return m.backup(name, backupLevelTree)
+	}
+	return vfs.Walk(m.fs, name, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if m.backedUp[path] >= backupLevelTree {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return m.backup(path, backupLevelTree)
+	})
+}
+
+// backup backs up name to level, if it has not already been backed up to at
+// least level. Only directories distinguish between backupLevelPath and
+// backupLevelTree.
+func (m *BackupMutator) backup(name string, level backupLevel) error {
+	if m.backedUp[name] >= level {
+		return nil
+	}
+	if !m.started {
+		if err := m.startGeneration(); err != nil {
+			return err
+		}
+	}
+
+	info, err := m.fs.Lstat(name)
+	switch {
+	case os.IsNotExist(err):
+		m.backedUp[name] = backupLevelTree
+		return m.appendEntry(&BackupEntry{
+			Path: name,
+			Type: BackupEntryTypeAbsent,
+		})
+	case err != nil:
+		return err
+	}
+
+	prevLevel := m.backedUp[name]
+	if !info.IsDir() && level > backupLevelMode {
+		level = backupLevelTree
+	}
+	m.backedUp[name] = level
+
+	entry := &BackupEntry{
+		Path: name,
+		Mode: info.Mode().Perm(),
+	}
+	// If only the mode was backed up previously then it may since have
+	// changed, so use the original mode.
+	if prevLevel == backupLevelMode {
+		entry.Mode = m.modes[name]
+	}
+	switch {
+	case level == backupLevelMode:
+		m.modes[name] = entry.Mode
+		entry.Type = BackupEntryTypeMode
+	case info.IsDir():
+		// The directory itself has already been recorded, only its children
+		// remain to be backed up.
+		if prevLevel >= backupLevelPath {
+			return nil
+		}
+		entry.Type = BackupEntryTypeDir
+	case info.Mode().IsRegular():
+		contents, err := m.fs.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		entry.Type = BackupEntryTypeFile
+		entry.Contents = strconv.Itoa(m.entries)
+		if err := m.fs.WriteFile(filepath.Join(m.dir, entry.Contents), contents, 0o600); err != nil {
+			return err
+		}
+	case info.Mode()&os.ModeType == os.ModeSymlink:
+		linkname, err := m.fs.Readlink(name)
+		if err != nil {
+			return err
+		}
+		entry.Type = BackupEntryTypeSymlink
+		entry.Mode = 0
+		entry.Linkname = linkname
+	default:
+		// Other file types cannot be restored, so do not back them up.
+		return nil
+	}
+	return m.appendEntry(entry)
+}
+
+// startGeneration creates m's generation directory and writes the header of
+// its manifest.
+func (m *BackupMutator) startGeneration() error {
+	if err := vfs.MkdirAll(m.fs, m.dir, 0o700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(backupManifestHeader{
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := m.fs.WriteFile(filepath.Join(m.dir, backupManifestName), append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	m.started = true
+	return nil
+}
+
+// appendEntry appends entry to m's manifest.
+func (m *BackupMutator) appendEntry(entry *BackupEntry) (err error) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := m.fs.OpenFile(filepath.Join(m.dir, backupManifestName), os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	if _, err = f.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	m.entries++
+	return nil
+}
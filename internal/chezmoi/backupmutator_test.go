@@ -0,0 +1,175 @@
+package chezmoi
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+var _ Mutator = &BackupMutator{}
+
+func TestBackupMutator(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".bashrc": &vfst.File{
+				Perm:     0o600,
+				Contents: []byte("# contents of .bashrc\n"),
+			},
+			"dir": map[string]interface{}{
+				"file": "# contents of dir/file\n",
+			},
+			"symlink": &vfst.Symlink{Target: ".bashrc"},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	backupsDir := "/home/user/.local/state/chezmoi/backups"
+	generations, err := BackupGenerations(fs, backupsDir)
+	require.NoError(t, err)
+	assert.Empty(t, generations)
+	dir, err := NextBackupGenerationDir(fs, backupsDir)
+	require.NoError(t, err)
+	assert.Equal(t, BackupGenerationDir(backupsDir, 1), dir)
+
+	m := NewBackupMutator(NewFSMutator(fs), fs, dir)
+	require.NoError(t, m.WriteFile("/home/user/.bashrc", []byte("# new contents of .bashrc\n"), 0o644, nil))
+	require.NoError(t, m.WriteFile("/home/user/.bashrc", []byte("# newer contents of .bashrc\n"), 0o644, nil))
+	require.NoError(t, m.RemoveAll("/home/user/dir"))
+	require.NoError(t, m.WriteSymlink("dir", "/home/user/symlink"))
+	require.NoError(t, m.Mkdir("/home/user/newdir", 0o755))
+	require.NoError(t, m.WriteFile("/home/user/newdir/file", []byte("# contents of newdir/file\n"), 0o644, nil))
+
+	generations, err = BackupGenerations(fs, backupsDir)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, generations)
+	generation, err := ReadBackupGeneration(fs, dir)
+	require.NoError(t, err)
+	var types []string
+	for _, entry := range generation.Entries {
+		types = append(types, entry.Type)
+	}
+	assert.Equal(t, []string{
+		BackupEntryTypeFile,
+		BackupEntryTypeDir,
+		BackupEntryTypeFile,
+		BackupEntryTypeSymlink,
+		BackupEntryTypeAbsent,
+		BackupEntryTypeAbsent,
+	}, types)
+
+	require.NoError(t, RestoreBackupGeneration(fs, NewFSMutator(fs), dir))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestModeIsRegular,
+			vfst.TestModePerm(0o600),
+			vfst.TestContentsString("# contents of .bashrc\n"),
+		),
+		vfst.TestPath("/home/user/dir",
+			vfst.TestIsDir,
+		),
+		vfst.TestPath("/home/user/dir/file",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("# contents of dir/file\n"),
+		),
+		vfst.TestPath("/home/user/symlink",
+			vfst.TestModeType(os.ModeSymlink),
+			vfst.TestSymlinkTarget(".bashrc"),
+		),
+		vfst.TestPath("/home/user/newdir",
+			vfst.TestDoesNotExist,
+		),
+	)
+}
+
+func TestBackupMutatorChmod(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".config": &vfst.Dir{
+				Perm: 0o755,
+				Entries: map[string]interface{}{
+					"file": "# contents of .config/file\n",
+				},
+			},
+			".bashrc": &vfst.File{
+				Perm:     0o644,
+				Contents: []byte("# contents of .bashrc\n"),
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	dir := BackupGenerationDir("/home/user/.local/state/chezmoi/backups", 1)
+	m := NewBackupMutator(NewFSMutator(fs), fs, dir)
+	require.NoError(t, m.Chmod("/home/user/.config", 0o700))
+	require.NoError(t, m.Chmod("/home/user/.bashrc", 0o600))
+	require.NoError(t, m.WriteFile("/home/user/.bashrc", []byte("# new contents of .bashrc\n"), 0o600, nil))
+
+	generation, err := ReadBackupGeneration(fs, dir)
+	require.NoError(t, err)
+	assert.Equal(t, []BackupEntry{
+		{
+			Path: "/home/user/.config",
+			Type: BackupEntryTypeMode,
+			Mode: 0o755,
+		},
+		{
+			Path: "/home/user/.bashrc",
+			Type: BackupEntryTypeMode,
+			Mode: 0o644,
+		},
+		{
+			Path:     "/home/user/.bashrc",
+			Type:     BackupEntryTypeFile,
+			Mode:     0o644,
+			Contents: "2",
+		},
+	}, generation.Entries)
+
+	require.NoError(t, m.Restore(NewFSMutator(fs)))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.config",
+			vfst.TestIsDir,
+			vfst.TestModePerm(0o755),
+		),
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestModeIsRegular,
+			vfst.TestModePerm(0o644),
+			vfst.TestContentsString("# contents of .bashrc\n"),
+		),
+	)
+}
+
+func TestPruneBackupGenerations(t *testing.T) {
+	backupsDir := "/home/user/.local/state/chezmoi/backups"
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		backupsDir: map[string]interface{}{
+			"1":    &vfst.Dir{Perm: 0o700},
+			"2":    &vfst.Dir{Perm: 0o700},
+			"3":    &vfst.Dir{Perm: 0o700},
+			"10":   &vfst.Dir{Perm: 0o700},
+			"file": "",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	require.NoError(t, PruneBackupGenerations(fs, backupsDir, 5))
+	generations, err := BackupGenerations(fs, backupsDir)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3, 10}, generations)
+
+	require.NoError(t, PruneBackupGenerations(fs, backupsDir, 2))
+	generations, err = BackupGenerations(fs, backupsDir)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 10}, generations)
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath(backupsDir+"/file",
+			vfst.TestModeIsRegular,
+		),
+	)
+}
@@ -63,6 +63,35 @@ func (b *BoltPersistentState) Delete(bucket, key []byte) error {
 	})
 }
 
+// DeleteBucket deletes bucket and all of its keys. If bucket does not exist
+// then DeleteBucket does nothing.
+func (b *BoltPersistentState) DeleteBucket(bucket []byte) error {
+	if b.db == nil {
+		return nil
+	}
+	return b.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(bucket) == nil {
+			return nil
+		}
+		return tx.DeleteBucket(bucket)
+	})
+}
+
+// ForEach calls fn for each key and value in bucket, in key order. If bucket
+// does not exist then ForEach does nothing.
+func (b *BoltPersistentState) ForEach(bucket []byte, fn func(k, v []byte) error) error {
+	if b.db == nil {
+		return nil
+	}
+	return b.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(fn)
+	})
+}
+
 // Get returns the value associated with key in bucket.
 func (b *BoltPersistentState) Get(bucket, key []byte) ([]byte, error) {
 	var value []byte
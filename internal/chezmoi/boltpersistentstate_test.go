@@ -1,6 +1,7 @@
 package chezmoi
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -73,6 +74,39 @@ func TestBoltPersistentState(t *testing.T) {
 	assert.Equal(t, []byte(nil), actualValue)
 }
 
+func TestBoltPersistentStateForEachAndDeleteBucket(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.config/chezmoi": &vfst.Dir{Perm: 0o755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	path := "/home/user/.config/chezmoi/chezmoistate.boltdb"
+	b, err := NewBoltPersistentState(fs, path, nil)
+	require.NoError(t, err)
+	defer b.Close()
+
+	bucket := []byte("bucket")
+	require.NoError(t, b.ForEach(bucket, func(k, v []byte) error {
+		return errors.New("unexpected key")
+	}))
+	require.NoError(t, b.DeleteBucket(bucket))
+
+	require.NoError(t, b.Set(bucket, []byte("b"), []byte("2")))
+	require.NoError(t, b.Set(bucket, []byte("a"), []byte("1")))
+	var keyValues []string
+	require.NoError(t, b.ForEach(bucket, func(k, v []byte) error {
+		keyValues = append(keyValues, string(k)+"="+string(v))
+		return nil
+	}))
+	assert.Equal(t, []string{"a=1", "b=2"}, keyValues)
+
+	require.NoError(t, b.DeleteBucket(bucket))
+	actualValue, err := b.Get(bucket, []byte("a"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte(nil), actualValue)
+}
+
 func TestBoltPersistentStateReadOnly(t *testing.T) {
 	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
 		"/home/user/.config/chezmoi": &vfst.Dir{Perm: 0o755},
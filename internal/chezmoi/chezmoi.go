@@ -1,9 +1,10 @@
 package chezmoi
 
 import (
-	"archive/tar"
 	"bytes"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
@@ -14,13 +15,19 @@ import (
 
 // Suffixes and prefixes.
 const (
+	afterPrefix      = "after_"
+	beforePrefix     = "before_"
+	createPrefix     = "create_"
 	dotPrefix        = "dot_"
 	emptyPrefix      = "empty_"
 	encryptedPrefix  = "encrypted_"
 	exactPrefix      = "exact_"
 	executablePrefix = "executable_"
+	modifyPrefix     = "modify_"
+	onChangePrefix   = "onchange_"
 	oncePrefix       = "once_"
 	privatePrefix    = "private_"
+	removePrefix     = "remove_"
 	runPrefix        = "run_"
 	symlinkPrefix    = "symlink_"
 	TemplateSuffix   = ".tmpl"
@@ -30,6 +37,8 @@ const (
 type PersistentState interface {
 	Close() error
 	Delete(bucket, key []byte) error
+	DeleteBucket(bucket []byte) error
+	ForEach(bucket []byte, fn func(k, v []byte) error) error
 	Get(bucket, key []byte) ([]byte, error)
 	Set(bucket, key, value []byte) error
 }
@@ -38,7 +47,7 @@ type PersistentState interface {
 type ApplyOptions struct {
 	DestDir           string
 	DryRun            bool
-	Ignore            func(string) bool
+	Ignore            func(string, bool) bool
 	PersistentState   PersistentState
 	Remove            bool
 	ScriptStateBucket []byte
@@ -47,23 +56,79 @@ type ApplyOptions struct {
 	Verbose           bool
 }
 
-// An Entry is either a Dir, a File, or a Symlink.
+// An Entry is either a Dir, a File, a Remove, a Script, or a Symlink.
 type Entry interface {
 	AppendAllEntries(allEntries []Entry) []Entry
 	Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions) error
-	ConcreteValue(ignore func(string) bool, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error)
-	Evaluate(ignore func(string) bool) error
+	ConcreteValue(ignore func(string, bool) bool, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error)
+	Evaluate(ignore func(string, bool) bool) error
 	SourceName() string
 	TargetName() string
-	archive(w *tar.Writer, ignore func(string) bool, headerTemplate *tar.Header, umask os.FileMode) error
+	archive(w ArchiveWriter, ignore func(string, bool) bool, umask os.FileMode) error
+}
+
+// An ExternalEntryError is returned when an operation needs the source file of
+// an entry that was created from an external, and so has no source file of its
+// own.
+type ExternalEntryError struct {
+	TargetName   string
+	ManifestName string
+}
+
+func (e *ExternalEntryError) Error() string {
+	return fmt.Sprintf("%s: from external in %s, edit the external instead", e.TargetName, e.ManifestName)
+}
+
+// CheckSourceFile returns an *ExternalEntryError if entry was created from an
+// external.
+func CheckSourceFile(entry Entry) error {
+	var external bool
+	switch entry := entry.(type) {
+	case *Dir:
+		external = entry.external
+	case *File:
+		external = entry.external
+	case *Symlink:
+		external = entry.external
+	}
+	if !external {
+		return nil
+	}
+	return &ExternalEntryError{
+		TargetName:   entry.TargetName(),
+		ManifestName: entry.SourceName(),
+	}
 }
 
 type parsedSourceFilePath struct {
 	dirAttributes    []DirAttributes
 	fileAttributes   *FileAttributes
+	removeAttributes *RemoveAttributes
 	scriptAttributes *ScriptAttributes
 }
 
+// SourcePathTargetName returns the target name of the source file path path,
+// which is relative to the source directory, and whether path corresponds to a
+// target at all. Scripts and paths with any component beginning with a . do
+// not correspond to targets.
+func SourcePathTargetName(path, encryptedSuffix string) (string, bool) {
+	for _, component := range splitPathList(path) {
+		if strings.HasPrefix(component, ".") {
+			return "", false
+		}
+	}
+	psfp := parseSourceFilePath(path, encryptedSuffix)
+	dns := dirNames(psfp.dirAttributes)
+	switch {
+	case psfp.fileAttributes != nil:
+		return filepath.Join(append(dns, psfp.fileAttributes.Name)...), true
+	case psfp.removeAttributes != nil:
+		return filepath.Join(append(dns, psfp.removeAttributes.Name)...), true
+	default:
+		return "", false
+	}
+}
+
 // dirNames returns the dir names from dirAttributes.
 func dirNames(dirAttributes []DirAttributes) []string {
 	dns := make([]string, len(dirAttributes))
@@ -89,24 +154,40 @@ func parseDirNameComponents(components []string) []DirAttributes {
 }
 
 // parseSourceFilePath parses a single source file path.
-func parseSourceFilePath(path string) parsedSourceFilePath {
+func parseSourceFilePath(path, encryptedSuffix string) parsedSourceFilePath {
 	components := splitPathList(path)
 	das := parseDirNameComponents(components[0 : len(components)-1])
 	sourceName := components[len(components)-1]
-	if strings.HasPrefix(sourceName, runPrefix) {
-		sa := ParseScriptAttributes(sourceName)
+	switch {
+	case strings.HasPrefix(sourceName, removePrefix):
+		ra := ParseRemoveAttributes(sourceName)
+		return parsedSourceFilePath{
+			dirAttributes:    das,
+			removeAttributes: &ra,
+		}
+	case strings.HasPrefix(sourceName, runPrefix):
+		sa := ParseScriptAttributes(sourceName, encryptedSuffix)
 		return parsedSourceFilePath{
 			dirAttributes:    das,
 			scriptAttributes: &sa,
 		}
 	}
-	fa := ParseFileAttributes(components[len(components)-1])
+	fa := ParseFileAttributes(components[len(components)-1], encryptedSuffix)
 	return parsedSourceFilePath{
 		dirAttributes:  das,
 		fileAttributes: &fa,
 	}
 }
 
+// sortedEntries returns a slice of all entries, sorted by name.
+func sortedEntries(entries map[string]Entry) []Entry {
+	sortedEntries := make([]Entry, 0, len(entries))
+	for _, entryName := range sortedEntryNames(entries) {
+		sortedEntries = append(sortedEntries, entries[entryName])
+	}
+	return sortedEntries
+}
+
 // sortedEntryNames returns a sorted slice of all entry names.
 func sortedEntryNames(entries map[string]Entry) []string {
 	entryNames := []string{}
@@ -123,3 +204,29 @@ func splitPathList(path string) []string {
 	}
 	return strings.Split(path, string(filepath.Separator))
 }
+
+// writeTempScript writes data to a new executable temporary file and returns
+// its path. The caller is responsible for removing it.
+func writeTempScript(scriptname string, data []byte) (string, error) {
+	// Put the randomness on the front of the filename to preserve any file
+	// extension for Windows scripts.
+	f, err := ioutil.TempFile("", "*."+filepath.Base(scriptname))
+	if err != nil {
+		return "", err
+	}
+	if err := os.Chmod(f.Name(), 0o700); err != nil {
+		_ = f.Close()
+		_ = os.RemoveAll(f.Name())
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.RemoveAll(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.RemoveAll(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
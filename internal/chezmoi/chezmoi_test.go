@@ -2,11 +2,35 @@ package chezmoi
 
 import (
 	"errors"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
+// A testEncryption is an Encryption that reverses its input, for testing.
+type testEncryption struct{}
+
+func (testEncryption) Decrypt(filename string, ciphertext []byte) ([]byte, error) {
+	return reverseBytes(ciphertext), nil
+}
+
+func (testEncryption) Encrypt(filename string, plaintext []byte) ([]byte, error) {
+	return reverseBytes(plaintext), nil
+}
+
+func (testEncryption) EncryptedSuffix() string {
+	return ".rev"
+}
+
+func reverseBytes(b []byte) []byte {
+	result := make([]byte, len(b))
+	for i, c := range b {
+		result[len(b)-1-i] = c
+	}
+	return result
+}
+
 func TestReturnTemplateError(t *testing.T) {
 	funcs := map[string]interface{}{
 		"returnTemplateError": func() string {
@@ -30,3 +54,52 @@ func TestReturnTemplateError(t *testing.T) {
 		})
 	}
 }
+
+func TestSourcePathTargetName(t *testing.T) {
+	for _, tc := range []struct {
+		path               string
+		expectedTargetName string
+		expectedOK         bool
+	}{
+		{
+			path:               "dot_zshrc",
+			expectedTargetName: ".zshrc",
+			expectedOK:         true,
+		},
+		{
+			path:               filepath.Join("private_dot_config", "starship.toml.tmpl"),
+			expectedTargetName: filepath.Join(".config", "starship.toml"),
+			expectedOK:         true,
+		},
+		{
+			path:               filepath.Join("exact_dot_ssh", "encrypted_private_id_rsa.rev"),
+			expectedTargetName: filepath.Join(".ssh", "id_rsa"),
+			expectedOK:         true,
+		},
+		{
+			path:               "remove_dot_oldrc",
+			expectedTargetName: ".oldrc",
+			expectedOK:         true,
+		},
+		{
+			path:               "symlink_dot_vimrc",
+			expectedTargetName: ".vimrc",
+			expectedOK:         true,
+		},
+		{
+			path: "run_once_install-packages.sh",
+		},
+		{
+			path: ".chezmoiignore",
+		},
+		{
+			path: filepath.Join(".chezmoitemplates", "part"),
+		},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			actualTargetName, actualOK := SourcePathTargetName(tc.path, ".rev")
+			assert.Equal(t, tc.expectedTargetName, actualTargetName)
+			assert.Equal(t, tc.expectedOK, actualOK)
+		})
+	}
+}
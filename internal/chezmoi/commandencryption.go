@@ -0,0 +1,55 @@
+package chezmoi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// A CommandEncryption encrypts and decrypts files by piping their contents
+// through arbitrary external commands, for example sops or openssl. The
+// commands read their input from stdin and write their output to stdout.
+type CommandEncryption struct {
+	DecryptCommand string
+	DecryptArgs    []string
+	EncryptCommand string
+	EncryptArgs    []string
+	Suffix         string
+}
+
+// Decrypt implements Encryption.Decrypt.
+func (e *CommandEncryption) Decrypt(filename string, ciphertext []byte) ([]byte, error) {
+	if e.DecryptCommand == "" {
+		return nil, errors.New("encryptionCommand.decryptCommand not set")
+	}
+	return e.run(filename, e.DecryptCommand, e.DecryptArgs, ciphertext)
+}
+
+// Encrypt implements Encryption.Encrypt.
+func (e *CommandEncryption) Encrypt(filename string, plaintext []byte) ([]byte, error) {
+	if e.EncryptCommand == "" {
+		return nil, errors.New("encryptionCommand.encryptCommand not set")
+	}
+	return e.run(filename, e.EncryptCommand, e.EncryptArgs, plaintext)
+}
+
+// EncryptedSuffix implements Encryption.EncryptedSuffix.
+func (e *CommandEncryption) EncryptedSuffix() string {
+	return e.Suffix
+}
+
+// run runs name with args, writing input to its stdin and returning its
+// stdout.
+func (e *CommandEncryption) run(filename, name string, args []string, input []byte) ([]byte, error) {
+	//nolint:gosec
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s: %w", filename, name, err)
+	}
+	return output, nil
+}
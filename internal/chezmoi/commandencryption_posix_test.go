@@ -0,0 +1,63 @@
+// +build !windows
+
+package chezmoi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestCommandEncryption(t *testing.T) {
+	rot13 := []string{"a-zA-Z", "n-za-mN-ZA-M"}
+	e := &CommandEncryption{
+		DecryptCommand: "tr",
+		DecryptArgs:    rot13,
+		EncryptCommand: "tr",
+		EncryptArgs:    rot13,
+		Suffix:         ".rot13",
+	}
+
+	ciphertext, err := e.Encrypt(".netrc", []byte("# contents of .netrc\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("# pbagragf bs .argep\n"), ciphertext)
+	plaintext, err := e.Decrypt(".netrc", ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("# contents of .netrc\n"), plaintext)
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".local/share/chezmoi/encrypted_dot_netrc.rot13": "# pbagragf bs .argep\n",
+			".bashrc": "# contents of .bashrc\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+	ts := NewTargetState(
+		WithDestDir("/home/user"),
+		WithEncryption(e),
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+	)
+	require.NoError(t, ts.Populate(fs, nil))
+
+	entry, err := ts.Get(fs, "/home/user/.netrc")
+	require.NoError(t, err)
+	contents, err := entry.(*File).Contents()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("# contents of .netrc\n"), contents)
+
+	require.NoError(t, ts.Add(fs, AddOptions{Encrypt: true}, "/home/user/.bashrc", nil, false, NewFSMutator(fs)))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/share/chezmoi/encrypted_dot_bashrc.rot13",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("# pbagragf bs .onfuep\n"),
+		),
+	)
+
+	// Adding the same file again does not change the source state.
+	anyMutator := NewAnyMutator(NullMutator{})
+	require.NoError(t, ts.Add(fs, AddOptions{Encrypt: true}, "/home/user/.bashrc", nil, false, anyMutator))
+	assert.False(t, anyMutator.Mutated())
+}
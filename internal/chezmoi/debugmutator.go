@@ -59,6 +59,13 @@ func (m *DebugMutator) Rename(oldpath, newpath string) error {
 	})
 }
 
+// RecordScript implements ScriptRecorder.RecordScript.
+func (m *DebugMutator) RecordScript(scriptname, dir string, data []byte, stateUpdate *ScriptStateUpdate) error {
+	return Debugf("RecordScript(%q, %q)", []interface{}{scriptname, dir}, func() error {
+		return recordScript(m.m, scriptname, dir, data, stateUpdate)
+	})
+}
+
 // RunCmd implements Mutator.RunCmd.
 func (m *DebugMutator) RunCmd(cmd *exec.Cmd) error {
 	cmdStr := ShellQuoteArgs(append([]string{cmd.Path}, cmd.Args[1:]...))
@@ -67,6 +74,13 @@ func (m *DebugMutator) RunCmd(cmd *exec.Cmd) error {
 	})
 }
 
+// RunScript implements Mutator.RunScript.
+func (m *DebugMutator) RunScript(scriptname, dir string, data []byte) error {
+	return Debugf("RunScript(%q, %q)", []interface{}{scriptname, dir}, func() error {
+		return m.m.RunScript(scriptname, dir, data)
+	})
+}
+
 // Stat implements Mutator.Stat.
 func (m *DebugMutator) Stat(name string) (os.FileInfo, error) {
 	var fi os.FileInfo
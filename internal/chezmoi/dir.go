@@ -1,7 +1,6 @@
 package chezmoi
 
 import (
-	"archive/tar"
 	"os"
 	"path/filepath"
 	"strings"
@@ -20,6 +19,7 @@ type DirAttributes struct {
 type Dir struct {
 	sourceName string
 	targetName string
+	external   bool
 	Exact      bool
 	Perm       os.FileMode
 	Entries    map[string]Entry
@@ -96,7 +96,7 @@ func (d *Dir) AppendAllEntries(allEntries []Entry) []Entry {
 
 // Apply ensures that destDir in fs matches d.
 func (d *Dir) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions) error {
-	if applyOptions.Ignore(d.targetName) {
+	if applyOptions.Ignore(d.targetName, true) {
 		return nil
 	}
 	targetPath := filepath.Join(applyOptions.DestDir, d.targetName)
@@ -127,7 +127,13 @@ func (d *Dir) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Apply
 		return err
 	}
 	for _, entryName := range sortedEntryNames(d.Entries) {
-		if err := d.Entries[entryName].Apply(fs, mutator, follow, applyOptions); err != nil {
+		entry := d.Entries[entryName]
+		// Scripts that run before or after all other entries are run by
+		// TargetState.Apply.
+		if isBeforeOrAfterScript(entry) {
+			continue
+		}
+		if err := entry.Apply(fs, mutator, follow, applyOptions); err != nil {
 			return err
 		}
 	}
@@ -139,7 +145,7 @@ func (d *Dir) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Apply
 		for _, info := range infos {
 			name := info.Name()
 			if _, ok := d.Entries[name]; !ok {
-				if applyOptions.Ignore(filepath.Join(d.targetName, name)) {
+				if applyOptions.Ignore(filepath.Join(d.targetName, name), info.IsDir()) {
 					continue
 				}
 				if err := mutator.RemoveAll(filepath.Join(targetPath, name)); err != nil {
@@ -152,8 +158,8 @@ func (d *Dir) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Apply
 }
 
 // ConcreteValue implements Entry.ConcreteValue.
-func (d *Dir) ConcreteValue(ignore func(string) bool, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error) {
-	if ignore(d.targetName) {
+func (d *Dir) ConcreteValue(ignore func(string, bool) bool, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error) {
+	if ignore(d.targetName, true) {
 		return nil, nil
 	}
 	var entryConcreteValues []interface{}
@@ -179,8 +185,8 @@ func (d *Dir) ConcreteValue(ignore func(string) bool, sourceDir string, umask os
 }
 
 // Evaluate evaluates all entries in d.
-func (d *Dir) Evaluate(ignore func(string) bool) error {
-	if ignore(d.targetName) {
+func (d *Dir) Evaluate(ignore func(string, bool) bool) error {
+	if ignore(d.targetName, true) {
 		return nil
 	}
 	for _, entryName := range sortedEntryNames(d.Entries) {
@@ -207,19 +213,15 @@ func (d *Dir) TargetName() string {
 }
 
 // archive writes d to w.
-func (d *Dir) archive(w *tar.Writer, ignore func(string) bool, headerTemplate *tar.Header, umask os.FileMode) error {
-	if ignore(d.targetName) {
+func (d *Dir) archive(w ArchiveWriter, ignore func(string, bool) bool, umask os.FileMode) error {
+	if ignore(d.targetName, true) {
 		return nil
 	}
-	header := *headerTemplate
-	header.Typeflag = tar.TypeDir
-	header.Name = d.targetName + "/"
-	header.Mode = int64(d.Perm &^ umask)
-	if err := w.WriteHeader(&header); err != nil {
+	if err := w.WriteDir(d.targetName, d.Perm&^umask); err != nil {
 		return err
 	}
 	for _, entryName := range sortedEntryNames(d.Entries) {
-		if err := d.Entries[entryName].archive(w, ignore, headerTemplate, umask); err != nil {
+		if err := d.Entries[entryName].archive(w, ignore, umask); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,8 @@
+package chezmoi
+
+// An Encryption encrypts and decrypts the contents of encrypted files.
+type Encryption interface {
+	Decrypt(filename string, ciphertext []byte) ([]byte, error)
+	Encrypt(filename string, plaintext []byte) ([]byte, error)
+	EncryptedSuffix() string
+}
@@ -0,0 +1,364 @@
+package chezmoi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v2"
+	"github.com/pelletier/go-toml"
+	vfs "github.com/twpayne/go-vfs"
+	"gopkg.in/yaml.v2"
+)
+
+// External types.
+const (
+	ExternalTypeArchive = "archive"
+	ExternalTypeFile    = "file"
+)
+
+const externalName = ".chezmoiexternal"
+
+// externalHTTPClient is the HTTP client used to download externals. It has a
+// timeout so that a slow host cannot hang every command that reads the target
+// state.
+var externalHTTPClient = &http.Client{
+	Timeout: time.Minute,
+}
+
+// errExternalNotCached is returned by readExternal when it is not allowed to
+// download an external that is not cached.
+var errExternalNotCached = errors.New("not cached")
+
+// An External is a target whose contents are downloaded from a URL.
+type External struct {
+	Type            string   `json:"type" toml:"type" yaml:"type"`
+	URL             string   `json:"url" toml:"url" yaml:"url"`
+	Executable      bool     `json:"executable" toml:"executable" yaml:"executable"`
+	Exact           bool     `json:"exact" toml:"exact" yaml:"exact"`
+	Format          string   `json:"format" toml:"format" yaml:"format"`
+	StripComponents int      `json:"stripComponents" toml:"stripComponents" yaml:"stripComponents"`
+	Include         []string `json:"include" toml:"include" yaml:"include"`
+	Exclude         []string `json:"exclude" toml:"exclude" yaml:"exclude"`
+	RefreshPeriod   string   `json:"refreshPeriod" toml:"refreshPeriod" yaml:"refreshPeriod"`
+	refreshPeriod   time.Duration
+}
+
+// An externalManifest is a parsed .chezmoiexternal file.
+type externalManifest struct {
+	sourceName string
+	dirNames   []string
+	externals  map[string]*External
+}
+
+// isExternalName returns true if name is the name of an external manifest.
+func isExternalName(name string) bool {
+	return strings.HasPrefix(name, externalName+".")
+}
+
+// parseExternalManifest parses the external manifest data with sourceName.
+func parseExternalManifest(sourceName string, data []byte) (map[string]*External, error) {
+	name := strings.TrimSuffix(filepath.Base(sourceName), TemplateSuffix)
+	externals := make(map[string]*External)
+	var err error
+	switch format := strings.TrimPrefix(name, externalName+"."); format {
+	case "json":
+		err = json.Unmarshal(data, &externals)
+	case "toml":
+		err = toml.Unmarshal(data, &externals)
+	case "yaml":
+		err = yaml.Unmarshal(data, &externals)
+	default:
+		return nil, fmt.Errorf("%s: unknown format", sourceName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", sourceName, err)
+	}
+	for targetName, external := range externals {
+		if err := external.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", sourceName, targetName, err)
+		}
+	}
+	return externals, nil
+}
+
+// validate validates e and sets its unexported fields.
+func (e *External) validate() error {
+	switch e.Type {
+	case ExternalTypeArchive:
+		if e.Format == "" {
+			u, err := url.Parse(e.URL)
+			if err != nil {
+				return err
+			}
+			e.Format = GuessArchiveFormat(u.Path)
+		} else if !IsArchiveFormat(e.Format) {
+			return fmt.Errorf("%s: unknown archive format", e.Format)
+		}
+	case ExternalTypeFile:
+	default:
+		return fmt.Errorf("%s: unknown type", e.Type)
+	}
+	if e.URL == "" {
+		return errors.New("missing url")
+	}
+	if e.StripComponents < 0 {
+		return fmt.Errorf("%d: invalid stripComponents", e.StripComponents)
+	}
+	for _, pattern := range append(append([]string{}, e.Include...), e.Exclude...) {
+		if _, err := doublestar.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%s: %w", pattern, err)
+		}
+	}
+	if e.RefreshPeriod != "" {
+		refreshPeriod, err := time.ParseDuration(e.RefreshPeriod)
+		if err != nil {
+			return err
+		}
+		e.refreshPeriod = refreshPeriod
+	}
+	return nil
+}
+
+// match returns true if name, relative to e's target, should be included.
+func (e *External) match(name string) bool {
+	for _, pattern := range e.Exclude {
+		if ok, _ := doublestar.Match(pattern, name); ok {
+			return false
+		}
+	}
+	if len(e.Include) == 0 {
+		return true
+	}
+	for _, pattern := range e.Include {
+		if ok, _ := doublestar.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// addExternals adds the externals in em to ts. Entries already in ts take
+// precedence over entries from externals. If refresh is false then cached
+// externals are used even if they are stale. If cachedOnly is true then
+// nothing is downloaded and externals that are not cached are skipped.
+func (ts *TargetState) addExternals(em *externalManifest, refresh, cachedOnly bool) error {
+	for _, name := range sortedExternalNames(em.externals) {
+		external := em.externals[name]
+		targetName := filepath.Join(append(append([]string{}, em.dirNames...), filepath.FromSlash(name))...)
+		data, err := ts.readExternal(external, refresh, cachedOnly)
+		switch {
+		case errors.Is(err, errExternalNotCached):
+			continue
+		case err != nil:
+			return fmt.Errorf("%s: %w", targetName, err)
+		}
+		switch external.Type {
+		case ExternalTypeArchive:
+			err = ts.addExternalArchive(em.sourceName, targetName, external, data)
+		case ExternalTypeFile:
+			err = ts.addExternalFile(em.sourceName, targetName, external, data)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", targetName, err)
+		}
+	}
+	return nil
+}
+
+// addExternalArchive adds the archive external with targetName and data to ts.
+func (ts *TargetState) addExternalArchive(sourceName, targetName string, external *External, data []byte) error {
+	parentEntries, err := ts.mkdirAllEntries(sourceName, filepath.Dir(targetName), false)
+	if err != nil {
+		return err
+	}
+	switch entry := parentEntries[filepath.Base(targetName)].(type) {
+	case nil:
+		parentEntries[filepath.Base(targetName)] = newExternalDir(sourceName, targetName, external.Exact, 0o777)
+	case *Dir:
+		entry.Exact = entry.Exact || external.Exact
+	default:
+		return fmt.Errorf("%s: not a directory", targetName)
+	}
+
+	format := external.Format
+	if format == "" {
+		format = DetectArchiveFormat(data)
+		if format == "" {
+			return fmt.Errorf("%s: cannot detect archive format", external.URL)
+		}
+	}
+	return walkArchive(data, format, func(name string, info os.FileInfo, r io.Reader, linkname string) error {
+		components := strings.Split(strings.Trim(path.Clean(name), "/"), "/")
+		if len(components) <= external.StripComponents {
+			return nil
+		}
+		relPath := path.Join(components[external.StripComponents:]...)
+		if relPath == "." {
+			return nil
+		}
+		if relPath == ".." || strings.HasPrefix(relPath, "../") {
+			return fmt.Errorf("%s: invalid path", name)
+		}
+		if !external.match(relPath) {
+			return nil
+		}
+		entryTargetName := filepath.Join(targetName, filepath.FromSlash(relPath))
+
+		entries, err := ts.mkdirAllEntries(sourceName, filepath.Dir(entryTargetName), external.Exact)
+		if err != nil {
+			return err
+		}
+		entryName := filepath.Base(entryTargetName)
+		if _, ok := entries[entryName]; ok {
+			return nil
+		}
+		switch {
+		case info.IsDir():
+			entries[entryName] = newExternalDir(sourceName, entryTargetName, external.Exact, info.Mode().Perm())
+		case info.Mode().IsRegular():
+			contents, err := ioutil.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			entries[entryName] = &File{
+				sourceName: sourceName,
+				targetName: entryTargetName,
+				external:   true,
+				Empty:      true,
+				Perm:       info.Mode().Perm(),
+				contents:   contents,
+			}
+		case info.Mode()&os.ModeType == os.ModeSymlink:
+			entries[entryName] = &Symlink{
+				sourceName: sourceName,
+				targetName: entryTargetName,
+				external:   true,
+				linkname:   linkname,
+			}
+		}
+		return nil
+	})
+}
+
+// addExternalFile adds the file external with targetName and data to ts.
+func (ts *TargetState) addExternalFile(sourceName, targetName string, external *External, data []byte) error {
+	entries, err := ts.mkdirAllEntries(sourceName, filepath.Dir(targetName), false)
+	if err != nil {
+		return err
+	}
+	name := filepath.Base(targetName)
+	if _, ok := entries[name]; ok {
+		return nil
+	}
+	perm := os.FileMode(0o666)
+	if external.Executable {
+		perm = 0o777
+	}
+	entries[name] = &File{
+		sourceName: sourceName,
+		targetName: targetName,
+		external:   true,
+		Empty:      true,
+		Perm:       perm,
+		contents:   data,
+	}
+	return nil
+}
+
+// newExternalDir returns a new directory state created by the external in the
+// manifest with sourceName.
+func newExternalDir(sourceName, targetName string, exact bool, perm os.FileMode) *Dir {
+	dir := newDir(sourceName, targetName, exact, perm)
+	dir.external = true
+	return dir
+}
+
+// mkdirAllEntries returns the entries of the Dir with targetName, creating it
+// and any missing parent Dirs with sourceName and exact.
+func (ts *TargetState) mkdirAllEntries(sourceName, targetName string, exact bool) (map[string]Entry, error) {
+	entries := ts.Entries
+	if targetName == "." {
+		return entries, nil
+	}
+	components := splitPathList(targetName)
+	for i, component := range components {
+		entry, ok := entries[component]
+		if !ok {
+			entry = newExternalDir(sourceName, filepath.Join(components[:i+1]...), exact, 0o777)
+			entries[component] = entry
+		}
+		dir, ok := entry.(*Dir)
+		if !ok {
+			return nil, fmt.Errorf("%s: not a directory", filepath.Join(components[:i+1]...))
+		}
+		entries = dir.Entries
+	}
+	return entries, nil
+}
+
+// readExternal returns the contents of external, from the cache if it is
+// fresh or refresh is false, otherwise by downloading it and updating the
+// cache. A refreshPeriod of zero means that cached externals are never
+// downloaded again. If cachedOnly is true and external is not cached then it
+// returns errExternalNotCached.
+func (ts *TargetState) readExternal(external *External, refresh, cachedOnly bool) ([]byte, error) {
+	var cachePath string
+	if ts.CacheFS != nil && ts.CacheDir != "" {
+		urlSHA256 := sha256.Sum256([]byte(external.URL))
+		cachePath = filepath.Join(ts.CacheDir, "external", hex.EncodeToString(urlSHA256[:]))
+		if info, err := ts.CacheFS.Stat(cachePath); err == nil {
+			if !refresh || external.refreshPeriod == 0 || time.Since(info.ModTime()) < external.refreshPeriod {
+				return ts.CacheFS.ReadFile(cachePath)
+			}
+		}
+	}
+	if cachedOnly {
+		return nil, errExternalNotCached
+	}
+
+	resp, err := externalHTTPClient.Get(external.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", external.URL, resp.Status)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if cachePath != "" {
+		if err := vfs.MkdirAll(ts.CacheFS, filepath.Dir(cachePath), 0o700); err != nil {
+			return nil, err
+		}
+		if err := ts.CacheFS.WriteFile(cachePath, data, 0o600); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// sortedExternalNames returns the sorted names of externals.
+func sortedExternalNames(externals map[string]*External) []string {
+	names := make([]string, 0, len(externals))
+	for name := range externals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,264 @@
+package chezmoi
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestParseExternalManifest(t *testing.T) {
+	want := map[string]*External{
+		".oh-my-zsh": {
+			Type:            ExternalTypeArchive,
+			URL:             "https://example.com/archive.tar.gz",
+			Exact:           true,
+			Format:          ArchiveFormatTarGz,
+			StripComponents: 1,
+			RefreshPeriod:   "168h",
+			refreshPeriod:   168 * time.Hour,
+		},
+	}
+	for _, tc := range []struct {
+		sourceName string
+		data       string
+	}{
+		{
+			sourceName: ".chezmoiexternal.json",
+			data:       `{".oh-my-zsh":{"type":"archive","url":"https://example.com/archive.tar.gz","exact":true,"stripComponents":1,"refreshPeriod":"168h"}}`,
+		},
+		{
+			sourceName: ".chezmoiexternal.toml",
+			data: `[".oh-my-zsh"]` + "\n" +
+				`  type = "archive"` + "\n" +
+				`  url = "https://example.com/archive.tar.gz"` + "\n" +
+				`  exact = true` + "\n" +
+				`  stripComponents = 1` + "\n" +
+				`  refreshPeriod = "168h"` + "\n",
+		},
+		{
+			sourceName: ".chezmoiexternal.yaml",
+			data: ".oh-my-zsh:\n" +
+				"  type: archive\n" +
+				"  url: https://example.com/archive.tar.gz\n" +
+				"  exact: true\n" +
+				"  stripComponents: 1\n" +
+				"  refreshPeriod: 168h\n",
+		},
+	} {
+		t.Run(tc.sourceName, func(t *testing.T) {
+			got, err := parseExternalManifest(tc.sourceName, []byte(tc.data))
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestParseExternalManifestErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		data string
+	}{
+		{
+			name: "unknown_type",
+			data: `{"foo":{"type":"unknown","url":"https://example.com/foo"}}`,
+		},
+		{
+			name: "missing_url",
+			data: `{"foo":{"type":"file"}}`,
+		},
+		{
+			name: "unknown_archive_format",
+			data: `{"foo":{"type":"archive","url":"https://example.com/foo.rar","format":"rar"}}`,
+		},
+		{
+			name: "invalid_refresh_period",
+			data: `{"foo":{"type":"file","url":"https://example.com/foo","refreshPeriod":"weekly"}}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseExternalManifest(".chezmoiexternal.json", []byte(tc.data))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestTargetStatePopulateExternals(t *testing.T) {
+	archive := newTestTarGz(t, []*tar.Header{
+		{Typeflag: tar.TypeDir, Name: "archive-master/", Mode: 0o755},
+		{Typeflag: tar.TypeReg, Name: "archive-master/README.md", Mode: 0o644},
+		{Typeflag: tar.TypeReg, Name: "archive-master/bin/tool", Mode: 0o755},
+		{Typeflag: tar.TypeReg, Name: "archive-master/custom/plugin.zsh", Mode: 0o644},
+		{Typeflag: tar.TypeSymlink, Name: "archive-master/link", Linkname: "bin/tool"},
+	}, map[string]string{
+		"archive-master/README.md":         "# README\n",
+		"archive-master/bin/tool":          "#!/bin/sh\n",
+		"archive-master/custom/plugin.zsh": "# plugin\n",
+	})
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/archive.tar.gz":
+			_, _ = w.Write(archive)
+		case "/file":
+			_, _ = w.Write([]byte("# file\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".local/share/chezmoi": map[string]interface{}{
+				".chezmoiexternal.toml.tmpl": "" +
+					"[\".archive\"]\n" +
+					"  type = \"archive\"\n" +
+					"  url = \"{{ .url }}/archive.tar.gz\"\n" +
+					"  exact = true\n" +
+					"  stripComponents = 1\n" +
+					"  exclude = [\"*.md\"]\n" +
+					"[\".config/file\"]\n" +
+					"  type = \"file\"\n" +
+					"  url = \"{{ .url }}/file\"\n" +
+					"  executable = true\n" +
+					"  refreshPeriod = \"1h\"\n",
+				"dot_archive/custom/plugin.zsh": "# custom plugin\n",
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	newTargetState := func(options *PopulateOptions) *TargetState {
+		ts := NewTargetState(
+			WithCacheDir("/home/user/.cache/chezmoi"),
+			WithCacheFS(fs),
+			WithDestDir("/home/user"),
+			WithSourceDir("/home/user/.local/share/chezmoi"),
+			WithTemplateData(map[string]interface{}{
+				"url": server.URL,
+			}),
+		)
+		require.NoError(t, ts.Populate(fs, options))
+		return ts
+	}
+
+	ts := newTargetState(nil)
+	assert.Equal(t, 2, requests)
+	applyOptions := &ApplyOptions{
+		DestDir:           ts.DestDir,
+		Ignore:            ts.TargetIgnore.Match,
+		ScriptStateBucket: []byte("script"),
+		Stdout:            os.Stdout,
+		Umask:             0o22,
+	}
+	require.NoError(t, ts.Apply(fs, NewFSMutator(fs), false, applyOptions))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.archive",
+			vfst.TestIsDir,
+		),
+		vfst.TestPath("/home/user/.archive/README.md",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/.archive/bin/tool",
+			vfst.TestModeIsRegular,
+			vfst.TestModePerm(0o755),
+			vfst.TestContentsString("#!/bin/sh\n"),
+		),
+		vfst.TestPath("/home/user/.archive/custom/plugin.zsh",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("# custom plugin\n"),
+		),
+		vfst.TestPath("/home/user/.archive/link",
+			vfst.TestModeType(os.ModeSymlink),
+			vfst.TestSymlinkTarget("bin/tool"),
+		),
+		vfst.TestPath("/home/user/.config/file",
+			vfst.TestModeIsRegular,
+			vfst.TestModePerm(0o755),
+			vfst.TestContentsString("# file\n"),
+		),
+	)
+
+	// Entries from externals have no source files of their own, so cannot be
+	// added.
+	require.NoError(t, fs.WriteFile("/home/user/.config/file", []byte("# modified file\n"), 0o755))
+	require.NoError(t, fs.WriteFile("/home/user/.archive/bin/new", []byte("# new\n"), 0o644))
+	for _, targetPath := range []string{
+		"/home/user/.config/file",
+		"/home/user/.archive/bin/new",
+	} {
+		var externalEntryError *ExternalEntryError
+		assert.True(t, errors.As(ts.Add(fs, AddOptions{}, targetPath, nil, false, NewFSMutator(fs)), &externalEntryError))
+	}
+	entry, err := ts.Get(fs, "/home/user/.archive/custom/plugin.zsh")
+	require.NoError(t, err)
+	assert.NoError(t, CheckSourceFile(entry))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/share/chezmoi/.chezmoiexternal.toml.tmpl",
+			vfst.TestModeIsRegular,
+		),
+	)
+
+	// Fresh externals are read from the cache.
+	newTargetState(&PopulateOptions{
+		ExecuteTemplates: true,
+		RefreshExternals: true,
+	})
+	assert.Equal(t, 2, requests)
+
+	// Stale externals are downloaded again.
+	oldTime := time.Now().Add(-2 * time.Hour)
+	cacheInfos, err := fs.ReadDir("/home/user/.cache/chezmoi/external")
+	require.NoError(t, err)
+	for _, info := range cacheInfos {
+		require.NoError(t, fs.Chtimes("/home/user/.cache/chezmoi/external/"+info.Name(), oldTime, oldTime))
+	}
+	// Stale externals are only downloaded again when refreshing.
+	newTargetState(nil)
+	assert.Equal(t, 2, requests)
+	newTargetState(&PopulateOptions{
+		ExecuteTemplates: true,
+		RefreshExternals: true,
+	})
+	assert.Equal(t, 3, requests)
+
+	// Externals that are not cached are skipped, and never downloaded, when
+	// only cached externals are allowed.
+	require.NoError(t, fs.RemoveAll("/home/user/.cache/chezmoi/external"))
+	ts = newTargetState(&PopulateOptions{
+		ExecuteTemplates:    true,
+		CachedExternalsOnly: true,
+	})
+	assert.Equal(t, 3, requests)
+	_, err = ts.Get(fs, "/home/user/.config/file")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func newTestTarGz(t *testing.T, headers []*tar.Header, contents map[string]string) []byte {
+	t.Helper()
+	b := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(b)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for _, header := range headers {
+		data := []byte(contents[header.Name])
+		header.Size = int64(len(data))
+		require.NoError(t, tarWriter.WriteHeader(header))
+		_, err := tarWriter.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, gzipWriter.Close())
+	return b.Bytes()
+}
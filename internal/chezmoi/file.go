@@ -1,10 +1,10 @@
 package chezmoi
 
 import (
-	"archive/tar"
 	"bytes"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 
@@ -15,8 +15,10 @@ import (
 type FileAttributes struct {
 	Name      string
 	Mode      os.FileMode
+	Create    bool
 	Empty     bool
 	Encrypted bool
+	Modify    bool
 	Template  bool
 }
 
@@ -24,8 +26,11 @@ type FileAttributes struct {
 type File struct {
 	sourceName       string
 	targetName       string
+	external         bool
+	Create           bool
 	Empty            bool
 	Encrypted        bool
+	Modify           bool
 	Perm             os.FileMode
 	Template         bool
 	contents         []byte
@@ -37,24 +42,37 @@ type fileConcreteValue struct {
 	Type       string `json:"type" yaml:"type"`
 	SourcePath string `json:"sourcePath" yaml:"sourcePath"`
 	TargetPath string `json:"targetPath" yaml:"targetPath"`
+	Create     bool   `json:"create" yaml:"create"`
 	Empty      bool   `json:"empty" yaml:"empty"`
 	Encrypted  bool   `json:"encrypted" yaml:"encrypted"`
+	Modify     bool   `json:"modify" yaml:"modify"`
 	Perm       int    `json:"perm" yaml:"perm"`
 	Template   bool   `json:"template" yaml:"template"`
 	Contents   string `json:"contents" yaml:"contents"`
 }
 
-// ParseFileAttributes parses a source file name.
-func ParseFileAttributes(sourceName string) FileAttributes {
+// ParseFileAttributes parses a source file name. encryptedSuffix is removed
+// from the names of encrypted files.
+func ParseFileAttributes(sourceName, encryptedSuffix string) FileAttributes {
 	name := sourceName
 	mode := os.FileMode(0o666)
+	create := false
 	empty := false
 	encrypted := false
+	modify := false
 	template := false
 	if strings.HasPrefix(name, symlinkPrefix) {
 		name = strings.TrimPrefix(name, symlinkPrefix)
 		mode |= os.ModeSymlink
 	} else {
+		switch {
+		case strings.HasPrefix(name, createPrefix):
+			name = strings.TrimPrefix(name, createPrefix)
+			create = true
+		case strings.HasPrefix(name, modifyPrefix):
+			name = strings.TrimPrefix(name, modifyPrefix)
+			modify = true
+		}
 		private := false
 		if strings.HasPrefix(name, encryptedPrefix) {
 			name = strings.TrimPrefix(name, encryptedPrefix)
@@ -79,6 +97,9 @@ func ParseFileAttributes(sourceName string) FileAttributes {
 	if strings.HasPrefix(name, dotPrefix) {
 		name = "." + strings.TrimPrefix(name, dotPrefix)
 	}
+	if encrypted {
+		name = strings.TrimSuffix(name, encryptedSuffix)
+	}
 	if strings.HasSuffix(name, TemplateSuffix) {
 		name = strings.TrimSuffix(name, TemplateSuffix)
 		template = true
@@ -86,18 +107,27 @@ func ParseFileAttributes(sourceName string) FileAttributes {
 	return FileAttributes{
 		Name:      name,
 		Mode:      mode,
+		Create:    create,
 		Empty:     empty,
 		Encrypted: encrypted,
+		Modify:    modify,
 		Template:  template,
 	}
 }
 
-// SourceName returns fa's source name.
-func (fa FileAttributes) SourceName() string {
+// SourceName returns fa's source name. encryptedSuffix is appended to the names
+// of encrypted files.
+func (fa FileAttributes) SourceName(encryptedSuffix string) string {
 	sourceName := ""
 	//nolint:exhaustive
 	switch fa.Mode & os.ModeType {
 	case 0:
+		switch {
+		case fa.Create:
+			sourceName += createPrefix
+		case fa.Modify:
+			sourceName += modifyPrefix
+		}
 		if fa.Encrypted {
 			sourceName += encryptedPrefix
 		}
@@ -123,6 +153,9 @@ func (fa FileAttributes) SourceName() string {
 	if fa.Template {
 		sourceName += TemplateSuffix
 	}
+	if fa.Encrypted {
+		sourceName += encryptedSuffix
+	}
 	return sourceName
 }
 
@@ -133,7 +166,7 @@ func (f *File) AppendAllEntries(allEntries []Entry) []Entry {
 
 // Apply ensures that the state of targetPath in fs matches f.
 func (f *File) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions) error {
-	if applyOptions.Ignore(f.targetName) {
+	if applyOptions.Ignore(f.targetName, false) {
 		return nil
 	}
 	contents, err := f.Contents()
@@ -149,8 +182,14 @@ func (f *File) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Appl
 	}
 	var currData []byte
 	switch {
+	case err == nil && f.Create:
+		// Files with the create_ attribute are only written if they do not
+		// already exist.
+		return nil
 	case err == nil && info.Mode().IsRegular():
-		if isEmpty(contents) && !f.Empty {
+		// Empty output from a modify script never removes the target, it
+		// empties it.
+		if isEmpty(contents) && !f.Empty && !f.Modify {
 			return mutator.RemoveAll(targetPath)
 		}
 		currData, err = fs.ReadFile(targetPath)
@@ -174,15 +213,16 @@ func (f *File) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Appl
 	default:
 		return err
 	}
-	if isEmpty(contents) && !f.Empty {
+	// Empty files are not created, unless they have the empty_ attribute.
+	if isEmpty(contents) && !f.Empty && currData == nil {
 		return nil
 	}
 	return mutator.WriteFile(targetPath, contents, f.Perm&^applyOptions.Umask, currData)
 }
 
 // ConcreteValue implements Entry.ConcreteValue.
-func (f *File) ConcreteValue(ignore func(string) bool, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error) {
-	if ignore(f.targetName) {
+func (f *File) ConcreteValue(ignore func(string, bool) bool, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error) {
+	if ignore(f.targetName, false) {
 		return nil, nil
 	}
 	contents, err := f.Contents()
@@ -193,8 +233,10 @@ func (f *File) ConcreteValue(ignore func(string) bool, sourceDir string, umask o
 		Type:       "file",
 		SourcePath: filepath.Join(sourceDir, f.SourceName()),
 		TargetPath: f.TargetName(),
+		Create:     f.Create,
 		Empty:      f.Empty,
 		Encrypted:  f.Encrypted,
+		Modify:     f.Modify,
 		Perm:       int(f.Perm &^ umask),
 		Template:   f.Template,
 		Contents:   string(contents),
@@ -211,8 +253,8 @@ func (f *File) Contents() ([]byte, error) {
 }
 
 // Evaluate evaluates f's contents.
-func (f *File) Evaluate(ignore func(string) bool) error {
-	if ignore(f.targetName) {
+func (f *File) Evaluate(ignore func(string, bool) bool) error {
+	if ignore(f.targetName, false) {
 		return nil
 	}
 	_, err := f.Contents()
@@ -240,8 +282,8 @@ func (f *File) TargetName() string {
 }
 
 // archive writes f to w.
-func (f *File) archive(w *tar.Writer, ignore func(string) bool, headerTemplate *tar.Header, umask os.FileMode) error {
-	if ignore(f.targetName) {
+func (f *File) archive(w ArchiveWriter, ignore func(string, bool) bool, umask os.FileMode) error {
+	if ignore(f.targetName, false) {
 		return nil
 	}
 	contents, err := f.Contents()
@@ -251,14 +293,42 @@ func (f *File) archive(w *tar.Writer, ignore func(string) bool, headerTemplate *
 	if len(contents) == 0 && !f.Empty {
 		return nil
 	}
-	header := *headerTemplate
-	header.Typeflag = tar.TypeReg
-	header.Name = f.targetName
-	header.Size = int64(len(contents))
-	header.Mode = int64(f.Perm &^ umask)
-	if err := w.WriteHeader(&header); err != nil {
-		return nil
+	return w.WriteFile(f.targetName, contents, f.Perm&^umask)
+}
+
+// runModifyScript runs script, the modify script for targetName, with
+// currentContents on stdin and returns its output. It is run in the closest
+// existing ancestor directory of targetName in fs. Empty scripts leave
+// currentContents unchanged.
+func runModifyScript(fs vfs.FS, destDir, targetName string, script, currentContents []byte) ([]byte, error) {
+	if isEmpty(script) {
+		return currentContents, nil
 	}
-	_, err = w.Write(contents)
-	return err
+
+	scriptPath, err := writeTempScript(targetName, script)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = os.RemoveAll(scriptPath)
+	}()
+
+	dir := filepath.Join(destDir, filepath.Dir(targetName))
+	for {
+		if _, err := fs.Stat(dir); err == nil || filepath.Dir(dir) == dir {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+	rawDir, err := fs.RawPath(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	//nolint:gosec
+	c := exec.Command(scriptPath)
+	c.Dir = rawDir
+	c.Stdin = bytes.NewReader(currentContents)
+	c.Stderr = os.Stderr
+	return c.Output()
 }
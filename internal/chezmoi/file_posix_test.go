@@ -0,0 +1,118 @@
+// +build !windows
+
+package chezmoi
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestModifyFile(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".config/app.conf": "color = red\nsize = 1\n",
+			".emptied":         "remove me\n",
+			".unchanged":       "keep\n",
+		},
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			"dot_config/modify_app.conf": "#!/bin/sh\nsed 's/color = red/color = blue/'\n",
+			"modify_dot_emptied":         "#!/bin/sh\ntrue\n",
+			"modify_dot_new.tmpl":        "#!/bin/sh\ncat\necho {{ .line }}\n",
+			"modify_dot_unchanged.tmpl":  "{{ if false }}#!/bin/sh\n{{ end }}",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	ts := NewTargetState(
+		WithDestDir("/home/user"),
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+		WithTemplateData(map[string]interface{}{
+			"line": "added",
+		}),
+	)
+	require.NoError(t, ts.Populate(fs, nil))
+
+	entry, err := ts.Get(fs, "/home/user/.config/app.conf")
+	require.NoError(t, err)
+	concreteValue, err := entry.ConcreteValue(ts.TargetIgnore.Match, ts.SourceDir, 0o22, false)
+	require.NoError(t, err)
+	assert.Equal(t, &fileConcreteValue{
+		Type:       "file",
+		SourcePath: "/home/user/.local/share/chezmoi/dot_config/modify_app.conf",
+		TargetPath: ".config/app.conf",
+		Modify:     true,
+		Perm:       0o644,
+		Contents:   "color = blue\nsize = 1\n",
+	}, concreteValue)
+
+	applyOptions := &ApplyOptions{
+		DestDir: ts.DestDir,
+		Ignore:  ts.TargetIgnore.Match,
+		Stdout:  os.Stdout,
+		Umask:   0o22,
+	}
+	require.NoError(t, ts.Apply(fs, NewFSMutator(fs), false, applyOptions))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.config/app.conf",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("color = blue\nsize = 1\n"),
+		),
+		vfst.TestPath("/home/user/.emptied",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString(""),
+		),
+		vfst.TestPath("/home/user/.new",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("added\n"),
+		),
+		vfst.TestPath("/home/user/.unchanged",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("keep\n"),
+		),
+	)
+}
+
+func TestModifyFileSkipModifyScripts(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".config/app.conf": "color = red\nsize = 1\n",
+		},
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			"dot_config/modify_app.conf": "#!/bin/sh\nsed 's/color = red/color = blue/'\n",
+			"modify_dot_new":             "#!/bin/sh\necho added\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	ts := NewTargetState(
+		WithDestDir("/home/user"),
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+	)
+	require.NoError(t, ts.Populate(fs, &PopulateOptions{
+		ExecuteTemplates:  true,
+		SkipModifyScripts: true,
+	}))
+
+	applyOptions := &ApplyOptions{
+		DestDir: ts.DestDir,
+		Ignore:  ts.TargetIgnore.Match,
+		Stdout:  os.Stdout,
+		Umask:   0o22,
+	}
+	require.NoError(t, ts.Apply(fs, NewFSMutator(fs), false, applyOptions))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.config/app.conf",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("color = red\nsize = 1\n"),
+		),
+		vfst.TestPath("/home/user/.new",
+			vfst.TestDoesNotExist,
+		),
+	)
+}
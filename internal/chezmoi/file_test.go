@@ -9,8 +9,9 @@ import (
 
 func TestFileAttributes(t *testing.T) {
 	for _, tc := range []struct {
-		sourceName string
-		fa         FileAttributes
+		sourceName      string
+		encryptedSuffix string
+		fa              FileAttributes
 	}{
 		{
 			sourceName: "foo",
@@ -84,6 +85,31 @@ func TestFileAttributes(t *testing.T) {
 				Template: true,
 			},
 		},
+		{
+			sourceName: "create_private_dot_foo",
+			fa: FileAttributes{
+				Name:   ".foo",
+				Mode:   0o600,
+				Create: true,
+			},
+		},
+		{
+			sourceName: "modify_dot_foo",
+			fa: FileAttributes{
+				Name:   ".foo",
+				Mode:   0o666,
+				Modify: true,
+			},
+		},
+		{
+			sourceName: "modify_private_executable_foo.tmpl",
+			fa: FileAttributes{
+				Name:     "foo",
+				Mode:     0o700,
+				Modify:   true,
+				Template: true,
+			},
+		},
 		{
 			sourceName: "symlink_foo",
 			fa: FileAttributes{
@@ -114,10 +140,29 @@ func TestFileAttributes(t *testing.T) {
 				Encrypted: true,
 			},
 		},
+		{
+			sourceName:      "encrypted_private_dot_secret_file.asc",
+			encryptedSuffix: ".asc",
+			fa: FileAttributes{
+				Name:      ".secret_file",
+				Mode:      0o600,
+				Encrypted: true,
+			},
+		},
+		{
+			sourceName:      "encrypted_dot_secret_file.tmpl.age",
+			encryptedSuffix: ".age",
+			fa: FileAttributes{
+				Name:      ".secret_file",
+				Mode:      0o666,
+				Encrypted: true,
+				Template:  true,
+			},
+		},
 	} {
 		t.Run(tc.sourceName, func(t *testing.T) {
-			assert.Equal(t, tc.fa, ParseFileAttributes(tc.sourceName))
-			assert.Equal(t, tc.sourceName, tc.fa.SourceName())
+			assert.Equal(t, tc.fa, ParseFileAttributes(tc.sourceName, tc.encryptedSuffix))
+			assert.Equal(t, tc.sourceName, tc.fa.SourceName(tc.encryptedSuffix))
 		})
 	}
 }
@@ -1,6 +1,7 @@
 package chezmoi
 
 import (
+	"os"
 	"os/exec"
 
 	vfs "github.com/twpayne/go-vfs"
@@ -31,3 +32,28 @@ func (m *FSMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
 func (m *FSMutator) RunCmd(cmd *exec.Cmd) error {
 	return cmd.Run()
 }
+
+// RunScript implements Mutator.RunScript.
+func (m *FSMutator) RunScript(scriptname, dir string, data []byte) error {
+	scriptPath, err := writeTempScript(scriptname, data)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(scriptPath)
+	}()
+
+	rawDir, err := m.FS.RawPath(dir)
+	if err != nil {
+		return err
+	}
+
+	// Run the temporary script file.
+	//nolint:gosec
+	c := exec.Command(scriptPath)
+	c.Dir = rawDir
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	c.Stdin = os.Stdin
+	return c.Run()
+}
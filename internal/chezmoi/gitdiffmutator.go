@@ -3,6 +3,7 @@ package chezmoi
 import (
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -108,6 +109,37 @@ func (m *GitDiffMutator) RunCmd(cmd *exec.Cmd) error {
 	return nil
 }
 
+// RecordScript implements ScriptRecorder.RecordScript.
+func (m *GitDiffMutator) RecordScript(scriptname, dir string, data []byte, stateUpdate *ScriptStateUpdate) error {
+	if err := m.RunScript(scriptname, dir, data); err != nil {
+		return err
+	}
+	return recordScript(m.m, scriptname, dir, data, stateUpdate)
+}
+
+// RunScript implements Mutator.RunScript. Scripts are written to the diff as
+// new executable files named after the script.
+func (m *GitDiffMutator) RunScript(scriptname, dir string, data []byte) error {
+	isBinary := isBinary(data)
+	var chunks []diff.Chunk
+	if !isBinary {
+		chunks = diffChunks("", string(data))
+	}
+	return m.unifiedEncoder.Encode(&gitDiffPatch{
+		filePatches: []diff.FilePatch{
+			&gitDiffFilePatch{
+				isBinary: isBinary,
+				to: &gitDiffFile{
+					fileMode: filemode.Executable,
+					path:     filepath.ToSlash(scriptname),
+					hash:     plumbing.ComputeHash(plumbing.BlobObject, data),
+				},
+				chunks: chunks,
+			},
+		},
+	})
+}
+
 // Stat implements Mutator.Stat.
 func (m *GitDiffMutator) Stat(name string) (os.FileInfo, error) {
 	return m.m.Stat(name)
@@ -4,6 +4,7 @@ import "github.com/go-git/go-git/v5/plumbing/format/diff"
 
 var (
 	_ Mutator        = &GitDiffMutator{}
+	_ ScriptRecorder = &GitDiffMutator{}
 	_ diff.Chunk     = &gitDiffChunk{}
 	_ diff.File      = &gitDiffFile{}
 	_ diff.FilePatch = &gitDiffFilePatch{}
@@ -11,11 +11,12 @@ import (
 type GPG struct {
 	Command   string
 	Recipient string
+	Suffix    string
 	Symmetric bool
 }
 
-// Decrypt decrypts ciphertext. filename is used as a hint for naming temporary
-// files.
+// Decrypt implements Encryption.Decrypt. filename is used as a hint for naming
+// temporary files.
 func (g *GPG) Decrypt(filename string, ciphertext []byte) ([]byte, error) {
 	tempDir, err := ioutil.TempDir("", "chezmoi-decrypt")
 	if err != nil {
@@ -46,8 +47,8 @@ func (g *GPG) Decrypt(filename string, ciphertext []byte) ([]byte, error) {
 	return ioutil.ReadFile(outputFilename)
 }
 
-// Encrypt encrypts plaintext for ts's recipient. filename is used as a hint for
-// naming temporary files.
+// Encrypt implements Encryption.Encrypt, encrypting plaintext for g's recipient.
+// filename is used as a hint for naming temporary files.
 func (g *GPG) Encrypt(filename string, plaintext []byte) ([]byte, error) {
 	tempDir, err := ioutil.TempDir("", "chezmoi-encrypt")
 	if err != nil {
@@ -74,7 +75,7 @@ func (g *GPG) Encrypt(filename string, plaintext []byte) ([]byte, error) {
 		}
 		args = append(args, "--encrypt")
 	}
-	args = append(args, filename)
+	args = append(args, inputFilename)
 
 	//nolint:gosec
 	cmd := exec.Command(g.Command, args...)
@@ -87,3 +88,8 @@ func (g *GPG) Encrypt(filename string, plaintext []byte) ([]byte, error) {
 
 	return ioutil.ReadFile(outputFilename)
 }
+
+// EncryptedSuffix implements Encryption.EncryptedSuffix.
+func (g *GPG) EncryptedSuffix() string {
+	return g.Suffix
+}
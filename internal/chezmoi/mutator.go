@@ -13,7 +13,26 @@ type Mutator interface {
 	RemoveAll(name string) error
 	Rename(oldpath, newpath string) error
 	RunCmd(cmd *exec.Cmd) error
+	RunScript(scriptname, dir string, data []byte) error
 	Stat(name string) (os.FileInfo, error)
 	WriteFile(filename string, data []byte, perm os.FileMode, currData []byte) error
 	WriteSymlink(oldname, newname string) error
 }
+
+// A ScriptRecorder is a Mutator that records the scripts that would be run in
+// a dry run. In a dry run, scripts are passed to RecordScript instead of
+// RunScript, together with the update to the persistent state that running
+// them would make, if any.
+type ScriptRecorder interface {
+	RecordScript(scriptname, dir string, data []byte, stateUpdate *ScriptStateUpdate) error
+}
+
+// recordScript passes the script to m if m is a ScriptRecorder. Mutators that
+// wrap another Mutator call it from their RecordScript so that wrapped
+// recorders still see scripts.
+func recordScript(m Mutator, scriptname, dir string, data []byte, stateUpdate *ScriptStateUpdate) error {
+	if scriptRecorder, ok := m.(ScriptRecorder); ok {
+		return scriptRecorder.RecordScript(scriptname, dir, data, stateUpdate)
+	}
+	return nil
+}
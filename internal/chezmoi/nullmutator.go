@@ -38,6 +38,11 @@ func (NullMutator) RunCmd(cmd *exec.Cmd) error {
 	return nil
 }
 
+// RunScript implements Mutator.RunScript.
+func (NullMutator) RunScript(scriptname, dir string, data []byte) error {
+	return nil
+}
+
 // Stat implements Mutator.Stat.
 func (NullMutator) Stat(path string) (os.FileInfo, error) {
 	return nil, &os.PathError{
@@ -1,47 +1,230 @@
 package chezmoi
 
 import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
 	"github.com/bmatcuk/doublestar/v2"
+	vfs "github.com/twpayne/go-vfs"
 )
 
-// An PatternSet is a set of patterns.
+// A Pattern is a single pattern in a PatternSet, with .gitignore-like
+// semantics. A Pattern that ends with a slash only matches directories. A
+// Pattern that begins with or contains a slash is anchored to its directory,
+// otherwise it matches at any depth below its directory, unless its PatternSet
+// anchors all patterns.
+type Pattern struct {
+	SourceName string
+	LineNumber int
+	Text       string
+	Include    bool
+	dir        string
+	glob       string
+	dirOnly    bool
+}
+
+// A PatternMatch records which Pattern in a PatternSet decided whether a name
+// matched. Name is the name, or one of its parent directories, that Pattern
+// matched.
+type PatternMatch struct {
+	Name    string
+	Pattern *Pattern
+}
+
+// A PatternSet is an ordered list of patterns. The last pattern that matches a
+// name decides whether the name matches. If a parent directory of a name
+// matches then the name also matches.
 type PatternSet struct {
-	includes map[string]struct{}
-	excludes map[string]struct{}
+	anchored bool
+	patterns []*Pattern
+}
+
+// A PatternSetOption sets an option on a PatternSet.
+type PatternSetOption func(*PatternSet)
+
+// WithAnchoredPatterns sets whether all patterns are anchored to their
+// directory. Anchored patterns only match at any depth if they explicitly begin
+// with **/.
+func WithAnchoredPatterns(anchored bool) PatternSetOption {
+	return func(ps *PatternSet) {
+		ps.anchored = anchored
+	}
 }
 
-// NewPatternSet returns a new PatternSet.
-func NewPatternSet() *PatternSet {
-	return &PatternSet{
-		includes: make(map[string]struct{}),
-		excludes: make(map[string]struct{}),
+// NewPatternSet returns a new PatternSet with the given options.
+func NewPatternSet(options ...PatternSetOption) *PatternSet {
+	ps := &PatternSet{}
+	for _, o := range options {
+		o(ps)
 	}
+	return ps
 }
 
-// Add adds a pattern to ps.
+// Add adds a pattern to ps. If include is false then names that match pattern
+// are excluded from ps.
 func (ps *PatternSet) Add(pattern string, include bool) error {
-	if _, err := doublestar.PathMatch(pattern, ""); err != nil {
-		return nil
+	return ps.add(&Pattern{
+		Text:    pattern,
+		Include: include,
+	}, "")
+}
+
+// AddPatterns adds the patterns in data, read from sourceName, to ps. Patterns
+// are relative to the target directory dir. Blank lines and comments beginning
+// with # are ignored, and patterns beginning with ! exclude names.
+func (ps *PatternSet) AddPatterns(sourceName, dir string, data []byte) error {
+	s := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for s.Scan() {
+		lineNumber++
+		text := s.Text()
+		if index := strings.IndexRune(text, '#'); index != -1 {
+			text = text[:index]
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		include := true
+		if strings.HasPrefix(text, "!") {
+			include = false
+			text = strings.TrimPrefix(text, "!")
+		}
+		if err := ps.add(&Pattern{
+			SourceName: sourceName,
+			LineNumber: lineNumber,
+			Text:       text,
+			Include:    include,
+		}, dir); err != nil {
+			return fmt.Errorf("%s:%d: %w", sourceName, lineNumber, err)
+		}
 	}
-	if include {
-		ps.includes[pattern] = struct{}{}
-	} else {
-		ps.excludes[pattern] = struct{}{}
+	return s.Err()
+}
+
+// Glob returns the names of all paths in fs below dir that match ps.
+func (ps *PatternSet) Glob(fs vfs.FS, dir string) ([]string, error) {
+	var names []string
+	seen := make(map[string]struct{})
+	for _, p := range ps.patterns {
+		if !p.Include {
+			continue
+		}
+		matches, err := doublestar.GlobOS(doubleStarOS{FS: fs}, filepath.Join(dir, p.dir, p.glob))
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			name, err := filepath.Rel(dir, match)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			info, err := fs.Lstat(match)
+			if err != nil {
+				return nil, err
+			}
+			if ps.Match(name, info.IsDir()) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
+
+// Match returns true if name, which is a directory if isDir is true, matches
+// ps.
+func (ps *PatternSet) Match(name string, isDir bool) bool {
+	patternMatch := ps.Explain(name, isDir)
+	return patternMatch != nil && patternMatch.Pattern.Include
+}
+
+// Explain returns the PatternMatch that decides whether name, which is a
+// directory if isDir is true, matches ps, or nil if no pattern matches name or
+// any of its parent directories.
+func (ps *PatternSet) Explain(name string, isDir bool) *PatternMatch {
+	components := splitPathList(name)
+	for i := 1; i < len(components); i++ {
+		parentName := filepath.Join(components[:i]...)
+		if p := ps.lastMatch(parentName, true); p != nil && p.Include {
+			return &PatternMatch{
+				Name:    parentName,
+				Pattern: p,
+			}
+		}
+	}
+	if p := ps.lastMatch(name, isDir); p != nil {
+		return &PatternMatch{
+			Name:    name,
+			Pattern: p,
+		}
 	}
 	return nil
 }
 
-// Match returns if name matches any pattern in ps.
-func (ps *PatternSet) Match(name string) bool {
-	for pattern := range ps.excludes {
-		if ok, _ := doublestar.PathMatch(pattern, name); ok {
-			return false
+// add adds p, relative to dir, to ps.
+func (ps *PatternSet) add(p *Pattern, dir string) error {
+	text := p.Text
+	if strings.HasSuffix(text, "/") {
+		p.dirOnly = true
+		text = strings.TrimRight(text, "/")
+	}
+	anchored := ps.anchored || strings.Contains(text, "/")
+	text = strings.TrimLeft(text, "/")
+	if text == "" {
+		return fmt.Errorf("%s: invalid pattern", p.Text)
+	}
+	if !anchored && !strings.HasPrefix(text, "**") {
+		text = "**/" + text
+	}
+	p.glob = filepath.FromSlash(text)
+	if _, err := doublestar.PathMatch(p.glob, ""); err != nil {
+		return fmt.Errorf("%s: %w", p.Text, err)
+	}
+	if dir != "." {
+		p.dir = dir
+	}
+	ps.patterns = append(ps.patterns, p)
+	return nil
+}
+
+// lastMatch returns the last Pattern in ps that matches name, or nil if no
+// Pattern matches name.
+func (ps *PatternSet) lastMatch(name string, isDir bool) *Pattern {
+	for i := len(ps.patterns) - 1; i >= 0; i-- {
+		if ps.patterns[i].match(name, isDir) {
+			return ps.patterns[i]
 		}
 	}
-	for pattern := range ps.includes {
-		if ok, _ := doublestar.PathMatch(pattern, name); ok {
-			return true
+	return nil
+}
+
+// match returns true if p matches name.
+func (p *Pattern) match(name string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	if p.dir != "" {
+		dirPrefix := p.dir + string(filepath.Separator)
+		if !strings.HasPrefix(name, dirPrefix) {
+			return false
 		}
+		name = strings.TrimPrefix(name, dirPrefix)
+	}
+	ok, _ := doublestar.PathMatch(p.glob, name)
+	return ok
+}
+
+// String returns p's original text, including its ! prefix if it excludes.
+func (p *Pattern) String() string {
+	if p.Include {
+		return p.Text
 	}
-	return false
+	return "!" + p.Text
 }
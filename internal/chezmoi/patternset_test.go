@@ -2,6 +2,7 @@ package chezmoi
 
 import (
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -23,8 +24,8 @@ func TestPatternSet(t *testing.T) {
 		},
 		{
 			name: "exact",
-			ps: mustNewPatternSet(t, map[string]bool{
-				"foo": true,
+			ps: mustNewPatternSet(t, []string{
+				"foo",
 			}),
 			expectMatches: map[string]bool{
 				"foo": true,
@@ -33,8 +34,8 @@ func TestPatternSet(t *testing.T) {
 		},
 		{
 			name: "wildcard",
-			ps: mustNewPatternSet(t, map[string]bool{
-				"b*": true,
+			ps: mustNewPatternSet(t, []string{
+				"b*",
 			}),
 			expectMatches: map[string]bool{
 				"foo": false,
@@ -44,9 +45,9 @@ func TestPatternSet(t *testing.T) {
 		},
 		{
 			name: "exclude",
-			ps: mustNewPatternSet(t, map[string]bool{
-				"b*":  true,
-				"baz": false,
+			ps: mustNewPatternSet(t, []string{
+				"b*",
+				"!baz",
 			}),
 			expectMatches: map[string]bool{
 				"foo": false,
@@ -54,10 +55,21 @@ func TestPatternSet(t *testing.T) {
 				"baz": false,
 			},
 		},
+		{
+			name: "last_match_wins",
+			ps: mustNewPatternSet(t, []string{
+				"!baz",
+				"b*",
+			}),
+			expectMatches: map[string]bool{
+				"bar": true,
+				"baz": true,
+			},
+		},
 		{
 			name: "doublestar",
-			ps: mustNewPatternSet(t, map[string]bool{
-				"**/foo": true,
+			ps: mustNewPatternSet(t, []string{
+				"**/foo",
 			}),
 			expectMatches: map[string]bool{
 				"foo":                              true,
@@ -65,19 +77,114 @@ func TestPatternSet(t *testing.T) {
 				filepath.Join("baz", "bar", "foo"): true,
 			},
 		},
+		{
+			name: "unanchored",
+			ps: mustNewPatternSet(t, []string{
+				"*.txt",
+			}),
+			expectMatches: map[string]bool{
+				"foo.txt":                       true,
+				filepath.Join("bar", "foo.txt"): true,
+				"foo.md":                        false,
+			},
+		},
+		{
+			name: "anchored",
+			ps: mustNewPatternSet(t, []string{
+				"/foo",
+				"bar/baz",
+			}),
+			expectMatches: map[string]bool{
+				"foo":                              true,
+				filepath.Join("bar", "foo"):        false,
+				filepath.Join("bar", "baz"):        true,
+				filepath.Join("qux", "bar", "baz"): false,
+			},
+		},
+		{
+			name: "parent",
+			ps: mustNewPatternSet(t, []string{
+				"foo",
+			}),
+			expectMatches: map[string]bool{
+				filepath.Join("foo", "bar"):        true,
+				filepath.Join("foo", "bar", "baz"): true,
+			},
+		},
+		{
+			name: "reignore",
+			ps: mustNewPatternSet(t, []string{
+				".config/*",
+				"!.config/nvim",
+				".config/nvim/cache",
+			}),
+			expectMatches: map[string]bool{
+				filepath.Join(".config", "fish"):             true,
+				filepath.Join(".config", "nvim"):             false,
+				filepath.Join(".config", "nvim", "init.vim"): false,
+				filepath.Join(".config", "nvim", "cache"):    true,
+			},
+		},
+		{
+			name: "anchored",
+			ps:   mustParsePatternSet(t, "", ".", "foo\n**/bar\n", WithAnchoredPatterns(true)),
+			expectMatches: map[string]bool{
+				"foo":                       true,
+				filepath.Join("dir", "foo"): false,
+				"bar":                       true,
+				filepath.Join("dir", "bar"): true,
+			},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			for s, expectMatch := range tc.expectMatches {
-				assert.Equal(t, expectMatch, tc.ps.Match(s))
+				assert.Equal(t, expectMatch, tc.ps.Match(s, false), s)
 			}
 		})
 	}
 }
 
-func mustNewPatternSet(t *testing.T, patterns map[string]bool) *PatternSet {
+func TestPatternSetDirOnly(t *testing.T) {
+	ps := mustNewPatternSet(t, []string{
+		"foo/",
+	})
+	assert.True(t, ps.Match("foo", true))
+	assert.False(t, ps.Match("foo", false))
+	assert.True(t, ps.Match(filepath.Join("foo", "bar"), false))
+	assert.True(t, ps.Match(filepath.Join("bar", "foo"), true))
+}
+
+func TestPatternSetAddPatterns(t *testing.T) {
 	ps := NewPatternSet()
-	for pattern, exclude := range patterns {
-		require.NoError(t, ps.Add(pattern, exclude))
-	}
+	require.NoError(t, ps.AddPatterns(filepath.Join("dot_config", ".chezmoiignore"), ".config", []byte(""+
+		"# comment\n"+
+		"\n"+
+		"*.log # trailing comment\n"+
+		"!keep.log\n",
+	)))
+
+	patternMatch := ps.Explain(filepath.Join(".config", "app", "debug.log"), false)
+	require.NotNil(t, patternMatch)
+	assert.Equal(t, filepath.Join(".config", "app", "debug.log"), patternMatch.Name)
+	assert.Equal(t, filepath.Join("dot_config", ".chezmoiignore"), patternMatch.Pattern.SourceName)
+	assert.Equal(t, 3, patternMatch.Pattern.LineNumber)
+	assert.Equal(t, "*.log", patternMatch.Pattern.String())
+
+	patternMatch = ps.Explain(filepath.Join(".config", "keep.log"), false)
+	require.NotNil(t, patternMatch)
+	assert.Equal(t, 4, patternMatch.Pattern.LineNumber)
+	assert.Equal(t, "!keep.log", patternMatch.Pattern.String())
+	assert.False(t, ps.Match(filepath.Join(".config", "keep.log"), false))
+
+	assert.Nil(t, ps.Explain("debug.log", false))
+}
+
+func mustNewPatternSet(t *testing.T, patterns []string) *PatternSet {
+	return mustParsePatternSet(t, "", ".", strings.Join(patterns, "\n"))
+}
+
+func mustParsePatternSet(t *testing.T, sourceName, dir, data string, options ...PatternSetOption) *PatternSet {
+	ps := NewPatternSet(options...)
+	require.NoError(t, ps.AddPatterns(sourceName, dir, []byte(data)))
 	return ps
 }
@@ -0,0 +1,285 @@
+package chezmoi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"time"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// Plan operation types.
+const (
+	PlanOperationChmod        = "chmod"
+	PlanOperationMkdir        = "mkdir"
+	PlanOperationRemoveAll    = "removeAll"
+	PlanOperationRename       = "rename"
+	PlanOperationRunCmd       = "runCmd"
+	PlanOperationRunScript    = "runScript"
+	PlanOperationWriteFile    = "writeFile"
+	PlanOperationWriteSymlink = "writeSymlink"
+)
+
+// A PlanOperation is a single operation recorded by a PlanMutator.
+type PlanOperation struct {
+	Type     string      `json:"type" yaml:"type"`
+	Name     string      `json:"name" yaml:"name"`
+	Dir      string      `json:"dir,omitempty" yaml:"dir,omitempty"`
+	Args     []string    `json:"args,omitempty" yaml:"args,omitempty"`
+	NewName  string      `json:"newName,omitempty" yaml:"newName,omitempty"`
+	Linkname string      `json:"linkname,omitempty" yaml:"linkname,omitempty"`
+	Mode     os.FileMode `json:"mode,omitempty" yaml:"mode,omitempty"`
+	Contents []byte      `json:"contents,omitempty" yaml:"contents,omitempty"`
+
+	// ScriptState is the update to the persistent state to make after running
+	// a script, if any.
+	ScriptState *ScriptStateUpdate `json:"scriptState,omitempty" yaml:"scriptState,omitempty"`
+}
+
+// A Plan is a sequence of operations. Targets and DestHashes are not set by
+// PlanMutator, but can be set by the caller to record the targets that the plan
+// was created for and the state of the destination paths when the plan was
+// created.
+type Plan struct {
+	Targets    []string          `json:"targets,omitempty" yaml:"targets,omitempty"`
+	DestHashes map[string]string `json:"destHashes,omitempty" yaml:"destHashes,omitempty"`
+	Operations []PlanOperation   `json:"operations" yaml:"operations"`
+}
+
+// Apply performs p's operations in order with mutator. Files are read from fs
+// only to pass their current contents to mutator. In a dry run, scripts are
+// recorded instead of run. After each script is run, its update to the
+// persistent state, if any, is made.
+func (p *Plan) Apply(fs vfs.FS, mutator Mutator, applyOptions *ApplyOptions) error {
+	for _, operation := range p.Operations {
+		var err error
+		switch operation.Type {
+		case PlanOperationChmod:
+			err = mutator.Chmod(operation.Name, operation.Mode)
+		case PlanOperationMkdir:
+			err = mutator.Mkdir(operation.Name, operation.Mode)
+		case PlanOperationRemoveAll:
+			err = mutator.RemoveAll(operation.Name)
+		case PlanOperationRename:
+			err = mutator.Rename(operation.Name, operation.NewName)
+		case PlanOperationRunCmd:
+			//nolint:gosec
+			cmd := exec.Command(operation.Name, operation.Args...)
+			cmd.Dir = operation.Dir
+			err = mutator.RunCmd(cmd)
+		case PlanOperationRunScript:
+			if applyOptions.DryRun {
+				err = recordScript(mutator, operation.Name, operation.Dir, operation.Contents, operation.ScriptState)
+				break
+			}
+			if err = mutator.RunScript(operation.Name, operation.Dir, operation.Contents); err == nil && operation.ScriptState != nil {
+				err = operation.ScriptState.Apply(applyOptions, time.Now())
+			}
+		case PlanOperationWriteFile:
+			var currData []byte
+			currData, err = fs.ReadFile(operation.Name)
+			if err != nil && !os.IsNotExist(err) {
+				break
+			}
+			err = mutator.WriteFile(operation.Name, operation.Contents, operation.Mode, currData)
+		case PlanOperationWriteSymlink:
+			err = mutator.WriteSymlink(operation.Linkname, operation.Name)
+		default:
+			err = fmt.Errorf("%s: unknown operation type %q", operation.Name, operation.Type)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DestPaths returns the sorted destination paths that p's operations modify.
+func (p *Plan) DestPaths() []string {
+	destPathsMap := make(map[string]struct{})
+	for _, operation := range p.Operations {
+		switch operation.Type {
+		case PlanOperationRunCmd, PlanOperationRunScript:
+		case PlanOperationRename:
+			destPathsMap[operation.Name] = struct{}{}
+			destPathsMap[operation.NewName] = struct{}{}
+		default:
+			destPathsMap[operation.Name] = struct{}{}
+		}
+	}
+	destPaths := make([]string, 0, len(destPathsMap))
+	for destPath := range destPathsMap {
+		destPaths = append(destPaths, destPath)
+	}
+	sort.Strings(destPaths)
+	return destPaths
+}
+
+// DestHash returns a hash of the state of name in fs, including its type,
+// permissions, and contents or link target. It returns the empty string if
+// name does not exist.
+func DestHash(fs vfs.FS, name string) (string, error) {
+	info, err := fs.Lstat(name)
+	switch {
+	case os.IsNotExist(err):
+		return "", nil
+	case err != nil:
+		return "", err
+	}
+	h := sha256.New()
+	switch {
+	case info.Mode().IsDir():
+		fmt.Fprintf(h, "dir\x00%o\x00", info.Mode().Perm())
+	case info.Mode().IsRegular():
+		contents, err := fs.ReadFile(name)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "file\x00%o\x00", info.Mode().Perm())
+		_, _ = h.Write(contents)
+	case info.Mode()&os.ModeType == os.ModeSymlink:
+		linkname, err := fs.Readlink(name)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "symlink\x00%s", linkname)
+	default:
+		fmt.Fprintf(h, "other\x00%s", info.Mode())
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// A PlanMutator wraps a Mutator and records all of the mutating operations
+// that it executes.
+type PlanMutator struct {
+	m    Mutator
+	plan Plan
+}
+
+// NewPlanMutator returns a new PlanMutator.
+func NewPlanMutator(m Mutator) *PlanMutator {
+	return &PlanMutator{
+		m: m,
+		plan: Plan{
+			Operations: []PlanOperation{},
+		},
+	}
+}
+
+// Plan returns the operations recorded by m.
+func (m *PlanMutator) Plan() *Plan {
+	return &m.plan
+}
+
+// Chmod implements Mutator.Chmod.
+func (m *PlanMutator) Chmod(name string, mode os.FileMode) error {
+	m.record(PlanOperation{
+		Type: PlanOperationChmod,
+		Name: name,
+		Mode: mode,
+	})
+	return m.m.Chmod(name, mode)
+}
+
+// IdempotentCmdOutput implements Mutator.IdempotentCmdOutput.
+func (m *PlanMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
+	return m.m.IdempotentCmdOutput(cmd)
+}
+
+// Mkdir implements Mutator.Mkdir.
+func (m *PlanMutator) Mkdir(name string, perm os.FileMode) error {
+	m.record(PlanOperation{
+		Type: PlanOperationMkdir,
+		Name: name,
+		Mode: perm,
+	})
+	return m.m.Mkdir(name, perm)
+}
+
+// RemoveAll implements Mutator.RemoveAll.
+func (m *PlanMutator) RemoveAll(name string) error {
+	m.record(PlanOperation{
+		Type: PlanOperationRemoveAll,
+		Name: name,
+	})
+	return m.m.RemoveAll(name)
+}
+
+// Rename implements Mutator.Rename.
+func (m *PlanMutator) Rename(oldpath, newpath string) error {
+	m.record(PlanOperation{
+		Type:    PlanOperationRename,
+		Name:    oldpath,
+		NewName: newpath,
+	})
+	return m.m.Rename(oldpath, newpath)
+}
+
+// RunCmd implements Mutator.RunCmd.
+func (m *PlanMutator) RunCmd(cmd *exec.Cmd) error {
+	m.record(PlanOperation{
+		Type: PlanOperationRunCmd,
+		Name: cmd.Path,
+		Dir:  cmd.Dir,
+		Args: cmd.Args[1:],
+	})
+	return m.m.RunCmd(cmd)
+}
+
+// RecordScript implements ScriptRecorder.RecordScript.
+func (m *PlanMutator) RecordScript(scriptname, dir string, data []byte, stateUpdate *ScriptStateUpdate) error {
+	m.record(PlanOperation{
+		Type:        PlanOperationRunScript,
+		Name:        scriptname,
+		Dir:         dir,
+		Contents:    data,
+		ScriptState: stateUpdate,
+	})
+	return recordScript(m.m, scriptname, dir, data, stateUpdate)
+}
+
+// RunScript implements Mutator.RunScript.
+func (m *PlanMutator) RunScript(scriptname, dir string, data []byte) error {
+	m.record(PlanOperation{
+		Type:     PlanOperationRunScript,
+		Name:     scriptname,
+		Dir:      dir,
+		Contents: data,
+	})
+	return m.m.RunScript(scriptname, dir, data)
+}
+
+// Stat implements Mutator.Stat.
+func (m *PlanMutator) Stat(name string) (os.FileInfo, error) {
+	return m.m.Stat(name)
+}
+
+// WriteFile implements Mutator.WriteFile.
+func (m *PlanMutator) WriteFile(name string, data []byte, perm os.FileMode, currData []byte) error {
+	m.record(PlanOperation{
+		Type:     PlanOperationWriteFile,
+		Name:     name,
+		Mode:     perm,
+		Contents: data,
+	})
+	return m.m.WriteFile(name, data, perm, currData)
+}
+
+// WriteSymlink implements Mutator.WriteSymlink.
+func (m *PlanMutator) WriteSymlink(oldname, newname string) error {
+	m.record(PlanOperation{
+		Type:     PlanOperationWriteSymlink,
+		Name:     newname,
+		Linkname: oldname,
+	})
+	return m.m.WriteSymlink(oldname, newname)
+}
+
+// record appends operation to m's plan.
+func (m *PlanMutator) record(operation PlanOperation) {
+	m.plan.Operations = append(m.plan.Operations, operation)
+}
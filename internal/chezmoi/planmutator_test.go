@@ -0,0 +1,251 @@
+package chezmoi
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+var (
+	_ Mutator        = &PlanMutator{}
+	_ ScriptRecorder = &PlanMutator{}
+)
+
+func TestPlanMutator(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".bashrc": "# old contents of .bashrc\n",
+			"foo":     "# contents of foo\n",
+			".local/share/chezmoi": map[string]interface{}{
+				".chezmoiremove":        "foo\n",
+				"dot_bashrc":            "# contents of .bashrc\n",
+				"dir/file":              "# contents of dir/file\n",
+				"run_before_install.sh": "#!/bin/sh\n",
+				"symlink_link":          "dir/file",
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	ts := NewTargetState(
+		WithDestDir("/home/user"),
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+		WithUmask(0o22),
+	)
+	require.NoError(t, ts.Populate(fs, nil))
+
+	planMutator := NewPlanMutator(NullMutator{})
+	require.NoError(t, ts.Apply(fs, planMutator, false, &ApplyOptions{
+		DestDir: ts.DestDir,
+		DryRun:  true,
+		Ignore:  ts.TargetIgnore.Match,
+		Remove:  true,
+		Stdout:  os.Stdout,
+		Umask:   ts.Umask,
+	}))
+
+	assert.Equal(t, &Plan{
+		Operations: []PlanOperation{
+			{
+				Type: PlanOperationRemoveAll,
+				Name: "/home/user/foo",
+			},
+			{
+				Type:     PlanOperationRunScript,
+				Name:     "install.sh",
+				Dir:      "/home/user",
+				Contents: []byte("#!/bin/sh\n"),
+			},
+			{
+				Type:     PlanOperationWriteFile,
+				Name:     "/home/user/.bashrc",
+				Mode:     0o644,
+				Contents: []byte("# contents of .bashrc\n"),
+			},
+			{
+				Type: PlanOperationMkdir,
+				Name: "/home/user/dir",
+				Mode: 0o755,
+			},
+			{
+				Type:     PlanOperationWriteFile,
+				Name:     "/home/user/dir/file",
+				Mode:     0o644,
+				Contents: []byte("# contents of dir/file\n"),
+			},
+			{
+				Type:     PlanOperationWriteSymlink,
+				Name:     "/home/user/link",
+				Linkname: "dir/file",
+			},
+		},
+	}, planMutator.Plan())
+}
+
+func TestPlanMutatorWrapped(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			"run_install.sh": "#!/bin/sh\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	for _, tc := range []struct {
+		name string
+		wrap func(Mutator) Mutator
+	}{
+		{
+			name: "any",
+			wrap: func(m Mutator) Mutator {
+				return NewAnyMutator(m)
+			},
+		},
+		{
+			name: "backup",
+			wrap: func(m Mutator) Mutator {
+				return NewBackupMutator(m, fs, "/home/user/.local/state/chezmoi/backups")
+			},
+		},
+		{
+			name: "debug",
+			wrap: func(m Mutator) Mutator {
+				return NewDebugMutator(m)
+			},
+		},
+		{
+			name: "verbose",
+			wrap: func(m Mutator) Mutator {
+				return NewVerboseMutator(ioutil.Discard, m, false, 0)
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := NewTargetState(
+				WithDestDir("/home/user"),
+				WithSourceDir("/home/user/.local/share/chezmoi"),
+				WithUmask(0o22),
+			)
+			require.NoError(t, ts.Populate(fs, nil))
+
+			planMutator := NewPlanMutator(NullMutator{})
+			require.NoError(t, ts.Apply(fs, tc.wrap(planMutator), false, &ApplyOptions{
+				DestDir: ts.DestDir,
+				DryRun:  true,
+				Ignore:  ts.TargetIgnore.Match,
+				Stdout:  ioutil.Discard,
+				Umask:   ts.Umask,
+			}))
+
+			assert.Equal(t, &Plan{
+				Operations: []PlanOperation{
+					{
+						Type:     PlanOperationRunScript,
+						Name:     "install.sh",
+						Dir:      "/home/user",
+						Contents: []byte("#!/bin/sh\n"),
+					},
+				},
+			}, planMutator.Plan())
+		})
+	}
+}
+
+// A noScriptsMutator is a Mutator that does not run scripts.
+type noScriptsMutator struct {
+	Mutator
+}
+
+func (noScriptsMutator) RunScript(scriptname, dir string, data []byte) error {
+	return nil
+}
+
+func TestPlanApply(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".bashrc": "# old contents of .bashrc\n",
+			".local/share/chezmoi": map[string]interface{}{
+				"dot_bashrc":          "# contents of .bashrc\n",
+				"run_once_install.sh": "#!/bin/sh\n",
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	persistentState, err := NewBoltPersistentState(fs, "/home/user/.config/chezmoi/chezmoistate.boltdb", nil)
+	require.NoError(t, err)
+	defer persistentState.Close()
+	applyOptions := &ApplyOptions{
+		DestDir:           "/home/user",
+		Ignore:            func(string, bool) bool { return false },
+		PersistentState:   persistentState,
+		ScriptStateBucket: []byte("script"),
+		Stdout:            ioutil.Discard,
+		Umask:             0o22,
+	}
+
+	ts := NewTargetState(
+		WithDestDir("/home/user"),
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+		WithUmask(0o22),
+	)
+	require.NoError(t, ts.Populate(fs, nil))
+	planMutator := NewPlanMutator(NullMutator{})
+	applyOptions.DryRun = true
+	require.NoError(t, ts.Apply(fs, planMutator, false, applyOptions))
+	plan := planMutator.Plan()
+
+	// Changing the source state does not change the plan.
+	require.NoError(t, fs.WriteFile("/home/user/.local/share/chezmoi/dot_bashrc", []byte("# new contents of .bashrc\n"), 0o644))
+
+	applyOptions.DryRun = false
+	require.NoError(t, plan.Apply(fs, noScriptsMutator{NewFSMutator(fs)}, applyOptions))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestContentsString("# contents of .bashrc\n"),
+		),
+	)
+	require.Len(t, plan.Operations, 2)
+	require.NotNil(t, plan.Operations[1].ScriptState)
+	scriptStateData, err := persistentState.Get([]byte("script"), []byte(plan.Operations[1].ScriptState.Key))
+	require.NoError(t, err)
+	assert.NotNil(t, scriptStateData)
+}
+
+func TestDestHash(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			"dir":     &vfst.Dir{Perm: 0o755},
+			"file":    "# contents of file\n",
+			"symlink": &vfst.Symlink{Target: "file"},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	hashes := make(map[string]string)
+	for _, name := range []string{"dir", "file", "missing", "symlink"} {
+		hash, err := DestHash(fs, "/home/user/"+name)
+		require.NoError(t, err)
+		hashes[name] = hash
+	}
+	assert.Equal(t, "", hashes["missing"])
+	assert.NotEqual(t, hashes["dir"], hashes["file"])
+	assert.NotEqual(t, hashes["file"], hashes["symlink"])
+
+	require.NoError(t, fs.Chmod("/home/user/dir", 0o700))
+	hash, err := DestHash(fs, "/home/user/dir")
+	require.NoError(t, err)
+	assert.NotEqual(t, hashes["dir"], hash)
+
+	require.NoError(t, fs.WriteFile("/home/user/file", []byte("# new contents of file\n"), 0o644))
+	hash, err = DestHash(fs, "/home/user/file")
+	require.NoError(t, err)
+	assert.NotEqual(t, hashes["file"], hash)
+}
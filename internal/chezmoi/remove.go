@@ -0,0 +1,97 @@
+package chezmoi
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// A RemoveAttributes holds attributes parsed from a source remove name.
+type RemoveAttributes struct {
+	Name string
+}
+
+// A Remove represents a target that must not exist.
+type Remove struct {
+	sourceName string
+	targetName string
+}
+
+type removeConcreteValue struct {
+	Type       string `json:"type" yaml:"type"`
+	SourcePath string `json:"sourcePath" yaml:"sourcePath"`
+	TargetPath string `json:"targetPath" yaml:"targetPath"`
+}
+
+// ParseRemoveAttributes parses a source remove name.
+func ParseRemoveAttributes(sourceName string) RemoveAttributes {
+	name := strings.TrimPrefix(sourceName, removePrefix)
+	if strings.HasPrefix(name, dotPrefix) {
+		name = "." + strings.TrimPrefix(name, dotPrefix)
+	}
+	return RemoveAttributes{
+		Name: name,
+	}
+}
+
+// SourceName returns ra's source name.
+func (ra RemoveAttributes) SourceName() string {
+	if strings.HasPrefix(ra.Name, ".") {
+		return removePrefix + dotPrefix + strings.TrimPrefix(ra.Name, ".")
+	}
+	return removePrefix + ra.Name
+}
+
+// AppendAllEntries returns allEntries unchanged.
+func (r *Remove) AppendAllEntries(allEntries []Entry) []Entry {
+	return allEntries
+}
+
+// Apply removes r's target from fs, if it exists.
+func (r *Remove) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions) error {
+	targetPath := filepath.Join(applyOptions.DestDir, r.targetName)
+	info, err := fs.Lstat(targetPath)
+	switch {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return err
+	case applyOptions.Ignore(r.targetName, info.IsDir()):
+		return nil
+	}
+	return mutator.RemoveAll(targetPath)
+}
+
+// ConcreteValue implements Entry.ConcreteValue.
+func (r *Remove) ConcreteValue(ignore func(string, bool) bool, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error) {
+	if ignore(r.targetName, false) {
+		return nil, nil
+	}
+	return &removeConcreteValue{
+		Type:       "remove",
+		SourcePath: filepath.Join(sourceDir, r.SourceName()),
+		TargetPath: r.TargetName(),
+	}, nil
+}
+
+// Evaluate evaluates r, which is a no-op.
+func (r *Remove) Evaluate(ignore func(string, bool) bool) error {
+	return nil
+}
+
+// SourceName implements Entry.SourceName.
+func (r *Remove) SourceName() string {
+	return r.sourceName
+}
+
+// TargetName implements Entry.TargetName.
+func (r *Remove) TargetName() string {
+	return r.targetName
+}
+
+// archive does nothing, as archives only contain targets that exist.
+func (r *Remove) archive(w ArchiveWriter, ignore func(string, bool) bool, umask os.FileMode) error {
+	return nil
+}
@@ -0,0 +1,32 @@
+package chezmoi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoveAttributes(t *testing.T) {
+	for _, tc := range []struct {
+		sourceName string
+		ra         RemoveAttributes
+	}{
+		{
+			sourceName: "remove_foo",
+			ra: RemoveAttributes{
+				Name: "foo",
+			},
+		},
+		{
+			sourceName: "remove_dot_oldrc",
+			ra: RemoveAttributes{
+				Name: ".oldrc",
+			},
+		},
+	} {
+		t.Run(tc.sourceName, func(t *testing.T) {
+			assert.Equal(t, tc.ra, ParseRemoveAttributes(tc.sourceName))
+			assert.Equal(t, tc.sourceName, tc.ra.SourceName())
+		})
+	}
+}
@@ -1,14 +1,11 @@
 package chezmoi
 
 import (
-	"archive/tar"
 	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
-	"io/ioutil"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
@@ -16,26 +13,54 @@ import (
 	vfs "github.com/twpayne/go-vfs"
 )
 
-// FIXME allow encrypted scripts
-// FIXME add pre- and post- attributes
-
 // A ScriptAttributes holds attributes parsed from a source script name.
 type ScriptAttributes struct {
-	Name     string
-	Once     bool
-	Template bool
+	Name      string
+	After     bool
+	Before    bool
+	Encrypted bool
+	OnChange  bool
+	Once      bool
+	Template  bool
 }
 
 // A ScriptState represents the state of a script.
 type ScriptState struct {
-	Name       string    `json:"name"`
-	ExecutedAt time.Time `json:"executedAt"`
+	Name           string    `json:"name"`
+	ContentsSHA256 string    `json:"contentsSHA256,omitempty"`
+	ExecutedAt     time.Time `json:"executedAt"`
+}
+
+// A ScriptStateUpdate is the update to the persistent state that is made after
+// a script is run.
+type ScriptStateUpdate struct {
+	Key            string `json:"key" yaml:"key"`
+	Name           string `json:"name" yaml:"name"`
+	ContentsSHA256 string `json:"contentsSHA256,omitempty" yaml:"contentsSHA256,omitempty"`
+}
+
+// Apply records in applyOptions.PersistentState that the script was executed
+// at executedAt.
+func (u *ScriptStateUpdate) Apply(applyOptions *ApplyOptions, executedAt time.Time) error {
+	scriptStateData, err := json.Marshal(&ScriptState{
+		Name:           u.Name,
+		ContentsSHA256: u.ContentsSHA256,
+		ExecutedAt:     executedAt,
+	})
+	if err != nil {
+		return err
+	}
+	return applyOptions.PersistentState.Set(applyOptions.ScriptStateBucket, []byte(u.Key), scriptStateData)
 }
 
 // A Script represents a script to run.
 type Script struct {
 	sourceName       string
 	targetName       string
+	After            bool
+	Before           bool
+	Encrypted        bool
+	OnChange         bool
 	Once             bool
 	Template         bool
 	contents         []byte
@@ -47,41 +72,87 @@ type scriptConcreteValue struct {
 	Type       string `json:"type" yaml:"type"`
 	SourcePath string `json:"sourcePath" yaml:"sourcePath"`
 	TargetPath string `json:"targetPath" yaml:"targetPath"`
+	After      bool   `json:"after" yaml:"after"`
+	Before     bool   `json:"before" yaml:"before"`
+	Encrypted  bool   `json:"encrypted" yaml:"encrypted"`
+	OnChange   bool   `json:"onChange" yaml:"onChange"`
 	Once       bool   `json:"once" yaml:"once"`
 	Template   bool   `json:"template" yaml:"template"`
 	Contents   string `json:"contents" yaml:"contents"`
 }
 
-// ParseScriptAttributes parses a source script file name.
-func ParseScriptAttributes(sourceName string) ScriptAttributes {
+// ParseScriptAttributes parses a source script file name. encryptedSuffix is
+// removed from the names of encrypted scripts.
+func ParseScriptAttributes(sourceName, encryptedSuffix string) ScriptAttributes {
 	name := strings.TrimPrefix(sourceName, runPrefix)
+	after := false
+	before := false
+	encrypted := false
+	onChange := false
 	once := false
 	template := false
-	if strings.HasPrefix(name, oncePrefix) {
+	switch {
+	case strings.HasPrefix(name, oncePrefix):
 		once = true
 		name = strings.TrimPrefix(name, oncePrefix)
+	case strings.HasPrefix(name, onChangePrefix):
+		onChange = true
+		name = strings.TrimPrefix(name, onChangePrefix)
+	}
+	switch {
+	case strings.HasPrefix(name, beforePrefix):
+		before = true
+		name = strings.TrimPrefix(name, beforePrefix)
+	case strings.HasPrefix(name, afterPrefix):
+		after = true
+		name = strings.TrimPrefix(name, afterPrefix)
+	}
+	if strings.HasPrefix(name, encryptedPrefix) {
+		encrypted = true
+		name = strings.TrimPrefix(name, encryptedPrefix)
+		name = strings.TrimSuffix(name, encryptedSuffix)
 	}
 	if strings.HasSuffix(name, TemplateSuffix) {
 		template = true
 		name = strings.TrimSuffix(name, TemplateSuffix)
 	}
 	return ScriptAttributes{
-		Name:     name,
-		Once:     once,
-		Template: template,
+		Name:      name,
+		After:     after,
+		Before:    before,
+		Encrypted: encrypted,
+		OnChange:  onChange,
+		Once:      once,
+		Template:  template,
 	}
 }
 
-// SourceName returns sa's source name.
-func (sa ScriptAttributes) SourceName() string {
+// SourceName returns sa's source name. encryptedSuffix is appended to the names
+// of encrypted scripts.
+func (sa ScriptAttributes) SourceName(encryptedSuffix string) string {
 	sourceName := runPrefix
-	if sa.Once {
+	switch {
+	case sa.Once:
 		sourceName += oncePrefix
+	case sa.OnChange:
+		sourceName += onChangePrefix
+	}
+	switch {
+	case sa.Before:
+		sourceName += beforePrefix
+	case sa.After:
+		sourceName += afterPrefix
+	}
+	if sa.Encrypted {
+		sourceName += encryptedPrefix
 	}
 	sourceName += sa.Name
 	if sa.Template {
 		sourceName += TemplateSuffix
 	}
+	if sa.Encrypted {
+		sourceName += encryptedSuffix
+	}
 	return sourceName
 }
 
@@ -92,7 +163,7 @@ func (s *Script) AppendAllEntries(allEntries []Entry) []Entry {
 
 // Apply runs s.
 func (s *Script) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions) error {
-	if applyOptions.Ignore(s.targetName) {
+	if applyOptions.Ignore(s.targetName, false) {
 		return nil
 	}
 	contents, err := s.Contents()
@@ -103,16 +174,36 @@ func (s *Script) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Ap
 		return nil
 	}
 
+	// once_ scripts are keyed on their target name and contents, so they are
+	// run once for each distinct contents. onchange_ scripts are keyed on
+	// their target name only and record the SHA256 of their contents, so they
+	// are re-run whenever their contents differ from the last run.
+	contentsSHA256Arr := sha256.Sum256(contents)
+	contentsSHA256 := hex.EncodeToString(contentsSHA256Arr[:])
 	var key []byte
-	if s.Once {
-		contentsKeyArr := sha256.Sum256(contents)
-		key = []byte(s.targetName + ":" + hex.EncodeToString(contentsKeyArr[:]))
+	switch {
+	case s.Once:
+		key = []byte(s.targetName + ":" + contentsSHA256)
+	case s.OnChange:
+		key = []byte(s.targetName)
+	}
+	if key != nil {
 		scriptStateData, err := applyOptions.PersistentState.Get(applyOptions.ScriptStateBucket, key)
 		if err != nil {
 			return err
 		}
-		if scriptStateData != nil {
+		switch {
+		case scriptStateData == nil:
+		case s.Once:
 			return nil
+		case s.OnChange:
+			var scriptState ScriptState
+			if err := json.Unmarshal(scriptStateData, &scriptState); err != nil {
+				return err
+			}
+			if scriptState.ContentsSHA256 == contentsSHA256 {
+				return nil
+			}
 		}
 	}
 
@@ -121,61 +212,47 @@ func (s *Script) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Ap
 			return err
 		}
 	}
-	if applyOptions.DryRun {
-		return nil
-	}
-
-	// Write the temporary script file. Put the randomness on the front of the
-	// filename to preserve any file extension for Windows scripts.
-	f, err := ioutil.TempFile("", "*."+filepath.Base(s.targetName))
-	if err != nil {
-		return err
-	}
 
-	defer func() {
-		_ = os.RemoveAll(f.Name())
-	}()
-	if err := os.Chmod(f.Name(), 0o700); err != nil {
-		return err
+	// Scripts that run before all other entries may be run before their
+	// parent directory is created, so run them in the closest existing
+	// ancestor directory.
+	dir := filepath.Join(applyOptions.DestDir, filepath.Dir(s.targetName))
+	for dir != applyOptions.DestDir {
+		if _, err := fs.Stat(dir); err == nil {
+			break
+		}
+		dir = filepath.Dir(dir)
 	}
-	if _, err := f.Write(contents); err != nil {
-		return err
+	var stateUpdate *ScriptStateUpdate
+	if key != nil {
+		stateUpdate = &ScriptStateUpdate{
+			Key:  string(key),
+			Name: s.sourceName,
+		}
+		if s.OnChange {
+			stateUpdate.ContentsSHA256 = contentsSHA256
+		}
 	}
-	if err := f.Close(); err != nil {
-		return err
+
+	// Never run scripts in a dry run, but let mutators that record scripts
+	// record them.
+	if applyOptions.DryRun {
+		return recordScript(mutator, s.targetName, dir, contents, stateUpdate)
 	}
 
-	// Run the temporary script file.
-	//nolint:gosec
-	c := exec.Command(f.Name())
-	c.Dir = filepath.Join(applyOptions.DestDir, filepath.Dir(s.targetName))
-	c.Stdout = os.Stdout
-	c.Stderr = os.Stderr
-	c.Stdin = os.Stdin
-	if err := c.Run(); err != nil {
+	if err := mutator.RunScript(s.targetName, dir, contents); err != nil {
 		return err
 	}
 
-	if s.Once {
-		scriptState := &ScriptState{
-			Name:       s.sourceName,
-			ExecutedAt: time.Now(),
-		}
-		scriptStateData, err := json.Marshal(&scriptState)
-		if err != nil {
-			return err
-		}
-		if err := applyOptions.PersistentState.Set(applyOptions.ScriptStateBucket, key, scriptStateData); err != nil {
-			return err
-		}
+	if stateUpdate != nil {
+		return stateUpdate.Apply(applyOptions, time.Now())
 	}
-
-	return err
+	return nil
 }
 
 // ConcreteValue implements Entry.ConcreteValue.
-func (s *Script) ConcreteValue(ignore func(string) bool, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error) {
-	if ignore(s.targetName) {
+func (s *Script) ConcreteValue(ignore func(string, bool) bool, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error) {
+	if ignore(s.targetName, false) {
 		return nil, nil
 	}
 	contents, err := s.Contents()
@@ -186,6 +263,10 @@ func (s *Script) ConcreteValue(ignore func(string) bool, sourceDir string, umask
 		Type:       "script",
 		SourcePath: filepath.Join(sourceDir, s.SourceName()),
 		TargetPath: s.TargetName(),
+		After:      s.After,
+		Before:     s.Before,
+		Encrypted:  s.Encrypted,
+		OnChange:   s.OnChange,
 		Once:       s.Once,
 		Template:   s.Template,
 		Contents:   string(contents),
@@ -202,8 +283,8 @@ func (s *Script) Contents() ([]byte, error) {
 }
 
 // Evaluate evaluates s's contents.
-func (s *Script) Evaluate(ignore func(string) bool) error {
-	if ignore(s.targetName) {
+func (s *Script) Evaluate(ignore func(string, bool) bool) error {
+	if ignore(s.targetName, false) {
 		return nil
 	}
 	_, err := s.Contents()
@@ -221,22 +302,13 @@ func (s *Script) TargetName() string {
 }
 
 // archive writes s to w.
-func (s *Script) archive(w *tar.Writer, ignore func(string) bool, headerTemplate *tar.Header, umask os.FileMode) error {
-	if ignore(s.targetName) {
+func (s *Script) archive(w ArchiveWriter, ignore func(string, bool) bool, umask os.FileMode) error {
+	if ignore(s.targetName, false) {
 		return nil
 	}
 	contents, err := s.Contents()
 	if err != nil {
 		return err
 	}
-	header := *headerTemplate
-	header.Typeflag = tar.TypeReg
-	header.Name = s.targetName
-	header.Size = int64(len(contents))
-	header.Mode = int64(0o777 &^ umask)
-	if err := w.WriteHeader(&header); err != nil {
-		return nil
-	}
-	_, err = w.Write(contents)
-	return err
+	return w.WriteFile(s.targetName, contents, 0o777&^umask)
 }
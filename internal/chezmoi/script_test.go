@@ -0,0 +1,111 @@
+package chezmoi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestScriptAttributes(t *testing.T) {
+	for _, tc := range []struct {
+		sourceName      string
+		encryptedSuffix string
+		sa              ScriptAttributes
+	}{
+		{
+			sourceName: "run_foo",
+			sa: ScriptAttributes{
+				Name: "foo",
+			},
+		},
+		{
+			sourceName: "run_once_foo.tmpl",
+			sa: ScriptAttributes{
+				Name:     "foo",
+				Once:     true,
+				Template: true,
+			},
+		},
+		{
+			sourceName: "run_onchange_after_foo.sh",
+			sa: ScriptAttributes{
+				Name:     "foo.sh",
+				After:    true,
+				OnChange: true,
+			},
+		},
+		{
+			sourceName: "run_before_foo",
+			sa: ScriptAttributes{
+				Name:   "foo",
+				Before: true,
+			},
+		},
+		{
+			sourceName: "run_once_after_foo.tmpl",
+			sa: ScriptAttributes{
+				Name:     "foo",
+				After:    true,
+				Once:     true,
+				Template: true,
+			},
+		},
+		{
+			sourceName:      "run_encrypted_foo.sh.asc",
+			encryptedSuffix: ".asc",
+			sa: ScriptAttributes{
+				Name:      "foo.sh",
+				Encrypted: true,
+			},
+		},
+		{
+			sourceName:      "run_once_before_encrypted_foo.sh.tmpl.age",
+			encryptedSuffix: ".age",
+			sa: ScriptAttributes{
+				Name:      "foo.sh",
+				Before:    true,
+				Encrypted: true,
+				Once:      true,
+				Template:  true,
+			},
+		},
+	} {
+		t.Run(tc.sourceName, func(t *testing.T) {
+			assert.Equal(t, tc.sa, ParseScriptAttributes(tc.sourceName, tc.encryptedSuffix))
+			assert.Equal(t, tc.sourceName, tc.sa.SourceName(tc.encryptedSuffix))
+		})
+	}
+}
+
+func TestEncryptedScript(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			"run_once_encrypted_install.sh.tmpl.rev": string(reverseBytes([]byte("#!/bin/sh\necho {{ .token }}\n"))),
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	ts := NewTargetState(
+		WithDestDir("/home/user"),
+		WithEncryption(testEncryption{}),
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+		WithTemplateData(map[string]interface{}{
+			"token": "secret",
+		}),
+	)
+	require.NoError(t, ts.Populate(fs, nil))
+
+	entry, ok := ts.Entries["install.sh"]
+	require.True(t, ok)
+	script, ok := entry.(*Script)
+	require.True(t, ok)
+	assert.True(t, script.Encrypted)
+	assert.True(t, script.Once)
+	assert.True(t, script.Template)
+	contents, err := script.Contents()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("#!/bin/sh\necho secret\n"), contents)
+}
@@ -1,7 +1,6 @@
 package chezmoi
 
 import (
-	"archive/tar"
 	"os"
 	"path/filepath"
 	"strings"
@@ -13,6 +12,7 @@ import (
 type Symlink struct {
 	sourceName       string
 	targetName       string
+	external         bool
 	Template         bool
 	linkname         string
 	linknameErr      error
@@ -34,7 +34,7 @@ func (s *Symlink) AppendAllEntries(allEntries []Entry) []Entry {
 
 // Apply ensures that the state of s's target in fs matches s.
 func (s *Symlink) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions) error {
-	if applyOptions.Ignore(s.targetName) {
+	if applyOptions.Ignore(s.targetName, false) {
 		return nil
 	}
 	target, err := s.Linkname()
@@ -70,8 +70,8 @@ func (s *Symlink) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *A
 }
 
 // ConcreteValue implements Entry.ConcreteValue.
-func (s *Symlink) ConcreteValue(ignore func(string) bool, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error) {
-	if ignore(s.targetName) {
+func (s *Symlink) ConcreteValue(ignore func(string, bool) bool, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error) {
+	if ignore(s.targetName, false) {
 		return nil, nil
 	}
 	linkname, err := s.Linkname()
@@ -88,8 +88,8 @@ func (s *Symlink) ConcreteValue(ignore func(string) bool, sourceDir string, umas
 }
 
 // Evaluate evaluates s's target.
-func (s *Symlink) Evaluate(ignore func(string) bool) error {
-	if ignore(s.targetName) {
+func (s *Symlink) Evaluate(ignore func(string, bool) bool) error {
+	if ignore(s.targetName, false) {
 		return nil
 	}
 	_, err := s.Linkname()
@@ -116,17 +116,13 @@ func (s *Symlink) TargetName() string {
 }
 
 // archive writes s to w.
-func (s *Symlink) archive(w *tar.Writer, ignore func(string) bool, headerTemplate *tar.Header, umask os.FileMode) error {
-	if ignore(s.targetName) {
+func (s *Symlink) archive(w ArchiveWriter, ignore func(string, bool) bool, umask os.FileMode) error {
+	if ignore(s.targetName, false) {
 		return nil
 	}
 	linkname, err := s.Linkname()
 	if err != nil {
 		return err
 	}
-	header := *headerTemplate
-	header.Name = s.targetName
-	header.Typeflag = tar.TypeSymlink
-	header.Linkname = linkname
-	return w.WriteHeader(&header)
+	return w.WriteSymlink(s.targetName, linkname)
 }
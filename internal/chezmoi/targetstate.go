@@ -1,10 +1,7 @@
 package chezmoi
 
 import (
-	"archive/tar"
-	"bufio"
 	"bytes"
-	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -12,9 +9,9 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"text/template"
 
-	"github.com/bmatcuk/doublestar/v2"
 	"github.com/coreos/go-semver/semver"
 	vfs "github.com/twpayne/go-vfs"
 )
@@ -31,6 +28,7 @@ const (
 
 // An AddOptions contains options for TargetState.Add.
 type AddOptions struct {
+	Create       bool
 	Empty        bool
 	Encrypt      bool
 	Exact        bool
@@ -39,8 +37,8 @@ type AddOptions struct {
 	AutoTemplate bool
 }
 
-// An ImportTAROptions contains options for TargetState.ImportTAR.
-type ImportTAROptions struct {
+// An ImportArchiveOptions contains options for TargetState.ImportArchive.
+type ImportArchiveOptions struct {
 	DestinationDir  string
 	Exact           bool
 	StripComponents int
@@ -48,28 +46,83 @@ type ImportTAROptions struct {
 
 // A PopulateOptions contains options for TargetState.Populate.
 type PopulateOptions struct {
-	ExecuteTemplates bool
+	ExecuteTemplates    bool
+	RefreshExternals    bool
+	CachedExternalsOnly bool
+	SkipModifyScripts   bool
+}
+
+// An EvaluateError is an error encountered while evaluating a single entry.
+type EvaluateError struct {
+	TargetName string
+	Err        error
+}
+
+// EvaluateErrors is a list of errors encountered while evaluating entries, in
+// target name order.
+type EvaluateErrors []*EvaluateError
+
+func (e *EvaluateError) Error() string {
+	return e.TargetName + ": " + e.Err.Error()
+}
+
+func (e *EvaluateError) Unwrap() error {
+	return e.Err
+}
+
+func (e EvaluateErrors) Error() string {
+	errStrs := make([]string, 0, len(e))
+	for _, err := range e {
+		errStrs = append(errStrs, err.Error())
+	}
+	return strings.Join(errStrs, "\n")
 }
 
 // A TargetState represents the root target state.
 type TargetState struct {
-	DestDir         string
-	Entries         map[string]Entry
-	GPG             *GPG
-	MinVersion      *semver.Version
-	SourceDir       string
-	TargetIgnore    *PatternSet
-	TargetRemove    *PatternSet
-	TemplateData    map[string]interface{}
-	TemplateFuncs   template.FuncMap
-	TemplateOptions []string
-	Templates       map[string]*template.Template
-	Umask           os.FileMode
+	CacheDir            string
+	CacheFS             vfs.FS
+	Concurrency         int
+	DestDir             string
+	Encryption          Encryption
+	Entries             map[string]Entry
+	MinVersion          *semver.Version
+	SourceDir           string
+	TargetIgnore        *PatternSet
+	TargetRemove        *PatternSet
+	TemplateData        map[string]interface{}
+	TemplateDataSources map[string]string
+	TemplateFuncs       template.FuncMap
+	TemplateOptions     []string
+	Templates           map[string]*template.Template
+	Umask               os.FileMode
 }
 
 // A TargetStateOption sets an option on a TargeState.
 type TargetStateOption func(*TargetState)
 
+// WithCacheDir sets the cache directory.
+func WithCacheDir(cacheDir string) TargetStateOption {
+	return func(ts *TargetState) {
+		ts.CacheDir = cacheDir
+	}
+}
+
+// WithCacheFS sets the filesystem in which the cache directory is.
+func WithCacheFS(cacheFS vfs.FS) TargetStateOption {
+	return func(ts *TargetState) {
+		ts.CacheFS = cacheFS
+	}
+}
+
+// WithConcurrency sets the maximum number of entries that are evaluated
+// concurrently.
+func WithConcurrency(concurrency int) TargetStateOption {
+	return func(ts *TargetState) {
+		ts.Concurrency = concurrency
+	}
+}
+
 // WithDestDir sets DestDir.
 func WithDestDir(destDir string) TargetStateOption {
 	return func(ts *TargetState) {
@@ -77,17 +130,17 @@ func WithDestDir(destDir string) TargetStateOption {
 	}
 }
 
-// WithEntries sets the entries.
-func WithEntries(entries map[string]Entry) TargetStateOption {
+// WithEncryption sets the encryption.
+func WithEncryption(encryption Encryption) TargetStateOption {
 	return func(ts *TargetState) {
-		ts.Entries = entries
+		ts.Encryption = encryption
 	}
 }
 
-// WithGPG sets the GPG options.
-func WithGPG(gpg *GPG) TargetStateOption {
+// WithEntries sets the entries.
+func WithEntries(entries map[string]Entry) TargetStateOption {
 	return func(ts *TargetState) {
-		ts.GPG = gpg
+		ts.Entries = entries
 	}
 }
 
@@ -157,9 +210,10 @@ func WithUmask(umask os.FileMode) TargetStateOption {
 // NewTargetState creates a new TargetState with the given options.
 func NewTargetState(options ...TargetStateOption) *TargetState {
 	ts := &TargetState{
+		Concurrency:     1,
 		Entries:         make(map[string]Entry),
 		TargetIgnore:    NewPatternSet(),
-		TargetRemove:    NewPatternSet(),
+		TargetRemove:    NewPatternSet(WithAnchoredPatterns(true)),
 		TemplateOptions: DefaultTemplateOptions,
 	}
 	for _, o := range options {
@@ -217,6 +271,9 @@ func (ts *TargetState) Add(fs vfs.FS, addOptions AddOptions, targetPath string,
 		} else if _, ok := parentEntry.(*Dir); !ok {
 			return fmt.Errorf("%s: not a directory", parentDirName)
 		}
+		if err := CheckSourceFile(parentEntry); err != nil {
+			return err
+		}
 		parentDir := parentEntry.(*Dir)
 		parentDirSourceName = parentDir.sourceName
 		entries = parentDir.Entries
@@ -248,6 +305,9 @@ func (ts *TargetState) Add(fs vfs.FS, addOptions AddOptions, targetPath string,
 			case os.IsNotExist(err):
 				return nil
 			case err == nil:
+				if err := CheckSourceFile(entry); err != nil {
+					return err
+				}
 				return mutator.RemoveAll(filepath.Join(ts.SourceDir, entry.SourceName()))
 			default:
 				return err
@@ -260,12 +320,6 @@ func (ts *TargetState) Add(fs vfs.FS, addOptions AddOptions, targetPath string,
 		if addOptions.Template && addOptions.AutoTemplate {
 			contents = autoTemplate(contents, ts.TemplateData)
 		}
-		if addOptions.Encrypt {
-			contents, err = ts.GPG.Encrypt(targetPath, contents)
-			if err != nil {
-				return err
-			}
-		}
 		perm := info.Mode().Perm()
 		private, err := IsPrivate(fs, targetPath, perm&0o77 == 0)
 		if err != nil {
@@ -274,7 +328,7 @@ func (ts *TargetState) Add(fs vfs.FS, addOptions AddOptions, targetPath string,
 		if private {
 			perm &^= 0o77
 		}
-		return ts.addFile(targetName, entries, parentDirSourceName, info, perm, addOptions.Encrypt, addOptions.Template, contents, mutator)
+		return ts.addFile(targetName, entries, parentDirSourceName, info, perm, addOptions.Create, addOptions.Encrypt, addOptions.Template, contents, mutator)
 	case info.Mode()&os.ModeType == os.ModeSymlink:
 		linkname, err := fs.Readlink(targetPath)
 		if err != nil {
@@ -299,28 +353,21 @@ func (ts *TargetState) AllEntries() []Entry {
 func (ts *TargetState) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions) error {
 	if applyOptions.Remove {
 		// Build a set of targets to remove.
-		targetsToRemove := make(map[string]struct{})
-		includes := make([]string, 0, len(ts.TargetRemove.includes))
-		for include := range ts.TargetRemove.includes {
-			includes = append(includes, include)
+		removeNames, err := ts.TargetRemove.Glob(fs, ts.DestDir)
+		if err != nil {
+			return err
 		}
-		for _, include := range includes {
-			matches, err := doublestar.GlobOS(doubleStarOS{FS: fs}, filepath.Join(ts.DestDir, include))
+		targetsToRemove := make(map[string]struct{})
+		for _, removeName := range removeNames {
+			info, err := fs.Lstat(filepath.Join(ts.DestDir, removeName))
 			if err != nil {
 				return err
 			}
-			for _, match := range matches {
-				relPath := strings.TrimPrefix(match, ts.DestDir+string(filepath.Separator))
-				// Don't remove targets that are ignored.
-				if ts.TargetIgnore.Match(relPath) {
-					continue
-				}
-				// Don't remove targets that are excluded from remove.
-				if !ts.TargetRemove.Match(relPath) {
-					continue
-				}
-				targetsToRemove[match] = struct{}{}
+			// Don't remove targets that are ignored.
+			if ts.TargetIgnore.Match(removeName, info.IsDir()) {
+				continue
 			}
+			targetsToRemove[filepath.Join(ts.DestDir, removeName)] = struct{}{}
 		}
 
 		// FIXME check that the set of targets to remove does not intersect wth
@@ -340,27 +387,89 @@ func (ts *TargetState) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptio
 		}
 	}
 
-	for _, entryName := range sortedEntryNames(ts.Entries) {
-		if err := ts.Entries[entryName].Apply(fs, mutator, follow, applyOptions); err != nil {
+	return ts.ApplyEntries(fs, mutator, follow, applyOptions, sortedEntries(ts.Entries))
+}
+
+// ApplyEntries applies entries, which must be in ts. Scripts in entries that
+// should be run before or after all other entries are run first or last
+// respectively. All other entries are evaluated concurrently before they are
+// applied in order.
+func (ts *TargetState) ApplyEntries(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions, entries []Entry) error {
+	// Run scripts that should be run before all other entries are applied.
+	beforeScripts := appendScripts(nil, entries, func(s *Script) bool {
+		return s.Before
+	})
+	for _, script := range beforeScripts {
+		if err := script.Apply(fs, mutator, follow, applyOptions); err != nil {
+			return err
+		}
+	}
+
+	// Evaluate all other entries after the before scripts have run, as the
+	// before scripts might install programs that templates depend on. Entries
+	// that cannot be evaluated are ignored and their errors are returned after
+	// all other entries have been applied.
+	evaluateErrors := ts.evaluateEntries(entries, func(entry Entry) bool {
+		return !isBeforeOrAfterScript(entry)
+	})
+	if len(evaluateErrors) != 0 {
+		failedTargetNames := make(map[string]struct{}, len(evaluateErrors))
+		for _, evaluateError := range evaluateErrors {
+			failedTargetNames[evaluateError.TargetName] = struct{}{}
+		}
+		ignore := applyOptions.Ignore
+		applyOptionsCopy := *applyOptions
+		applyOptionsCopy.Ignore = func(targetName string, isDir bool) bool {
+			if _, ok := failedTargetNames[targetName]; ok {
+				return true
+			}
+			return ignore(targetName, isDir)
+		}
+		applyOptions = &applyOptionsCopy
+	}
+
+	for _, entry := range entries {
+		if isBeforeOrAfterScript(entry) {
+			continue
+		}
+		if err := entry.Apply(fs, mutator, follow, applyOptions); err != nil {
 			return err
 		}
 	}
+
+	// Run scripts that should be run after all other entries are applied.
+	afterScripts := appendScripts(nil, entries, func(s *Script) bool {
+		return s.After
+	})
+	for _, script := range afterScripts {
+		if err := script.Apply(fs, mutator, follow, applyOptions); err != nil {
+			return err
+		}
+	}
+
+	if len(evaluateErrors) != 0 {
+		return evaluateErrors
+	}
 	return nil
 }
 
-// Archive writes ts to w.
-func (ts *TargetState) Archive(w *tar.Writer, umask os.FileMode) error {
+// Archive writes an archive of ts in format to w.
+func (ts *TargetState) Archive(w io.Writer, format string, umask os.FileMode) error {
 	headerTemplate, err := ts.getTarHeaderTemplate()
 	if err != nil {
 		return err
 	}
 
+	archiveWriter, err := NewArchiveWriter(w, format, headerTemplate)
+	if err != nil {
+		return err
+	}
 	for _, entryName := range sortedEntryNames(ts.Entries) {
-		if err := ts.Entries[entryName].archive(w, ts.TargetIgnore.Match, headerTemplate, umask); err != nil {
+		if err := ts.Entries[entryName].archive(archiveWriter, ts.TargetIgnore.Match, umask); err != nil {
 			return err
 		}
 	}
-	return nil
+	return archiveWriter.Close()
 }
 
 // ConcreteValue returns a value suitable for serialization.
@@ -380,10 +489,17 @@ func (ts *TargetState) ConcreteValue(recursive bool) (interface{}, error) {
 
 // Evaluate evaluates all of the entries in ts.
 func (ts *TargetState) Evaluate() error {
-	for _, entryName := range sortedEntryNames(ts.Entries) {
-		if err := ts.Entries[entryName].Evaluate(ts.TargetIgnore.Match); err != nil {
-			return err
-		}
+	return ts.EvaluateEntries(sortedEntries(ts.Entries))
+}
+
+// EvaluateEntries evaluates entries and all of their children concurrently,
+// using at most ts.Concurrency goroutines. If any entries cannot be evaluated
+// then it returns an EvaluateErrors containing an error for each of them.
+func (ts *TargetState) EvaluateEntries(entries []Entry) error {
+	if evaluateErrors := ts.evaluateEntries(entries, func(Entry) bool {
+		return true
+	}); len(evaluateErrors) != 0 {
+		return evaluateErrors
 	}
 	return nil
 }
@@ -423,31 +539,23 @@ func (ts *TargetState) Get(fs vfs.Stater, target string) (Entry, error) {
 	return ts.findEntry(targetName)
 }
 
-// ImportTAR imports a tar archive.
-func (ts *TargetState) ImportTAR(r *tar.Reader, importTAROptions ImportTAROptions, mutator Mutator) error {
-	for {
-		header, err := r.Next()
-		if errors.Is(err, io.EOF) {
-			break
-		} else if err != nil {
-			return err
-		}
-		switch header.Typeflag {
-		case tar.TypeDir, tar.TypeReg, tar.TypeSymlink:
-			if err := ts.importHeader(r, importTAROptions, header, mutator); err != nil {
-				return err
-			}
-		case tar.TypeXGlobalHeader:
-		default:
-			return fmt.Errorf("%s: unspported typeflag '%c'", header.Name, header.Typeflag)
-		}
-	}
-	return nil
+// ImportArchive imports the archive in format read from r into ts.
+func (ts *TargetState) ImportArchive(r io.Reader, format string, importArchiveOptions ImportArchiveOptions, mutator Mutator) error {
+	return walkArchiveReader(r, format, func(name string, info os.FileInfo, r io.Reader, linkname string) error {
+		return ts.importArchiveEntry(name, info, r, linkname, importArchiveOptions, mutator)
+	})
 }
 
-// Populate walks fs from ts.SourceDir to populate ts.
+// Populate reads template data from ts.SourceDir and then walks fs from
+// ts.SourceDir to populate ts. Externals are added after all other entries.
 func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
-	return vfs.Walk(fs, ts.SourceDir, func(path string, info os.FileInfo, _ error) error {
+	// Read template data first, as all templates might depend on it.
+	if err := ts.addTemplateData(fs); err != nil {
+		return err
+	}
+
+	var externalManifests []*externalManifest
+	if err := vfs.Walk(fs, ts.SourceDir, func(path string, info os.FileInfo, _ error) error {
 		relPath, err := filepath.Rel(ts.SourceDir, path)
 		if err != nil {
 			return err
@@ -459,16 +567,39 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 		if _, name := filepath.Split(relPath); strings.HasPrefix(name, ".") {
 			switch {
 			case info.Name() == ignoreName:
-				dns := dirNames(parseDirNameComponents(splitPathList(relPath)))
-				return ts.addPatterns(fs, ts.TargetIgnore, path, filepath.Join(dns...))
+				return ts.addPatterns(fs, ts.TargetIgnore, path, relPath)
 			case info.Name() == removeName:
-				dns := dirNames(parseDirNameComponents(splitPathList(relPath)))
-				return ts.addPatterns(fs, ts.TargetRemove, path, filepath.Join(dns...))
+				return ts.addPatterns(fs, ts.TargetRemove, path, relPath)
 			case info.Name() == templatesDirName:
 				if err := ts.addTemplatesDir(fs, path); err != nil {
 					return err
 				}
 				return filepath.SkipDir
+			case isExternalName(info.Name()) && info.Mode().IsRegular():
+				data, err := fs.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				if strings.HasSuffix(info.Name(), TemplateSuffix) {
+					if options != nil && !options.ExecuteTemplates {
+						return nil
+					}
+					data, err = ts.ExecuteTemplateData(path, data)
+					if err != nil {
+						return err
+					}
+				}
+				externals, err := parseExternalManifest(relPath, data)
+				if err != nil {
+					return err
+				}
+				components := splitPathList(relPath)
+				externalManifests = append(externalManifests, &externalManifest{
+					sourceName: relPath,
+					dirNames:   dirNames(parseDirNameComponents(components[:len(components)-1])),
+					externals:  externals,
+				})
+				return nil
 			case info.Name() == versionName:
 				data, err := fs.ReadFile(path)
 				if err != nil {
@@ -502,26 +633,31 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 			da := das[len(das)-1]
 			entries[da.Name] = newDir(relPath, targetName, da.Exact, da.Perm)
 		case info.Mode().IsRegular():
-			psfp := parseSourceFilePath(relPath)
+			psfp := parseSourceFilePath(relPath, ts.encryptedSuffix())
 			dns := dirNames(psfp.dirAttributes)
 			entries, err := ts.findEntries(dns)
 			if err != nil {
 				return err
 			}
 			switch {
+			case psfp.removeAttributes != nil:
+				entries[psfp.removeAttributes.Name] = &Remove{
+					sourceName: relPath,
+					targetName: filepath.Join(append(dns, psfp.removeAttributes.Name)...),
+				}
 			case psfp.fileAttributes != nil && psfp.fileAttributes.Mode&os.ModeType == 0 || psfp.scriptAttributes != nil:
 				readFile := func() ([]byte, error) {
 					return fs.ReadFile(path)
 				}
 				evaluateContents := readFile
-				if psfp.fileAttributes != nil && psfp.fileAttributes.Encrypted {
+				if psfp.fileAttributes != nil && psfp.fileAttributes.Encrypted || psfp.scriptAttributes != nil && psfp.scriptAttributes.Encrypted {
 					prevEvaluateContents := evaluateContents
 					evaluateContents = func() ([]byte, error) {
 						ciphertext, err := prevEvaluateContents()
 						if err != nil {
 							return nil, err
 						}
-						return ts.GPG.Decrypt(path, ciphertext)
+						return ts.Encryption.Decrypt(path, ciphertext)
 					}
 				}
 				if psfp.fileAttributes != nil && psfp.fileAttributes.Template || psfp.scriptAttributes != nil && psfp.scriptAttributes.Template {
@@ -538,11 +674,39 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 				}
 				switch {
 				case psfp.fileAttributes != nil:
+					targetName := filepath.Join(append(dns, psfp.fileAttributes.Name)...)
+					switch {
+					case !psfp.fileAttributes.Modify:
+					case options != nil && options.SkipModifyScripts:
+						// Leave the target's contents unchanged.
+						evaluateContents = func() ([]byte, error) {
+							currentContents, err := fs.ReadFile(filepath.Join(ts.DestDir, targetName))
+							if os.IsNotExist(err) {
+								return nil, nil
+							}
+							return currentContents, err
+						}
+					case options == nil || options.ExecuteTemplates:
+						prevEvaluateContents := evaluateContents
+						evaluateContents = func() ([]byte, error) {
+							script, err := prevEvaluateContents()
+							if err != nil {
+								return nil, err
+							}
+							currentContents, err := fs.ReadFile(filepath.Join(ts.DestDir, targetName))
+							if err != nil && !os.IsNotExist(err) {
+								return nil, err
+							}
+							return runModifyScript(fs, ts.DestDir, targetName, script, currentContents)
+						}
+					}
 					entry := &File{
 						sourceName:       relPath,
-						targetName:       filepath.Join(append(dns, psfp.fileAttributes.Name)...),
+						targetName:       targetName,
+						Create:           psfp.fileAttributes.Create,
 						Empty:            psfp.fileAttributes.Empty,
 						Encrypted:        psfp.fileAttributes.Encrypted,
+						Modify:           psfp.fileAttributes.Modify,
 						Perm:             psfp.fileAttributes.Mode.Perm(),
 						Template:         psfp.fileAttributes.Template,
 						evaluateContents: evaluateContents,
@@ -552,6 +716,10 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 					entry := &Script{
 						sourceName:       relPath,
 						targetName:       filepath.Join(append(dns, psfp.scriptAttributes.Name)...),
+						After:            psfp.scriptAttributes.After,
+						Before:           psfp.scriptAttributes.Before,
+						Encrypted:        psfp.scriptAttributes.Encrypted,
+						OnChange:         psfp.scriptAttributes.OnChange,
 						Once:             psfp.scriptAttributes.Once,
 						Template:         psfp.scriptAttributes.Template,
 						evaluateContents: evaluateContents,
@@ -583,7 +751,18 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 			return fmt.Errorf("%s: unsupported file type", path)
 		}
 		return nil
-	})
+	}); err != nil {
+		return err
+	}
+
+	refreshExternals := options != nil && options.RefreshExternals
+	cachedExternalsOnly := options != nil && options.CachedExternalsOnly
+	for _, externalManifest := range externalManifests {
+		if err := ts.addExternals(externalManifest, refreshExternals, cachedExternalsOnly); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (ts *TargetState) addDir(targetName string, entries map[string]Entry, parentDirSourceName string, exact bool, perm os.FileMode, createKeepFile bool, mutator Mutator) error {
@@ -615,7 +794,9 @@ func (ts *TargetState) addDir(targetName string, entries map[string]Entry, paren
 	return nil
 }
 
-func (ts *TargetState) addFile(targetName string, entries map[string]Entry, parentDirSourceName string, info os.FileInfo, perm os.FileMode, encrypted, template bool, contents []byte, mutator Mutator) error {
+// addFile adds a file with the given contents to entries. contents are
+// plaintext, and are encrypted with ts.Encryption if encrypted is true.
+func (ts *TargetState) addFile(targetName string, entries map[string]Entry, parentDirSourceName string, info os.FileInfo, perm os.FileMode, create, encrypted, template bool, contents []byte, mutator Mutator) error {
 	name := filepath.Base(targetName)
 	var existingFile *File
 	var existingContents []byte
@@ -624,6 +805,14 @@ func (ts *TargetState) addFile(targetName string, entries map[string]Entry, pare
 		if !ok {
 			return fmt.Errorf("%s: already added and not a regular file", targetName)
 		}
+		if err := CheckSourceFile(existingFile); err != nil {
+			return err
+		}
+		if existingFile.Modify {
+			return fmt.Errorf("%s: already added as a modify script", targetName)
+		}
+		// Keep the create_ attribute when re-adding a file.
+		create = create || existingFile.Create
 		var err error
 		existingContents, err = existingFile.Contents()
 		if err != nil {
@@ -635,16 +824,18 @@ func (ts *TargetState) addFile(targetName string, entries map[string]Entry, pare
 	sourceName := FileAttributes{
 		Name:      name,
 		Mode:      perm,
+		Create:    create,
 		Empty:     empty,
 		Encrypted: encrypted,
 		Template:  template,
-	}.SourceName()
+	}.SourceName(ts.encryptedSuffix())
 	if parentDirSourceName != "" {
 		sourceName = filepath.Join(parentDirSourceName, sourceName)
 	}
 	file := &File{
 		sourceName: sourceName,
 		targetName: targetName,
+		Create:     create,
 		Empty:      empty,
 		Encrypted:  encrypted,
 		Perm:       perm,
@@ -652,50 +843,48 @@ func (ts *TargetState) addFile(targetName string, entries map[string]Entry, pare
 		contents:   contents,
 	}
 	if existingFile != nil {
-		if bytes.Equal(existingFile.contents, file.contents) {
+		// Compare plaintexts, as encryption is not deterministic.
+		if bytes.Equal(existingContents, file.contents) {
 			if existingFile.sourceName == file.sourceName {
 				return nil
 			}
-			return mutator.Rename(filepath.Join(ts.SourceDir, existingFile.sourceName), filepath.Join(ts.SourceDir, file.sourceName))
+			if existingFile.Encrypted == file.Encrypted {
+				return mutator.Rename(filepath.Join(ts.SourceDir, existingFile.sourceName), filepath.Join(ts.SourceDir, file.sourceName))
+			}
 		}
 		if err := mutator.RemoveAll(filepath.Join(ts.SourceDir, existingFile.sourceName)); err != nil {
 			return err
 		}
 	}
+	sourceContents := contents
+	if encrypted {
+		var err error
+		sourceContents, err = ts.Encryption.Encrypt(targetName, contents)
+		if err != nil {
+			return err
+		}
+	}
 	entries[name] = file
-	return mutator.WriteFile(filepath.Join(ts.SourceDir, sourceName), contents, 0o666&^ts.Umask, existingContents)
+	return mutator.WriteFile(filepath.Join(ts.SourceDir, sourceName), sourceContents, 0o666&^ts.Umask, existingContents)
 }
 
-func (ts *TargetState) addPatterns(fs vfs.FS, ps *PatternSet, path, relPath string) error {
+// addPatterns adds the patterns in the file at path, with source name
+// sourceName, to ps. Patterns are scoped to the target directory that contains
+// the file. Files in ignored directories are not read, like .gitignore files.
+func (ts *TargetState) addPatterns(fs vfs.FS, ps *PatternSet, path, sourceName string) error {
+	components := splitPathList(sourceName)
+	dir := filepath.Join(dirNames(parseDirNameComponents(components[:len(components)-1]))...)
+	if dir != "" && ts.TargetIgnore.Match(dir, true) {
+		return nil
+	}
 	data, err := ts.executeTemplate(fs, path)
 	if err != nil {
 		return err
 	}
-	dir := filepath.Dir(relPath)
-	s := bufio.NewScanner(bytes.NewReader(data))
-	for s.Scan() {
-		text := s.Text()
-		if index := strings.IndexRune(text, '#'); index != -1 {
-			text = text[:index]
-		}
-		text = strings.TrimSpace(text)
-		if text == "" {
-			continue
-		}
-		include := true
-		if strings.HasPrefix(text, "!") {
-			include = false
-			text = strings.TrimPrefix(text, "!")
-		}
-		pattern := filepath.Join(dir, text)
-		if err := ps.Add(pattern, include); err != nil {
-			return fmt.Errorf("%s: %w", path, err)
-		}
-	}
-	if err := s.Err(); err != nil {
-		return fmt.Errorf("%s: %w", path, err)
+	if dir == "" {
+		dir = "."
 	}
-	return nil
+	return ps.AddPatterns(sourceName, dir, data)
 }
 
 func (ts *TargetState) addSymlink(targetName string, entries map[string]Entry, parentDirSourceName, linkname string, mutator Mutator) error {
@@ -707,6 +896,9 @@ func (ts *TargetState) addSymlink(targetName string, entries map[string]Entry, p
 		if !ok {
 			return fmt.Errorf("%s: already added and not a symlink", targetName)
 		}
+		if err := CheckSourceFile(existingSymlink); err != nil {
+			return err
+		}
 		var err error
 		existingLinkname, err = existingSymlink.Linkname()
 		if err != nil {
@@ -716,7 +908,7 @@ func (ts *TargetState) addSymlink(targetName string, entries map[string]Entry, p
 	sourceName := FileAttributes{
 		Name: name,
 		Mode: os.ModeSymlink,
-	}.SourceName()
+	}.SourceName(ts.encryptedSuffix())
 	if parentDirSourceName != "" {
 		sourceName = filepath.Join(parentDirSourceName, sourceName)
 	}
@@ -770,6 +962,63 @@ func (ts *TargetState) addTemplatesDir(fs vfs.FS, path string) error {
 	})
 }
 
+// encryptedSuffix returns the suffix of encrypted files in the source state.
+func (ts *TargetState) encryptedSuffix() string {
+	if ts.Encryption == nil {
+		return ""
+	}
+	return ts.Encryption.EncryptedSuffix()
+}
+
+// evaluateEntries evaluates entries and their descendants for which f returns
+// true concurrently, using at most ts.Concurrency goroutines, and returns the
+// errors encountered.
+func (ts *TargetState) evaluateEntries(entries []Entry, f func(Entry) bool) EvaluateErrors {
+	var evaluateEntries []Entry
+	for _, entry := range entries {
+		evaluateEntries = appendEvaluateEntries(evaluateEntries, entry, ts.TargetIgnore.Match, f)
+	}
+
+	concurrency := ts.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > len(evaluateEntries) {
+		concurrency = len(evaluateEntries)
+	}
+
+	// Each entry is evaluated by exactly one goroutine, so errs can be written
+	// without locking.
+	errs := make([]error, len(evaluateEntries))
+	indexCh := make(chan int)
+	wg := sync.WaitGroup{}
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for index := range indexCh {
+				errs[index] = evaluateEntries[index].Evaluate(ts.TargetIgnore.Match)
+			}
+		}()
+	}
+	for index := range evaluateEntries {
+		indexCh <- index
+	}
+	close(indexCh)
+	wg.Wait()
+
+	var evaluateErrors EvaluateErrors
+	for index, err := range errs {
+		if err != nil {
+			evaluateErrors = append(evaluateErrors, &EvaluateError{
+				TargetName: evaluateEntries[index].TargetName(),
+				Err:        err,
+			})
+		}
+	}
+	return evaluateErrors
+}
+
 func (ts *TargetState) executeTemplate(fs vfs.FS, path string) ([]byte, error) {
 	data, err := fs.ReadFile(path)
 	if err != nil {
@@ -805,14 +1054,14 @@ func (ts *TargetState) findEntry(name string) (Entry, error) {
 	return entry, nil
 }
 
-func (ts *TargetState) importHeader(r io.Reader, importTAROptions ImportTAROptions, header *tar.Header, mutator Mutator) error {
-	targetPath := header.Name
-	if importTAROptions.StripComponents > 0 {
-		targetPath = filepath.Join(strings.Split(targetPath, string(os.PathSeparator))[importTAROptions.StripComponents:]...)
+func (ts *TargetState) importArchiveEntry(name string, info os.FileInfo, r io.Reader, linkname string, importArchiveOptions ImportArchiveOptions, mutator Mutator) error {
+	targetPath := filepath.FromSlash(name)
+	if importArchiveOptions.StripComponents > 0 {
+		targetPath = filepath.Join(strings.Split(targetPath, string(os.PathSeparator))[importArchiveOptions.StripComponents:]...)
 	}
-	if importTAROptions.DestinationDir != "" {
+	if importArchiveOptions.DestinationDir != "" {
 		//nolint:gosec
-		targetPath = filepath.Join(importTAROptions.DestinationDir, targetPath)
+		targetPath = filepath.Join(importArchiveOptions.DestinationDir, targetPath)
 	} else {
 		//nolint:gosec
 		targetPath = filepath.Join(ts.DestDir, targetPath)
@@ -832,25 +1081,69 @@ func (ts *TargetState) importHeader(r io.Reader, importTAROptions ImportTAROptio
 		if !ok {
 			return fmt.Errorf("%s: parent is not a directory", targetName)
 		}
+		if err := CheckSourceFile(parentDir); err != nil {
+			return err
+		}
 		parentDirSourceName = parentDir.sourceName
 		entries = parentDir.Entries
 	}
-	switch header.Typeflag {
-	case tar.TypeDir:
-		perm := os.FileMode(header.Mode).Perm()
+	switch {
+	case info.IsDir():
+		perm := info.Mode().Perm()
 		createKeepFile := false // FIXME don't assume that we don't need a keep file
-		return ts.addDir(targetName, entries, parentDirSourceName, importTAROptions.Exact, perm, createKeepFile, mutator)
-	case tar.TypeReg:
-		info := header.FileInfo()
+		return ts.addDir(targetName, entries, parentDirSourceName, importArchiveOptions.Exact, perm, createKeepFile, mutator)
+	case info.Mode().IsRegular():
 		contents, err := ioutil.ReadAll(r)
 		if err != nil {
 			return err
 		}
-		return ts.addFile(targetName, entries, parentDirSourceName, info, info.Mode().Perm(), false, false, contents, mutator)
-	case tar.TypeSymlink:
-		linkname := header.Linkname
+		return ts.addFile(targetName, entries, parentDirSourceName, info, info.Mode().Perm(), false, false, false, contents, mutator)
+	case info.Mode()&os.ModeType == os.ModeSymlink:
 		return ts.addSymlink(targetName, entries, parentDirSourceName, linkname, mutator)
 	default:
-		return fmt.Errorf("%s: unspported typeflag '%c'", header.Name, header.Typeflag)
+		return fmt.Errorf("%s: unsupported file type", name)
+	}
+}
+
+// appendEvaluateEntries appends entry, or, if entry is a Dir, all of its
+// non-ignored descendants that are not Dirs, to evaluateEntries if f returns
+// true.
+func appendEvaluateEntries(evaluateEntries []Entry, entry Entry, ignore func(string, bool) bool, f func(Entry) bool) []Entry {
+	if !f(entry) {
+		return evaluateEntries
 	}
+	dir, ok := entry.(*Dir)
+	if !ok {
+		return append(evaluateEntries, entry)
+	}
+	if ignore(dir.targetName, true) {
+		return evaluateEntries
+	}
+	for _, entryName := range sortedEntryNames(dir.Entries) {
+		evaluateEntries = appendEvaluateEntries(evaluateEntries, dir.Entries[entryName], ignore, f)
+	}
+	return evaluateEntries
+}
+
+// appendScripts appends all Scripts in entries for which f returns true to
+// scripts, in the order in which they would be applied.
+func appendScripts(scripts []*Script, entries []Entry, f func(*Script) bool) []*Script {
+	for _, entry := range entries {
+		switch entry := entry.(type) {
+		case *Dir:
+			scripts = appendScripts(scripts, sortedEntries(entry.Entries), f)
+		case *Script:
+			if f(entry) {
+				scripts = append(scripts, entry)
+			}
+		}
+	}
+	return scripts
+}
+
+// isBeforeOrAfterScript returns true if entry is a Script that is run before or
+// after all other entries are applied.
+func isBeforeOrAfterScript(entry Entry) bool {
+	script, ok := entry.(*Script)
+	return ok && (script.Before || script.After)
 }
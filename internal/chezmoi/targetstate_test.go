@@ -1,10 +1,14 @@
 package chezmoi
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"text/template"
+	"time"
 
 	"github.com/coreos/go-semver/semver"
 	"github.com/stretchr/testify/assert"
@@ -22,6 +26,7 @@ func TestEndToEnd(t *testing.T) {
 		templateFuncs template.FuncMap
 		destDir       string
 		umask         os.FileMode
+		remove        bool
 		tests         interface{}
 	}{
 		{
@@ -94,6 +99,123 @@ func TestEndToEnd(t *testing.T) {
 				),
 			},
 		},
+		{
+			name: "create",
+			root: map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".existing": "user contents",
+				},
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"create_dot_existing": "default contents",
+					"create_dot_missing":  "default contents",
+				},
+			},
+			sourceDir: "/home/user/.local/share/chezmoi",
+			destDir:   "/home/user",
+			umask:     0o22,
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.existing",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("user contents"),
+				),
+				vfst.TestPath("/home/user/.missing",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("default contents"),
+				),
+			},
+		},
+		{
+			name: "remove",
+			root: map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".olddir/file":  "file",
+					".oldlink":      &vfst.Symlink{Target: ".oldrc"},
+					".oldrc":        "old",
+					".config/old":   "old",
+					".config/keep":  "keep",
+					".ignored_file": "ignored",
+				},
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					".chezmoiignore":          ".ignored_file\n",
+					"dot_config/remove_old":   "",
+					"remove_dot_ignored_file": "",
+					"remove_dot_missing":      "",
+					"remove_dot_olddir":       "",
+					"remove_dot_oldlink":      "",
+					"remove_dot_oldrc":        "",
+				},
+			},
+			sourceDir: "/home/user/.local/share/chezmoi",
+			destDir:   "/home/user",
+			umask:     0o22,
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.olddir",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.oldlink",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.oldrc",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.config/old",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.config/keep",
+					vfst.TestModeIsRegular,
+				),
+				vfst.TestPath("/home/user/.ignored_file",
+					vfst.TestModeIsRegular,
+				),
+				vfst.TestPath("/home/user/.missing",
+					vfst.TestDoesNotExist,
+				),
+			},
+		},
+		{
+			name: "nested_ignore_and_remove",
+			root: map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".config/old":     "old",
+					".config/sub/old": "old",
+					"old":             "old",
+				},
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					".chezmoiignore":            ".cache/\n",
+					"dot_cache/.chezmoiignore":  "{{ if }}\n",
+					"dot_cache/file":            "file",
+					"dot_config/.chezmoiremove": "/old\n",
+					"dot_config/.chezmoiignore": "/sub/\n",
+					"dot_config/file":           "file",
+					"dot_config/sub/file":       "file",
+				},
+			},
+			sourceDir: "/home/user/.local/share/chezmoi",
+			destDir:   "/home/user",
+			umask:     0o22,
+			remove:    true,
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.cache",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.config/file",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("file"),
+				),
+				vfst.TestPath("/home/user/.config/old",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.config/sub/file",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.config/sub/old",
+					vfst.TestModeIsRegular,
+				),
+				vfst.TestPath("/home/user/old",
+					vfst.TestModeIsRegular,
+				),
+			},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			fs, cleanup, err := vfst.NewTestFS(tc.root)
@@ -110,6 +232,7 @@ func TestEndToEnd(t *testing.T) {
 			applyOptions := &ApplyOptions{
 				DestDir:           ts.DestDir,
 				Ignore:            ts.TargetIgnore.Match,
+				Remove:            tc.remove,
 				ScriptStateBucket: []byte("script"),
 				Stdout:            os.Stdout,
 				Umask:             0o22,
@@ -365,14 +488,7 @@ func TestTargetStatePopulate(t *testing.T) {
 			want: NewTargetState(
 				WithDestDir("/"),
 				WithSourceDir("/"),
-				WithTargetIgnore(&PatternSet{
-					includes: map[string]struct{}{
-						"f*": {},
-					},
-					excludes: map[string]struct{}{
-						"g": {},
-					},
-				}),
+				WithTargetIgnore(mustParsePatternSet(t, ".chezmoiignore", ".", "f*\n!g\n")),
 			),
 		},
 		{
@@ -386,14 +502,7 @@ func TestTargetStatePopulate(t *testing.T) {
 			want: NewTargetState(
 				WithDestDir("/"),
 				WithSourceDir("/"),
-				WithTargetRemove(&PatternSet{
-					includes: map[string]struct{}{
-						"f*": {},
-					},
-					excludes: map[string]struct{}{
-						"g": {},
-					},
-				}),
+				WithTargetRemove(mustParsePatternSet(t, ".chezmoiremove", ".", "f*\n!g\n", WithAnchoredPatterns(true))),
 			),
 		},
 		{
@@ -415,14 +524,7 @@ func TestTargetStatePopulate(t *testing.T) {
 					},
 				}),
 				WithSourceDir("/"),
-				WithTargetIgnore(&PatternSet{
-					includes: map[string]struct{}{
-						filepath.Join("dir", "foo"): {},
-					},
-					excludes: map[string]struct{}{
-						filepath.Join("dir", "bar"): {},
-					},
-				}),
+				WithTargetIgnore(mustParsePatternSet(t, filepath.Join("dir", ".chezmoiignore"), "dir", "foo\n!bar\n")),
 			),
 		},
 		{
@@ -488,3 +590,108 @@ func TestTargetStatePopulate(t *testing.T) {
 		})
 	}
 }
+
+func TestTargetStateEvaluateConcurrency(t *testing.T) {
+	const concurrency = 4
+	root := map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			"dir": map[string]interface{}{},
+		},
+	}
+	sourceDir := root["/home/user/.local/share/chezmoi"].(map[string]interface{})
+	for i := 0; i < 32; i++ {
+		sourceDir[fmt.Sprintf("dot_file%02d.tmpl", i)] = "{{ secret }}"
+		sourceDir["dir"].(map[string]interface{})[fmt.Sprintf("file%02d.tmpl", i)] = "{{ secret }}"
+	}
+	fs, cleanup, err := vfst.NewTestFS(root)
+	require.NoError(t, err)
+	defer cleanup()
+
+	var mutex sync.Mutex
+	active, maxActive := 0, 0
+	ts := NewTargetState(
+		WithConcurrency(concurrency),
+		WithDestDir("/home/user"),
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+		WithTemplateFuncs(template.FuncMap{
+			"secret": func() string {
+				mutex.Lock()
+				active++
+				if active > maxActive {
+					maxActive = active
+				}
+				mutex.Unlock()
+				time.Sleep(time.Millisecond)
+				mutex.Lock()
+				active--
+				mutex.Unlock()
+				return "secret"
+			},
+		}),
+	)
+	require.NoError(t, ts.Populate(fs, nil))
+	require.NoError(t, ts.Evaluate())
+	assert.LessOrEqual(t, maxActive, concurrency)
+
+	file, err := ts.Get(fs, "/home/user/dir/file31")
+	require.NoError(t, err)
+	contents, err := file.(*File).Contents()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), contents)
+}
+
+func TestTargetStateApplyEvaluateErrors(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			"dot_a":      "a",
+			"dot_b.tmpl": "{{ fail }}",
+			"dir": map[string]interface{}{
+				"c.tmpl": "{{ fail }}",
+				"d":      "d",
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	ts := NewTargetState(
+		WithConcurrency(2),
+		WithDestDir("/home/user"),
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+		WithTemplateFuncs(template.FuncMap{
+			"fail": func() (string, error) {
+				return "", errors.New("fail")
+			},
+		}),
+	)
+	require.NoError(t, ts.Populate(fs, nil))
+	applyOptions := &ApplyOptions{
+		DestDir:           ts.DestDir,
+		Ignore:            ts.TargetIgnore.Match,
+		ScriptStateBucket: []byte("script"),
+		Stdout:            os.Stdout,
+		Umask:             0o22,
+	}
+	err = ts.Apply(fs, NewFSMutator(fs), false, applyOptions)
+	var evaluateErrors EvaluateErrors
+	require.True(t, errors.As(err, &evaluateErrors))
+	var targetNames []string
+	for _, evaluateError := range evaluateErrors {
+		targetNames = append(targetNames, evaluateError.TargetName)
+	}
+	assert.Equal(t, []string{".b", filepath.Join("dir", "c")}, targetNames)
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.a",
+			vfst.TestContentsString("a"),
+		),
+		vfst.TestPath("/home/user/.b",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/dir/c",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/dir/d",
+			vfst.TestContentsString("d"),
+		),
+	)
+}
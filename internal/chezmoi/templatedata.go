@@ -0,0 +1,189 @@
+package chezmoi
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+	vfs "github.com/twpayne/go-vfs"
+	"gopkg.in/yaml.v2"
+)
+
+const templateDataName = ".chezmoidata"
+
+// PopulateTemplateData reads only the template data files in ts.SourceDir into
+// ts, without reading the rest of the source state.
+func (ts *TargetState) PopulateTemplateData(fs vfs.FS) error {
+	return ts.addTemplateData(fs)
+}
+
+// addTemplateData reads the .chezmoidata.<format> files and the files in the
+// .chezmoidata directory in ts.SourceDir, in that order, and deep merges them
+// into ts.TemplateData. Later files take precedence over earlier files, and
+// values already in ts.TemplateData take precedence over all files. The source
+// name of every value from a file is recorded in ts.TemplateDataSources.
+func (ts *TargetState) addTemplateData(fs vfs.FS) error {
+	sourceNames, err := ts.templateDataSourceNames(fs)
+	if err != nil {
+		return err
+	}
+	if len(sourceNames) == 0 {
+		return nil
+	}
+
+	templateData := make(map[string]interface{})
+	templateDataSources := make(map[string]string)
+	for _, sourceName := range sourceNames {
+		data, err := fs.ReadFile(filepath.Join(ts.SourceDir, sourceName))
+		if err != nil {
+			return err
+		}
+		fileTemplateData, err := parseTemplateData(sourceName, data)
+		if err != nil {
+			return err
+		}
+		mergeTemplateData(templateData, fileTemplateData, "", sourceName, templateDataSources)
+	}
+	mergeTemplateData(templateData, ts.TemplateData, "", "", templateDataSources)
+
+	ts.TemplateData = templateData
+	ts.TemplateDataSources = templateDataSources
+	return nil
+}
+
+// templateDataSourceNames returns the source names of all template data files
+// in ts.SourceDir, in the order in which they should be merged.
+func (ts *TargetState) templateDataSourceNames(fs vfs.FS) ([]string, error) {
+	infos, err := fs.ReadDir(ts.SourceDir)
+	switch {
+	case os.IsNotExist(err):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+
+	var sourceNames []string
+	dirExists := false
+	for _, info := range infos {
+		switch {
+		case info.Name() == templateDataName && info.IsDir():
+			dirExists = true
+		case strings.HasPrefix(info.Name(), templateDataName+"."):
+			sourceNames = append(sourceNames, info.Name())
+		}
+	}
+	if !dirExists {
+		return sourceNames, nil
+	}
+
+	var dirSourceNames []string
+	if err := vfs.Walk(fs, filepath.Join(ts.SourceDir, templateDataName), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		sourceName, err := filepath.Rel(ts.SourceDir, path)
+		if err != nil {
+			return err
+		}
+		dirSourceNames = append(dirSourceNames, sourceName)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sort.Strings(dirSourceNames)
+	return append(sourceNames, dirSourceNames...), nil
+}
+
+// parseTemplateData parses data, with a format determined by sourceName's
+// extension.
+func parseTemplateData(sourceName string, data []byte) (map[string]interface{}, error) {
+	templateData := make(map[string]interface{})
+	var err error
+	switch ext := strings.TrimPrefix(filepath.Ext(sourceName), "."); ext {
+	case "json":
+		err = json.Unmarshal(data, &templateData)
+	case "toml":
+		err = toml.Unmarshal(data, &templateData)
+	case "yaml", "yml":
+		err = yaml.Unmarshal(data, &templateData)
+	default:
+		return nil, fmt.Errorf("%s: unknown format", sourceName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", sourceName, err)
+	}
+	return NormalizeTemplateData(templateData).(map[string]interface{}), nil
+}
+
+// mergeTemplateData deep merges src into dst. The source name of every value
+// merged is recorded in sources, keyed by its dot-separated path below prefix.
+// If sourceName is empty then the sources of all values merged are removed
+// from sources instead.
+func mergeTemplateData(dst, src map[string]interface{}, prefix, sourceName string, sources map[string]string) {
+	for key, srcValue := range src {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		srcValue = NormalizeTemplateData(srcValue)
+		if srcMap, ok := srcValue.(map[string]interface{}); ok {
+			dstMap, ok := dst[key].(map[string]interface{})
+			if !ok {
+				deleteTemplateDataSources(sources, path)
+				dstMap = make(map[string]interface{})
+				dst[key] = dstMap
+			}
+			mergeTemplateData(dstMap, srcMap, path, sourceName, sources)
+			continue
+		}
+		deleteTemplateDataSources(sources, path)
+		dst[key] = srcValue
+		if sourceName != "" {
+			sources[path] = sourceName
+		}
+	}
+}
+
+// deleteTemplateDataSources deletes the sources of path and all paths below it
+// from sources.
+func deleteTemplateDataSources(sources map[string]string, path string) {
+	for sourcePath := range sources {
+		if sourcePath == path || strings.HasPrefix(sourcePath, path+".") {
+			delete(sources, sourcePath)
+		}
+	}
+}
+
+// NormalizeTemplateData returns value with all maps converted to
+// map[string]interface{}s.
+func NormalizeTemplateData(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			result[fmt.Sprintf("%v", k)] = NormalizeTemplateData(v)
+		}
+		return result
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			result[k] = NormalizeTemplateData(v)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, v := range value {
+			result[i] = NormalizeTemplateData(v)
+		}
+		return result
+	default:
+		return value
+	}
+}
@@ -0,0 +1,97 @@
+package chezmoi
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestTargetStatePopulateTemplateData(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			".chezmoidata.json": `{"email":"john@home.org","hosts":{"laptop":{"os":"darwin"}}}`,
+			".chezmoidata.yaml": "" +
+				"hosts:\n" +
+				"  desktop:\n" +
+				"    os: linux\n",
+			".chezmoidata": map[string]interface{}{
+				"b.toml": "" +
+					"[hosts.laptop]\n" +
+					"  os = \"linux\"\n",
+				"a/packages.yaml": "" +
+					"packages:\n" +
+					"  brew: [git, jq]\n",
+			},
+			".chezmoiignore":     "{{ if eq .hosts.desktop.os \"linux\" }}ignored{{ end }}\n",
+			"dot_gitconfig.tmpl": "{{ .email }} {{ .hosts.laptop.os }} {{ index .packages.brew 1 }}\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	ts := NewTargetState(
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+		WithTemplateData(map[string]interface{}{
+			"email": "john.smith@company.com",
+			"hosts": map[string]interface{}{
+				"server": map[string]interface{}{
+					"os": "openbsd",
+				},
+			},
+		}),
+	)
+	require.NoError(t, ts.Populate(fs, nil))
+
+	assert.Equal(t, map[string]interface{}{
+		"email": "john.smith@company.com",
+		"hosts": map[string]interface{}{
+			"desktop": map[string]interface{}{
+				"os": "linux",
+			},
+			"laptop": map[string]interface{}{
+				"os": "linux",
+			},
+			"server": map[string]interface{}{
+				"os": "openbsd",
+			},
+		},
+		"packages": map[string]interface{}{
+			"brew": []interface{}{"git", "jq"},
+		},
+	}, ts.TemplateData)
+	assert.Equal(t, map[string]string{
+		"hosts.desktop.os": ".chezmoidata.yaml",
+		"hosts.laptop.os":  filepath.Join(".chezmoidata", "b.toml"),
+		"packages.brew":    filepath.Join(".chezmoidata", "a", "packages.yaml"),
+	}, ts.TemplateDataSources)
+	assert.True(t, ts.TargetIgnore.Match("ignored", false))
+
+	require.NoError(t, ts.Evaluate())
+	contents, err := ts.Entries[".gitconfig"].(*File).Contents()
+	require.NoError(t, err)
+	assert.Equal(t, "john.smith@company.com linux jq\n", string(contents))
+}
+
+func TestTargetStatePopulateTemplateDataErrors(t *testing.T) {
+	for name, root := range map[string]interface{}{
+		"invalid_json": map[string]interface{}{
+			"/home/user/.local/share/chezmoi/.chezmoidata.json": "{",
+		},
+		"unknown_format": map[string]interface{}{
+			"/home/user/.local/share/chezmoi/.chezmoidata/README.md": "# README\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(root)
+			require.NoError(t, err)
+			defer cleanup()
+			ts := NewTargetState(
+				WithSourceDir("/home/user/.local/share/chezmoi"),
+			)
+			assert.Error(t, ts.Populate(fs, nil))
+		})
+	}
+}
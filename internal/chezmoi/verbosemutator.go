@@ -90,6 +90,11 @@ func (m *VerboseMutator) Rename(oldpath, newpath string) error {
 	return err
 }
 
+// RecordScript implements ScriptRecorder.RecordScript.
+func (m *VerboseMutator) RecordScript(scriptname, dir string, data []byte, stateUpdate *ScriptStateUpdate) error {
+	return recordScript(m.m, scriptname, dir, data, stateUpdate)
+}
+
 // RunCmd implements Mutator.RunCmd.
 func (m *VerboseMutator) RunCmd(cmd *exec.Cmd) error {
 	action := cmdString(cmd)
@@ -102,6 +107,16 @@ func (m *VerboseMutator) RunCmd(cmd *exec.Cmd) error {
 	return err
 }
 
+// RunScript implements Mutator.RunScript. The script's contents are written by
+// Script.Apply if verbose output is requested, so only errors are logged here.
+func (m *VerboseMutator) RunScript(scriptname, dir string, data []byte) error {
+	err := m.m.RunScript(scriptname, dir, data)
+	if err != nil {
+		_, _ = fmt.Fprintf(m.w, "%s: %v\n", scriptname, err)
+	}
+	return err
+}
+
 // Stat implements Mutator.Stat.
 func (m *VerboseMutator) Stat(name string) (os.FileInfo, error) {
 	return m.m.Stat(name)
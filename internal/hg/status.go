@@ -0,0 +1,147 @@
+package hg
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// A ParseError is a parse error.
+type ParseError string
+
+// A FileStatus is the status of a file.
+type FileStatus struct {
+	Path   string
+	Source string
+}
+
+// A Status is a status.
+type Status struct {
+	Modified   []FileStatus
+	Added      []FileStatus
+	Removed    []FileStatus
+	Clean      []FileStatus
+	Missing    []FileStatus
+	NotTracked []FileStatus
+	Ignored    []FileStatus
+}
+
+type jsonFileStatus struct {
+	Path   string `json:"path"`
+	Source string `json:"source"`
+	Status string `json:"status"`
+}
+
+func (e ParseError) Error() string {
+	return fmt.Sprintf("%s: parse error", string(e))
+}
+
+// ParseStatus parses the output of
+//   hg status --copies
+// See https://www.mercurial-scm.org/doc/hg.1.html#status.
+//nolint:godot
+func ParseStatus(output []byte) (*Status, error) {
+	status := &Status{}
+	var prevFileStatus *FileStatus
+	s := bufio.NewScanner(bytes.NewReader(output))
+	for s.Scan() {
+		text := s.Text()
+		if len(text) < 3 || text[1] != ' ' {
+			return nil, ParseError(text)
+		}
+		// Copy sources are printed on the line after the added file,
+		// indented by two spaces.
+		if strings.HasPrefix(text, "  ") {
+			if prevFileStatus == nil {
+				return nil, ParseError(text)
+			}
+			prevFileStatus.Source = text[2:]
+			prevFileStatus = nil
+			continue
+		}
+		fileStatuses, err := status.fileStatuses(text[0])
+		if err != nil {
+			return nil, ParseError(text)
+		}
+		*fileStatuses = append(*fileStatuses, FileStatus{
+			Path: text[2:],
+		})
+		if text[0] == 'A' {
+			prevFileStatus = &(*fileStatuses)[len(*fileStatuses)-1]
+		} else {
+			prevFileStatus = nil
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if status.Empty() {
+		return nil, nil
+	}
+	return status, nil
+}
+
+// ParseStatusJSON parses the output of
+//   hg status --copies -Tjson
+// See https://www.mercurial-scm.org/doc/hg.1.html#status.
+//nolint:godot
+func ParseStatusJSON(output []byte) (*Status, error) {
+	var jsonFileStatuses []jsonFileStatus
+	if err := json.Unmarshal(output, &jsonFileStatuses); err != nil {
+		return nil, err
+	}
+	status := &Status{}
+	for _, jfs := range jsonFileStatuses {
+		if len(jfs.Status) != 1 {
+			return nil, ParseError(jfs.Path)
+		}
+		fileStatuses, err := status.fileStatuses(jfs.Status[0])
+		if err != nil {
+			return nil, ParseError(jfs.Path)
+		}
+		*fileStatuses = append(*fileStatuses, FileStatus{
+			Path:   jfs.Path,
+			Source: jfs.Source,
+		})
+	}
+	if status.Empty() {
+		return nil, nil
+	}
+	return status, nil
+}
+
+// Empty returns true if s is empty.
+func (s *Status) Empty() bool {
+	return s == nil || true &&
+		len(s.Modified) == 0 &&
+		len(s.Added) == 0 &&
+		len(s.Removed) == 0 &&
+		len(s.Clean) == 0 &&
+		len(s.Missing) == 0 &&
+		len(s.NotTracked) == 0 &&
+		len(s.Ignored) == 0
+}
+
+// fileStatuses returns the file statuses in s for code.
+func (s *Status) fileStatuses(code byte) (*[]FileStatus, error) {
+	switch code {
+	case 'M':
+		return &s.Modified, nil
+	case 'A':
+		return &s.Added, nil
+	case 'R':
+		return &s.Removed, nil
+	case 'C':
+		return &s.Clean, nil
+	case '!':
+		return &s.Missing, nil
+	case '?':
+		return &s.NotTracked, nil
+	case 'I':
+		return &s.Ignored, nil
+	default:
+		return nil, fmt.Errorf("%c: unknown status code", code)
+	}
+}
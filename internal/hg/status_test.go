@@ -0,0 +1,129 @@
+package hg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStatus(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		outputStr      string
+		expectedEmpty  bool
+		expectedStatus *Status
+	}{
+		{
+			name:          "empty",
+			outputStr:     "",
+			expectedEmpty: true,
+		},
+		{
+			name: "all",
+			outputStr: "" +
+				"M dot_bashrc\n" +
+				"A dot_vimrc\n" +
+				"R dot_zshrc\n" +
+				"C dot_profile\n" +
+				"! dot_inputrc\n" +
+				"? dot_gitconfig\n" +
+				"I dot_cache\n",
+			expectedStatus: &Status{
+				Modified:   []FileStatus{{Path: "dot_bashrc"}},
+				Added:      []FileStatus{{Path: "dot_vimrc"}},
+				Removed:    []FileStatus{{Path: "dot_zshrc"}},
+				Clean:      []FileStatus{{Path: "dot_profile"}},
+				Missing:    []FileStatus{{Path: "dot_inputrc"}},
+				NotTracked: []FileStatus{{Path: "dot_gitconfig"}},
+				Ignored:    []FileStatus{{Path: "dot_cache"}},
+			},
+		},
+		{
+			name: "copies",
+			outputStr: "" +
+				"A dot_bashrc\n" +
+				"  dot_bash_profile\n" +
+				"A dot_vimrc\n" +
+				"R dot_bash_profile\n",
+			expectedStatus: &Status{
+				Added: []FileStatus{
+					{Path: "dot_bashrc", Source: "dot_bash_profile"},
+					{Path: "dot_vimrc"},
+				},
+				Removed: []FileStatus{{Path: "dot_bash_profile"}},
+			},
+		},
+		{
+			name:      "path_with_spaces",
+			outputStr: "M private_Library/Application Support/Code/User/settings.json\n",
+			expectedStatus: &Status{
+				Modified: []FileStatus{{Path: "private_Library/Application Support/Code/User/settings.json"}},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actualStatus, err := ParseStatus([]byte(tc.outputStr))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedStatus, actualStatus)
+			assert.Equal(t, tc.expectedEmpty, actualStatus.Empty())
+		})
+	}
+}
+
+func TestParseStatusErrors(t *testing.T) {
+	for _, outputStr := range []string{
+		"X dot_bashrc\n",
+		"M\n",
+		"  dot_bash_profile\n",
+		"M dot_bashrc\n  dot_bash_profile\n",
+	} {
+		_, err := ParseStatus([]byte(outputStr))
+		assert.Error(t, err, outputStr)
+	}
+}
+
+func TestParseStatusJSON(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		outputStr      string
+		expectedEmpty  bool
+		expectedStatus *Status
+		expectedErr    bool
+	}{
+		{
+			name:          "empty",
+			outputStr:     "[]\n",
+			expectedEmpty: true,
+		},
+		{
+			name: "copies",
+			outputStr: `[` +
+				`{"path": "dot_bashrc", "source": "dot_bash_profile", "status": "A"},` +
+				`{"path": "dot_bash_profile", "status": "R"},` +
+				`{"path": "dot_vimrc", "status": "M"}` +
+				`]`,
+			expectedStatus: &Status{
+				Modified: []FileStatus{{Path: "dot_vimrc"}},
+				Added:    []FileStatus{{Path: "dot_bashrc", Source: "dot_bash_profile"}},
+				Removed:  []FileStatus{{Path: "dot_bash_profile"}},
+			},
+		},
+		{
+			name:        "unknown_status",
+			outputStr:   `[{"path": "dot_bashrc", "status": "X"}]`,
+			expectedErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actualStatus, err := ParseStatusJSON([]byte(tc.outputStr))
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedStatus, actualStatus)
+			assert.Equal(t, tc.expectedEmpty, actualStatus.Empty())
+		})
+	}
+}